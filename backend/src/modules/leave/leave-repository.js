@@ -192,10 +192,16 @@ const getPendingLeaveRequests = async () => {
             t1.submitted_dt AS "submitted",
             t1.updated_dt AS "updated",
             t3.name AS user,
+            t1.user_id AS "userId",
+            t3.reporter_id AS "reporterId",
+            t3.role_id AS "roleId",
+            t5.name AS department,
             EXTRACT(DAY FROM age(t1.to_dt + INTERVAL '1 day', t1.from_dt)) AS days
         FROM user_leaves t1
         JOIN leave_policies t2 ON t1.leave_policy_id = t2.id
         JOIN users t3 ON t1.user_id = t3.id
+        LEFT JOIN user_profiles t4 ON t1.user_id = t4.user_id
+        LEFT JOIN departments t5 ON t4.department_id = t5.id
         WHERE t1.status = 1
         ORDER BY submitted_dt DESC
     `;
@@ -43,6 +43,7 @@ const getStaffDetailById = async (id) => {
             t1.is_active AS "systemAccess",
             t1.role_id AS role,
             t4.name AS "roleName",
+            t5.name AS department,
             t1.email,
             t1.reporter_id AS "reporterId",
             t2.name AS "reporterName",
@@ -62,6 +63,7 @@ const getStaffDetailById = async (id) => {
         LEFT JOIN users t2 ON t1.reporter_id = t2.id
         LEFT JOIN user_profiles t3 ON t1.id = t3.user_id
         LEFT JOIN roles t4 ON t1.role_id = t4.id
+        LEFT JOIN departments t5 ON t3.department_id = t5.id
         WHERE t1.id = $1
     `;
     const queryParams = [id];
@@ -0,0 +1,61 @@
+// Command verify-pdf checks the signature of PDF reports signed by the
+// service against the CA certificates that issue signing certificates.
+//
+//	go run ./cmd/verify-pdf -ca ca.pem transcript.pdf [more.pdf ...]
+//
+// It exits with status 1 if any document fails verification.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"go-service/internal/signing"
+)
+
+func main() {
+	caFile := flag.String("ca", "", "PEM file of the trusted CA certificates")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -ca ca.pem file.pdf [file.pdf ...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *caFile == "" || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	roots, err := signing.LoadRoots(*caFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	failed := false
+	for _, path := range flag.Args() {
+		document, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", path, err)
+			failed = true
+			continue
+		}
+
+		signature, err := signing.Verify(document, roots)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", path, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("✅ %s: signed by %s", path, signature.Signer.Subject.CommonName)
+		if !signature.SignedAt.IsZero() {
+			fmt.Printf(" on %s", signature.SignedAt.UTC().Format("2006-01-02 15:04:05 MST"))
+		}
+		fmt.Println()
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -4,4 +4,8 @@ go 1.23.3
 
 require github.com/gorilla/mux v1.8.1
 
-require github.com/jung-kurt/gofpdf v1.16.2 // indirect
+require github.com/jung-kurt/gofpdf v1.16.2
+
+require github.com/boombuler/barcode v1.1.0
+
+require github.com/digitorus/pkcs7 v0.0.0-20250730155240-ffadbf3f398c
@@ -1,10 +1,25 @@
 package main
 
 import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+
+	"go-service/pkg/models"
 )
 
 func TestMain(m *testing.M) {
@@ -72,8 +87,9 @@ func TestHealthCheckWithMockBackend(t *testing.T) {
 		}
 		defer resp.Body.Close()
 
-		// In test mode, health should be healthy because test tokens are pre-set
-		// This tests that the health endpoint works regardless of per-request auth
+		// Health should be healthy because the service logs in as the tests'
+		// service account. This tests that the health endpoint works
+		// regardless of per-request auth
 		ValidateHealthResponse(t, resp, true)
 	})
 }
@@ -325,8 +341,8 @@ func TestAuthenticationMethods(t *testing.T) {
 				req.AddCookie(&http.Cookie{Name: "accessToken", Value: "invalid_token"})
 				req.Header.Set("X-CSRF-Token", config.TestCSRFToken)
 			},
-			expectSuccess: true, // In test mode, pre-set test tokens override request tokens
-			description:   "Invalid access token (overridden by test mode)",
+			expectSuccess: true, // The mock server accepts any access token it has not expired
+			description:   "Invalid access token (accepted by the mock server)",
 		},
 	}
 
@@ -365,8 +381,100 @@ func TestAuthenticationMethods(t *testing.T) {
 	}
 }
 
-// TestErrorHandling tests various error scenarios
-func TestErrorHandling(t *testing.T) {
+// TestConcurrentUsersDoNotShareTokens verifies that interleaved requests from
+// different users reach the Node.js API with their own credentials only
+func TestConcurrentUsersDoNotShareTokens(t *testing.T) {
+	// Start mock Node.js server that records the credentials it receives
+	requestLog := &MockRequestLog{}
+	mockServer := MockNodejsServerWithLog(requestLog)
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+
+	// Set up environment
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+
+	// Start Go service test server
+	testServer := CreateTestServer()
+	defer testServer.Close()
+
+	// Each user only ever requests their own student, so the mock can tell
+	// which credentials every backend call should have carried
+	users := []struct {
+		accessToken string
+		csrfToken   string
+		studentID   string
+	}{
+		{accessToken: "user-a-access-token", csrfToken: "user-a-csrf-token", studentID: "1"},
+		{accessToken: "user-b-access-token", csrfToken: "user-b-csrf-token", studentID: "2"},
+	}
+	requestsPerUser := 25
+
+	var wg sync.WaitGroup
+	errors := make(chan error, requestsPerUser*len(users))
+	client := &http.Client{}
+
+	// Interleave the two users' requests
+	for i := 0; i < requestsPerUser; i++ {
+		for _, user := range users {
+			wg.Add(1)
+			go func(accessToken, csrfToken, studentID string) {
+				defer wg.Done()
+
+				url := fmt.Sprintf("%s/api/v1/students/%s/report", testServer.URL, studentID)
+				req, err := http.NewRequest("GET", url, nil)
+				if err != nil {
+					errors <- err
+					return
+				}
+				req.AddCookie(&http.Cookie{Name: "accessToken", Value: accessToken})
+				req.Header.Set("X-CSRF-Token", csrfToken)
+
+				resp, err := client.Do(req)
+				if err != nil {
+					errors <- err
+					return
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+
+				if resp.StatusCode != http.StatusOK {
+					errors <- fmt.Errorf("student %s: unexpected status %d", studentID, resp.StatusCode)
+				}
+			}(user.accessToken, user.csrfToken, user.studentID)
+		}
+	}
+
+	wg.Wait()
+	close(errors)
+	for err := range errors {
+		t.Error(err)
+	}
+
+	// Every backend call must carry the tokens of the user who owns the student
+	backendRequests := requestLog.Requests()
+	if len(backendRequests) != requestsPerUser*len(users) {
+		t.Errorf("Expected %d backend requests, got %d", requestsPerUser*len(users), len(backendRequests))
+	}
+	for _, recorded := range backendRequests {
+		for _, user := range users {
+			if recorded.Path != "/api/v1/students/"+user.studentID {
+				continue
+			}
+			if recorded.AccessToken != user.accessToken || recorded.CSRFToken != user.csrfToken {
+				t.Errorf("Request for %s leaked credentials: got access token %q and CSRF token %q",
+					recorded.Path, recorded.AccessToken, recorded.CSRFToken)
+			}
+		}
+	}
+}
+
+// TestClassRosterReport tests roster PDF generation from the filtered students list
+func TestClassRosterReport(t *testing.T) {
 	// Start mock Node.js server
 	mockServer := MockNodejsServer()
 	defer mockServer.Close()
@@ -375,7 +483,7 @@ func TestErrorHandling(t *testing.T) {
 	config := DefaultTestConfig()
 	config.NodejsAPIURL = mockServer.URL
 	config.UseRealBackend = false
-	
+
 	// Set up environment
 	cleanup := SetupTestEnvironment(config)
 	defer cleanup()
@@ -384,55 +492,1820 @@ func TestErrorHandling(t *testing.T) {
 	testServer := CreateTestServer()
 	defer testServer.Close()
 
-	errorCases := []struct {
-		name             string
-		studentID        string
-		expectedStatus   int
-		expectedError    string
-		description      string
-	}{
-		{
-			name:           "invalid_student_id_non_numeric",
-			studentID:      "abc",
-			expectedStatus: http.StatusInternalServerError,
-			expectedError:  "Failed to fetch student data",
-			description:    "Non-numeric student ID",
-		},
-		{
-			name:           "student_not_found",
-			studentID:      "999",
-			expectedStatus: http.StatusNotFound,
-			expectedError:  "Student not found",
-			description:    "Student ID that doesn't exist",
-		},
-		{
-			name:           "empty_student_id",
-			studentID:      "",
-			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Student ID is required",
-			description:    "Empty student ID",
-		},
+	client := &http.Client{}
+
+	t.Run("multi_page_roster", func(t *testing.T) {
+		url := testServer.URL + "/api/v1/classes/Grade%209/sections/B/roster"
+
+		req, err := MakeAuthenticatedRequest("GET", url, nil, config)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			t.Fatalf("Expected status 200, got: %d, body: %s", resp.StatusCode, string(body))
+		}
+
+		pdfBytes := ValidatePDFResponse(t, resp)
+
+		// The page tree is written uncompressed, so the page count can be read directly
+		match := regexp.MustCompile(`/Count (\d+)`).FindSubmatch(pdfBytes)
+		if match == nil {
+			t.Fatal("Could not find page count in roster PDF")
+		}
+		if pages, _ := strconv.Atoi(string(match[1])); pages < 2 {
+			t.Errorf("Expected roster of %d students to span multiple pages, got %d", MockRosterStudentCount, pages)
+		}
+	})
+
+	t.Run("empty_roster", func(t *testing.T) {
+		url := testServer.URL + "/api/v1/classes/Grade%201/sections/Z/roster"
+
+		req, err := MakeAuthenticatedRequest("GET", url, nil, config)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200 for an empty roster, got: %d", resp.StatusCode)
+		}
+
+		ValidatePDFResponse(t, resp)
+	})
+
+	t.Run("no_authentication", func(t *testing.T) {
+		url := testServer.URL + "/api/v1/classes/Grade%209/sections/B/roster"
+
+		req, err := MakeUnauthenticatedRequest("GET", url, nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusOK {
+			t.Error("Expected authentication error, but request succeeded")
+		}
+	})
+}
+
+// TestBulkStudentReports tests the bulk report ZIP export
+func TestBulkStudentReports(t *testing.T) {
+	// Start mock Node.js server
+	mockServer := MockNodejsServer()
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+
+	// Set up environment
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+
+	// Start Go service test server
+	testServer := CreateTestServer()
+	defer testServer.Close()
+
+	client := &http.Client{}
+	url := testServer.URL + "/api/v1/reports/students/bulk"
+
+	// postBulk sends a bulk report request with the given JSON body
+	postBulk := func(t *testing.T, body string) *http.Response {
+		t.Helper()
+		req, err := MakeAuthenticatedRequest("POST", url, strings.NewReader(body), config)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp
 	}
 
-	for _, tc := range errorCases {
-		t.Run(tc.name, func(t *testing.T) {
-			url := testServer.URL + "/api/v1/students/" + tc.studentID + "/report"
-			
-			req, err := MakeAuthenticatedRequest("GET", url, nil, config)
-			if err != nil {
-				t.Fatalf("Failed to create request: %v", err)
+	t.Run("student_ids_with_failures", func(t *testing.T) {
+		resp := postBulk(t, `{"studentIds":[1,2,999]}`)
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got: %d", resp.StatusCode)
+		}
+
+		files, manifest := ReadBulkArchive(t, resp)
+		for _, name := range []string{"student_1_report.pdf", "student_2_report.pdf"} {
+			if !bytes.HasPrefix(files[name], []byte("%PDF")) {
+				t.Errorf("Expected %s to be a PDF in the archive", name)
 			}
+		}
 
-			client := &http.Client{}
+		if manifest.Requested != 3 || len(manifest.Succeeded) != 2 {
+			t.Errorf("Expected 3 requested and 2 succeeded, got %d and %d", manifest.Requested, len(manifest.Succeeded))
+		}
+		if len(manifest.Failures) != 1 || manifest.Failures[0].StudentID != "999" {
+			t.Errorf("Expected student 999 to be listed as the only failure, got %+v", manifest.Failures)
+		}
+	})
+
+	t.Run("class_section_filter", func(t *testing.T) {
+		resp := postBulk(t, `{"className":"Grade 9","section":"B"}`)
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got: %d", resp.StatusCode)
+		}
+
+		files, manifest := ReadBulkArchive(t, resp)
+		if len(manifest.Succeeded) != MockRosterStudentCount || len(manifest.Failures) != 0 {
+			t.Errorf("Expected %d reports without failures, got %d succeeded and %d failed",
+				MockRosterStudentCount, len(manifest.Succeeded), len(manifest.Failures))
+		}
+		if len(files) != MockRosterStudentCount+1 {
+			t.Errorf("Expected %d archive entries, got %d", MockRosterStudentCount+1, len(files))
+		}
+	})
+
+	t.Run("invalid_requests", func(t *testing.T) {
+		for _, body := range []string{
+			`not json`,
+			`{}`,
+			`{"studentIds":[1],"className":"Grade 9","section":"B"}`,
+			`{"className":"Grade 9"}`,
+		} {
+			resp := postBulk(t, body)
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for body %s, got: %d", body, resp.StatusCode)
+			}
+		}
+	})
+
+	t.Run("unknown_class", func(t *testing.T) {
+		resp := postBulk(t, `{"className":"Grade 1","section":"Z"}`)
+		defer resp.Body.Close()
+
+		ValidateErrorResponse(t, resp, http.StatusNotFound, "No students found")
+	})
+}
+
+// TestAsyncReportJobs tests that report requests with async=true are queued
+// as jobs whose status can be polled and whose artifact can be downloaded
+func TestAsyncReportJobs(t *testing.T) {
+	// Start mock Node.js server
+	mockServer := MockNodejsServer()
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+
+	// Set up environment
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+
+	// Start Go service test server
+	testServer := CreateTestServer()
+	defer testServer.Close()
+
+	client := &http.Client{}
+
+	t.Run("bulk_reports_job", func(t *testing.T) {
+		req, err := MakeAuthenticatedRequest("POST", testServer.URL+"/api/v1/reports/students/bulk?async=true",
+			strings.NewReader(`{"className":"Grade 9","section":"B"}`), config)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		accepted := DecodeJobAccepted(t, resp)
+
+		job := WaitForJob(t, testServer.URL+accepted.StatusURL, config)
+		if job.Status != "done" {
+			t.Fatalf("Expected job to be done, got %s (%s)", job.Status, job.Error)
+		}
+		if job.Progress.Done != MockRosterStudentCount || job.Progress.Total != MockRosterStudentCount {
+			t.Errorf("Expected progress %d/%d, got %d/%d", MockRosterStudentCount, MockRosterStudentCount, job.Progress.Done, job.Progress.Total)
+		}
+
+		req, _ = MakeAuthenticatedRequest("GET", testServer.URL+accepted.ResultURL, nil, config)
+		resp, err = client.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to download job result: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got: %d", resp.StatusCode)
+		}
+		_, manifest := ReadBulkArchive(t, resp)
+		if len(manifest.Succeeded) != MockRosterStudentCount {
+			t.Errorf("Expected %d reports, got %d", MockRosterStudentCount, len(manifest.Succeeded))
+		}
+	})
+
+	t.Run("roster_job", func(t *testing.T) {
+		req, _ := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/classes/Grade%209/sections/B/roster?async=true", nil, config)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		accepted := DecodeJobAccepted(t, resp)
+
+		job := WaitForJob(t, testServer.URL+accepted.StatusURL, config)
+		if job.Status != "done" {
+			t.Fatalf("Expected job to be done, got %s (%s)", job.Status, job.Error)
+		}
+
+		req, _ = MakeAuthenticatedRequest("GET", testServer.URL+accepted.ResultURL, nil, config)
+		resp, err = client.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to download job result: %v", err)
+		}
+		defer resp.Body.Close()
+
+		ValidatePDFResponse(t, resp)
+	})
+
+	t.Run("other_users_job", func(t *testing.T) {
+		req, _ := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/classes/Grade%209/sections/B/roster?async=true", nil, config)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		accepted := DecodeJobAccepted(t, resp)
+		WaitForJob(t, testServer.URL+accepted.StatusURL, config)
+
+		// Knowing the job ID is not enough to see another user's job
+		other := *config
+		other.TestAccessToken = MockAccessToken(2, MockTeacherRoleID, "teacher")
+		for _, path := range []string{accepted.StatusURL, accepted.ResultURL} {
+			req, _ := MakeAuthenticatedRequest("GET", testServer.URL+path, nil, &other)
 			resp, err := client.Do(req)
 			if err != nil {
 				t.Fatalf("Failed to make request: %v", err)
 			}
-			defer resp.Body.Close()
+			ValidateErrorResponse(t, resp, http.StatusNotFound, "Job not found")
+			resp.Body.Close()
+		}
+	})
 
-			ValidateErrorResponse(t, resp, tc.expectedStatus, tc.expectedError)
-		})
+	t.Run("unknown_job", func(t *testing.T) {
+		for _, path := range []string{"/api/v1/jobs/unknown", "/api/v1/jobs/unknown/result"} {
+			req, _ := MakeAuthenticatedRequest("GET", testServer.URL+path, nil, config)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			ValidateErrorResponse(t, resp, http.StatusNotFound, "Job not found")
+			resp.Body.Close()
+		}
+	})
+
+	t.Run("no_authentication", func(t *testing.T) {
+		req, _ := MakeUnauthenticatedRequest("GET", testServer.URL+"/api/v1/jobs/unknown", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got: %d", resp.StatusCode)
+		}
+	})
+}
+
+// TestReportTemplates tests selecting a student report layout with ?template=
+func TestReportTemplates(t *testing.T) {
+	// Start mock Node.js server
+	mockServer := MockNodejsServer()
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+
+	// Set up environment with a school-specific template
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+
+	templatesDir := t.TempDir()
+	template := `{"name":"compact","title":"PUPIL SUMMARY","sections":[{"title":"PUPIL","fields":[{"label":"Pupil","path":"name"}]}]}`
+	if err := os.WriteFile(templatesDir+"/compact.json", []byte(template), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	t.Setenv("REPORT_TEMPLATES_DIR", templatesDir)
+
+	// Start Go service test server
+	testServer := CreateTestServer()
+	defer testServer.Close()
+
+	client := &http.Client{}
+
+	t.Run("custom_template", func(t *testing.T) {
+		req, _ := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/students/1/report?template=compact", nil, config)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got: %d", resp.StatusCode)
+		}
+		ValidatePDFResponse(t, resp)
+	})
+
+	t.Run("default_template", func(t *testing.T) {
+		req, _ := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/students/1/report?template=default", nil, config)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		ValidatePDFResponse(t, resp)
+	})
+
+	t.Run("unknown_template", func(t *testing.T) {
+		for _, tc := range []struct{ method, path, body string }{
+			{"GET", "/api/v1/students/1/report?template=missing", ""},
+			{"POST", "/api/v1/reports/students/bulk?template=missing", `{"studentIds":[1]}`},
+		} {
+			req, _ := MakeAuthenticatedRequest(tc.method, testServer.URL+tc.path, strings.NewReader(tc.body), config)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			ValidateErrorResponse(t, resp, http.StatusBadRequest, "Unknown report template")
+			resp.Body.Close()
+		}
+	})
+}
+
+// TestMultilingualStudentReports tests reports for students with accented,
+// Devanagari, Arabic and CJK names, with and without embedded UTF-8 fonts
+func TestMultilingualStudentReports(t *testing.T) {
+	// Start mock Node.js server
+	mockServer := MockNodejsServer()
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+
+	// Set up environment
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+
+	students := MockMultilingualStudents()
+	if len(students) == 0 {
+		t.Fatalf("Expected multilingual fixtures in %s", MultilingualStudentsFixture)
+	}
+
+	// fetchReports requests every multilingual report from a fresh service
+	fetchReports := func(t *testing.T) [][]byte {
+		testServer := CreateTestServer()
+		defer testServer.Close()
+
+		var reports [][]byte
+		for _, student := range students {
+			url := fmt.Sprintf("%s/api/v1/students/%d/report", testServer.URL, student.ID)
+			req, _ := MakeAuthenticatedRequest("GET", url, nil, config)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("Expected status 200 for student %d, got: %d", student.ID, resp.StatusCode)
+			}
+			reports = append(reports, ValidatePDFResponse(t, resp))
+			resp.Body.Close()
+		}
+		return reports
+	}
+
+	t.Run("core_fonts", func(t *testing.T) {
+		t.Setenv("PDF_FONTS_DIR", t.TempDir())
+		fetchReports(t)
+	})
+
+	t.Run("embedded_fonts", func(t *testing.T) {
+		fontFile := "/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf"
+		if _, err := os.Stat(fontFile); err != nil {
+			t.Skipf("DejaVu fonts not installed: %v", err)
+		}
+
+		fontsDir := t.TempDir()
+		manifest := fmt.Sprintf(`{"default":{"regular":%q},"fallbacks":[{"scripts":["Arabic"],"regular":%q}]}`, fontFile, fontFile)
+		if err := os.WriteFile(fontsDir+"/fonts.json", []byte(manifest), 0o644); err != nil {
+			t.Fatalf("Failed to write font manifest: %v", err)
+		}
+		t.Setenv("PDF_FONTS_DIR", fontsDir)
+
+		for i, report := range fetchReports(t) {
+			if !bytes.Contains(report, []byte("/FontFile2")) {
+				t.Errorf("Expected an embedded TrueType font in the report for student %d", students[i].ID)
+			}
+		}
+	})
+
+	t.Run("roster", func(t *testing.T) {
+		testServer := CreateTestServer()
+		defer testServer.Close()
+
+		req, _ := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/classes/Grade%208/sections/C/roster", nil, config)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		ValidatePDFResponse(t, resp)
+	})
+}
+
+// TestBrandingReload tests that branding changes apply to reports without a restart
+func TestBrandingReload(t *testing.T) {
+	// Start mock Node.js server
+	mockServer := MockNodejsServer()
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+
+	// Set up environment with a branding config
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+
+	brandingDir := t.TempDir()
+	brandingConfig := brandingDir + "/branding.json"
+	WriteTestLogo(t, brandingDir+"/logo.png")
+	t.Setenv("BRANDING_CONFIG", brandingConfig)
+
+	// Start Go service test server
+	testServer := CreateTestServer()
+	defer testServer.Close()
+
+	// fetchReport returns a student report from the running service
+	fetchReport := func(t *testing.T) []byte {
+		t.Helper()
+		req, _ := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/students/1/report", nil, config)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+		return ValidatePDFResponse(t, resp)
+	}
+
+	if bytes.Contains(fetchReport(t), []byte("/Subtype /Image")) {
+		t.Error("Expected no logo without a branding config")
+	}
+
+	branding := `{"schoolName":"Springfield High","address":["742 Evergreen Terrace"],"logo":"logo.png","accentColor":"#1F4E79"}`
+	if err := os.WriteFile(brandingConfig, []byte(branding), 0o644); err != nil {
+		t.Fatalf("Failed to write branding config: %v", err)
+	}
+
+	if !bytes.Contains(fetchReport(t), []byte("/Subtype /Image")) {
+		t.Error("Expected the logo once the branding config is written")
+	}
+
+	// The roster and bulk archive reports are branded too
+	req, _ := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/classes/Grade%2010/sections/A/roster", nil, config)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+	if !bytes.Contains(ValidatePDFResponse(t, resp), []byte("/Subtype /Image")) {
+		t.Error("Expected the logo on the class roster")
+	}
+}
+
+// TestErrorHandling tests various error scenarios
+func TestErrorHandling(t *testing.T) {
+	// Start mock Node.js server
+	mockServer := MockNodejsServer()
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+	
+	// Set up environment
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+
+	// Start Go service test server
+	testServer := CreateTestServer()
+	defer testServer.Close()
+
+	errorCases := []struct {
+		name             string
+		studentID        string
+		expectedStatus   int
+		expectedError    string
+		description      string
+	}{
+		{
+			name:           "invalid_student_id_non_numeric",
+			studentID:      "abc",
+			expectedStatus: http.StatusInternalServerError,
+			expectedError:  "Failed to fetch student data",
+			description:    "Non-numeric student ID",
+		},
+		{
+			name:           "student_not_found",
+			studentID:      "999",
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Student not found",
+			description:    "Student ID that doesn't exist",
+		},
+		{
+			name:           "empty_student_id",
+			studentID:      "",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Student ID is required",
+			description:    "Empty student ID",
+		},
+	}
+
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url := testServer.URL + "/api/v1/students/" + tc.studentID + "/report"
+			
+			req, err := MakeAuthenticatedRequest("GET", url, nil, config)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			client := &http.Client{}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			ValidateErrorResponse(t, resp, tc.expectedStatus, tc.expectedError)
+		})
+	}
+}
+
+// TestBackendAuthErrors tests that authentication and authorization failures
+// from the Node.js API are passed through instead of becoming a 500
+func TestBackendAuthErrors(t *testing.T) {
+	// Start mock Node.js server
+	mockServer := MockNodejsServer()
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+
+	// Set up environment
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+
+	// Start Go service test server
+	testServer := CreateTestServer()
+	defer testServer.Close()
+
+	t.Run("expired_access_token", func(t *testing.T) {
+		expired := *config
+		expired.TestAccessToken = MockExpiredAccessToken
+		expired.TestRefreshToken = "revoked-refresh-token"
+
+		req, err := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/students/1/report", nil, &expired)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		ValidateErrorResponse(t, resp, http.StatusUnauthorized, "Authentication required")
+	})
+
+	t.Run("missing_csrf_token", func(t *testing.T) {
+		// Only the access token cookie, without the CSRF cookie or header
+		req, err := MakeUnauthenticatedRequest("GET", testServer.URL+"/api/v1/students/1/report", nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		req.AddCookie(&http.Cookie{Name: "accessToken", Value: config.TestAccessToken})
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		ValidateErrorResponse(t, resp, http.StatusForbidden, "Access denied")
+	})
+}
+
+// TestAccessTokenRefresh tests that an expired access token is refreshed once
+// with the caller's refresh token and the rotated cookies are returned
+func TestAccessTokenRefresh(t *testing.T) {
+	// Start mock Node.js server that records the credentials it receives
+	requestLog := &MockRequestLog{}
+	mockServer := MockNodejsServerWithLog(requestLog)
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+	config.TestAccessToken = MockExpiredAccessToken
+
+	// Set up environment
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+
+	// Start Go service test server
+	testServer := CreateTestServer()
+	defer testServer.Close()
+
+	// refreshCount counts the refresh calls received by the mock so far
+	refreshCount := func() int {
+		count := 0
+		for _, req := range requestLog.Requests() {
+			if req.Path == "/api/v1/auth/refresh" {
+				count++
+			}
+		}
+		return count
+	}
+
+	t.Run("student_report", func(t *testing.T) {
+		before := refreshCount()
+
+		req, err := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/students/1/report", nil, config)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		ValidatePDFResponse(t, resp)
+
+		cookies := make(map[string]string)
+		for _, cookie := range resp.Cookies() {
+			cookies[cookie.Name] = cookie.Value
+		}
+		if cookies["accessToken"] != MockRefreshedAccessToken || cookies["csrfToken"] != MockRefreshedCSRFToken {
+			t.Errorf("Expected the rotated cookies on the response, got %v", cookies)
+		}
+		if got := refreshCount() - before; got != 1 {
+			t.Errorf("Expected a single refresh, got %d", got)
+		}
+
+		// The retried request carries the rotated tokens
+		requests := requestLog.Requests()
+		last := requests[len(requests)-1]
+		if last.AccessToken != MockRefreshedAccessToken || last.CSRFToken != MockRefreshedCSRFToken {
+			t.Errorf("Expected the retry to use the rotated tokens, got %+v", last)
+		}
+	})
+
+	t.Run("bulk_reports_share_one_refresh", func(t *testing.T) {
+		before := refreshCount()
+
+		body := strings.NewReader(`{"studentIds":[1,2,3,4]}`)
+		req, err := MakeAuthenticatedRequest("POST", testServer.URL+"/api/v1/reports/students/bulk", body, config)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		_, manifest := ReadBulkArchive(t, resp)
+		if len(manifest.Succeeded) != 4 || len(manifest.Failures) != 0 {
+			t.Errorf("Expected all reports to succeed after the refresh, got %+v", manifest)
+		}
+		if got := refreshCount() - before; got != 1 {
+			t.Errorf("Expected a single refresh for the whole batch, got %d", got)
+		}
+	})
+
+	t.Run("invalid_refresh_token", func(t *testing.T) {
+		revoked := *config
+		revoked.TestRefreshToken = "revoked-refresh-token"
+		req, err := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/students/1/report", nil, &revoked)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		ValidateErrorResponse(t, resp, http.StatusUnauthorized, "Authentication required")
+		if len(resp.Cookies()) != 0 {
+			t.Errorf("Expected no cookies after a failed refresh, got %v", resp.Cookies())
+		}
+	})
+}
+
+// TestServiceAccountLogin tests that calls made without caller credentials
+// authenticate as the configured service account
+func TestServiceAccountLogin(t *testing.T) {
+	// Start mock Node.js server that records the credentials it receives
+	requestLog := &MockRequestLog{}
+	mockServer := MockNodejsServerWithLog(requestLog)
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+
+	// Set up environment without the tests' service account
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+	t.Setenv("SERVICE_ACCOUNT_FILE", "")
+
+	checkHealth := func(t *testing.T, expectedHealthy bool) {
+		testServer := CreateTestServer()
+		defer testServer.Close()
+
+		for i := 0; i < 2; i++ {
+			req, err := MakeUnauthenticatedRequest("GET", testServer.URL+"/health", nil)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			ValidateHealthResponse(t, resp, expectedHealthy)
+			resp.Body.Close()
+		}
+	}
+
+	// countPath counts the mock requests to path made with accessToken
+	countPath := func(path, accessToken string) int {
+		count := 0
+		for _, req := range requestLog.Requests() {
+			if req.Path == path && req.AccessToken == accessToken {
+				count++
+			}
+		}
+		return count
+	}
+
+	t.Run("logs_in_once", func(t *testing.T) {
+		t.Setenv("SERVICE_ACCOUNT_USERNAME", MockServiceAccountUsername)
+		t.Setenv("SERVICE_ACCOUNT_PASSWORD", MockServiceAccountPassword)
+
+		checkHealth(t, true)
+
+		if got := countPath("/api/v1/auth/login", ""); got != 1 {
+			t.Errorf("Expected a single login, got %d", got)
+		}
+		if got := countPath("/api/v1/dashboard", MockServiceAccessToken); got != 2 {
+			t.Errorf("Expected both health checks to use the service account, got %d", got)
+		}
+	})
+
+	t.Run("invalid_password", func(t *testing.T) {
+		t.Setenv("SERVICE_ACCOUNT_USERNAME", MockServiceAccountUsername)
+		t.Setenv("SERVICE_ACCOUNT_PASSWORD", "wrong-password")
+
+		checkHealth(t, false)
+	})
+}
+
+// TestStaffReport tests generating staff profile reports
+func TestStaffReport(t *testing.T) {
+	// Start mock Node.js server
+	mockServer := MockNodejsServer()
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+
+	// Set up environment
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+
+	// Start Go service test server
+	testServer := CreateTestServer()
+	defer testServer.Close()
+
+	t.Run("staff_profile", func(t *testing.T) {
+		req, err := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/staffs/7/report", nil, config)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		ValidatePDFResponse(t, resp)
+		if disposition := resp.Header.Get("Content-Disposition"); !strings.Contains(disposition, "staff_7_report.pdf") {
+			t.Errorf("Unexpected Content-Disposition: %s", disposition)
+		}
+	})
+
+	t.Run("unclean_path", func(t *testing.T) {
+		// Paths are cleaned with a redirect before they are routed
+		req, err := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1//staffs/7/./report", nil, config)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		ValidatePDFResponse(t, resp)
+	})
+
+	errorCases := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedError  string
+	}{
+		{"staff_not_found", "/api/v1/staffs/999/report", http.StatusNotFound, "Staff not found"},
+		{"empty_staff_id", "/api/v1/staffs//report", http.StatusBadRequest, "Staff ID is required"},
+		{"student_template", "/api/v1/staffs/7/report?template=default", http.StatusBadRequest, "Unknown report template"},
+	}
+
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := MakeAuthenticatedRequest("GET", testServer.URL+tc.path, nil, config)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			ValidateErrorResponse(t, resp, tc.expectedStatus, tc.expectedError)
+		})
+	}
+}
+
+// TestLeaveReport tests the leave history and balance report endpoint
+func TestLeaveReport(t *testing.T) {
+	// Start mock Node.js server
+	mockServer := MockNodejsServer()
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+
+	// Set up environment
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+
+	// Start Go service test server
+	testServer := CreateTestServer()
+	defer testServer.Close()
+
+	reportCases := []struct {
+		name     string
+		path     string
+		filename string
+	}{
+		{"own_report", fmt.Sprintf("/api/v1/leave/users/%d/report?year=2024", MockLeaveUserID), "leave_1_2024.pdf"},
+		{"me", "/api/v1/leave/users/me/report?year=2023", "leave_1_2023.pdf"},
+	}
+
+	for _, tc := range reportCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := MakeAuthenticatedRequest("GET", testServer.URL+tc.path, nil, config)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			ValidatePDFResponse(t, resp)
+			if disposition := resp.Header.Get("Content-Disposition"); !strings.Contains(disposition, tc.filename) {
+				t.Errorf("Unexpected Content-Disposition: %s", disposition)
+			}
+		})
+	}
+
+	errorCases := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedError  string
+	}{
+		{"other_user", "/api/v1/leave/users/2/report", http.StatusForbidden, "Leave reports are only available for your own account"},
+		{"invalid_year", "/api/v1/leave/users/me/report?year=last", http.StatusBadRequest, "Invalid academic year"},
+		{"empty_user_id", "/api/v1/leave/users//report", http.StatusBadRequest, "User ID is required"},
+	}
+
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := MakeAuthenticatedRequest("GET", testServer.URL+tc.path, nil, config)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			ValidateErrorResponse(t, resp, tc.expectedStatus, tc.expectedError)
+		})
+	}
+}
+
+// TestLeaveDigest tests the pending leave approvals digest endpoint
+func TestLeaveDigest(t *testing.T) {
+	// Start mock Node.js server
+	mockServer := MockNodejsServer()
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+
+	// Set up environment
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+
+	// Start Go service test server
+	testServer := CreateTestServer()
+	defer testServer.Close()
+
+	t.Run("pdf", func(t *testing.T) {
+		req, err := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/leave/pending/digest", nil, config)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		ValidatePDFResponse(t, resp)
+	})
+
+	t.Run("html", func(t *testing.T) {
+		req, err := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/leave/pending/digest?format=html", nil, config)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+			t.Errorf("Expected HTML content type, got %s", contentType)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("Failed to read response body: %v", err)
+		}
+		for _, request := range MockPendingLeaves() {
+			if listed := strings.Contains(string(body), request.User); listed != (request.ReporterID == 1) {
+				t.Errorf("Expected digest listing %s to be %v, got %v", request.User, request.ReporterID == 1, listed)
+			}
+		}
+		if !strings.Contains(string(body), "Primary") {
+			t.Error("Expected digest to show the requesters' department")
+		}
+		if strings.Count(string(body), `class="highlight"`) != 3 {
+			t.Error("Expected the overlap and both overlapping requests to be highlighted")
+		}
+	})
+
+	t.Run("unsupported_format", func(t *testing.T) {
+		req, err := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/leave/pending/digest?format=docx", nil, config)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		ValidateErrorResponse(t, resp, http.StatusBadRequest, "Unsupported digest format")
+	})
+}
+
+// TestNoticeBulletin tests the notice board bulletin endpoint
+func TestNoticeBulletin(t *testing.T) {
+	// Start mock Node.js server with request logging
+	requestLog := &MockRequestLog{}
+	mockServer := MockNodejsServerWithLog(requestLog)
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+
+	// Set up environment
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+
+	// Start Go service test server
+	testServer := CreateTestServer()
+	defer testServer.Close()
+
+	t.Run("week_for_students", func(t *testing.T) {
+		req, err := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/notices/bulletin?from=2024-05-06&to=2024-05-12&roleId=3", nil, config)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		ValidatePDFResponse(t, resp)
+		if disposition := resp.Header.Get("Content-Disposition"); !strings.Contains(disposition, "notices_2024-05-06_2024-05-12.pdf") {
+			t.Errorf("Unexpected Content-Disposition: %s", disposition)
+		}
+
+		// Only the approved notices in the range are fetched in full
+		details := 0
+		for _, entry := range requestLog.Requests() {
+			if strings.HasPrefix(entry.Path, "/api/v1/notices/") {
+				details++
+			}
+		}
+		if details != 2 {
+			t.Errorf("Expected 2 notice detail requests, got %d", details)
+		}
+	})
+
+	errorCases := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedError  string
+	}{
+		{"invalid_date", "?from=May", http.StatusBadRequest, "Invalid date, expected YYYY-MM-DD"},
+		{"invalid_recipient_type", "?recipientType=ALL", http.StatusBadRequest, "Invalid recipient type, expected EV or SP"},
+	}
+
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/notices/bulletin"+tc.query, nil, config)
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			ValidateErrorResponse(t, resp, tc.expectedStatus, tc.expectedError)
+		})
+	}
+}
+
+// TestDashboardReport tests the dashboard summary endpoint
+func TestDashboardReport(t *testing.T) {
+	// Start mock Node.js server
+	mockServer := MockNodejsServer()
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+
+	// Set up environment
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+
+	// Start Go service test server
+	testServer := CreateTestServer()
+	defer testServer.Close()
+
+	t.Run("summary", func(t *testing.T) {
+		req, err := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/reports/dashboard", nil, config)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		ValidatePDFResponse(t, resp)
+	})
+
+	t.Run("expired_token", func(t *testing.T) {
+		expired := *config
+		expired.TestAccessToken = MockExpiredAccessToken
+		expired.TestRefreshToken = "revoked-refresh-token"
+		req, err := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/reports/dashboard", nil, &expired)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		ValidateErrorResponse(t, resp, http.StatusUnauthorized, "Authentication required")
+	})
+}
+
+// TestClassTeacherReport tests the class teacher matrix endpoint in both formats
+func TestClassTeacherReport(t *testing.T) {
+	// Start mock Node.js server
+	mockServer := MockNodejsServer()
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+
+	// Set up environment
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+
+	// Start Go service test server
+	testServer := CreateTestServer()
+	defer testServer.Close()
+
+	t.Run("pdf", func(t *testing.T) {
+		req, err := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/reports/class-teachers", nil, config)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		ValidatePDFResponse(t, resp)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		req, err := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/reports/class-teachers?format=csv", nil, config)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/csv") {
+			t.Errorf("Expected CSV content type, got %s", contentType)
+		}
+
+		records, err := csv.NewReader(resp.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to parse CSV: %v", err)
+		}
+		want := [][]string{
+			{"Class", "A", "B", "C", "Flags"},
+			{"Grade 8", "Edna Krabappel", "Elizabeth Hoover", "", "Multiple sections: Edna Krabappel"},
+			{"Grade 9", "Edna Krabappel", "Dewey Largo", "UNASSIGNED", "Unassigned: C; Multiple sections: Edna Krabappel"},
+		}
+		if !reflect.DeepEqual(records, want) {
+			t.Errorf("Expected %v, got %v", want, records)
+		}
+	})
+
+	t.Run("unsupported_format", func(t *testing.T) {
+		req, err := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/reports/class-teachers?format=doc", nil, config)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		ValidateErrorResponse(t, resp, http.StatusBadRequest, "Unsupported report format")
+	})
+}
+
+// TestReportFormats tests choosing the export format of a report with
+// ?format= or the Accept header
+func TestReportFormats(t *testing.T) {
+	// Start mock Node.js server
+	mockServer := MockNodejsServer()
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+
+	// Set up environment
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+
+	// Start Go service test server
+	testServer := CreateTestServer()
+	defer testServer.Close()
+
+	get := func(t *testing.T, path, accept string) *http.Response {
+		req, err := MakeAuthenticatedRequest("GET", testServer.URL+path, nil, config)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("csv_parameter", func(t *testing.T) {
+		resp := get(t, "/api/v1/students/2/report?format=csv", "")
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		if disposition := resp.Header.Get("Content-Disposition"); disposition != "attachment; filename=student_2_report.csv" {
+			t.Errorf("Unexpected Content-Disposition %s", disposition)
+		}
+		records, err := csv.NewReader(resp.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to parse CSV: %v", err)
+		}
+		if len(records) < 2 || !reflect.DeepEqual(records[0], []string{"Section", "Field", "Value"}) {
+			t.Fatalf("Unexpected CSV %v", records)
+		}
+		found := false
+		for _, record := range records {
+			if record[1] == "Full Name" && record[2] == "Alice Johnson" {
+				found = true
+			}
+		}
+		if !found {
+			t.Error("Expected the CSV to list the student's name like the PDF")
+		}
+	})
+
+	t.Run("json_accept", func(t *testing.T) {
+		resp := get(t, "/api/v1/students/2/report", "application/json")
+		defer resp.Body.Close()
+
+		if resp.Header.Get("Content-Type") != "application/json" {
+			t.Fatalf("Expected JSON, got %s", resp.Header.Get("Content-Type"))
+		}
+		var student models.Student
+		if err := json.NewDecoder(resp.Body).Decode(&student); err != nil || student.Name != "Alice Johnson" {
+			t.Errorf("Unexpected student %+v (%v)", student, err)
+		}
+	})
+
+	t.Run("xlsx_roster", func(t *testing.T) {
+		resp := get(t, "/api/v1/classes/Grade%209/sections/B/roster", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("Failed to read response: %v", err)
+		}
+		archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+		if err != nil {
+			t.Fatalf("Expected an XLSX workbook, got error: %v", err)
+		}
+		for _, file := range archive.File {
+			if file.Name != "xl/worksheets/sheet1.xml" {
+				continue
+			}
+			rc, _ := file.Open()
+			sheet, _ := io.ReadAll(rc)
+			rc.Close()
+			if rows := strings.Count(string(sheet), "<row "); rows != MockRosterStudentCount+1 {
+				t.Errorf("Expected a header and %d student rows, got %d rows", MockRosterStudentCount, rows)
+			}
+			return
+		}
+		t.Error("Expected the workbook to contain a roster worksheet")
+	})
+
+	t.Run("browser_gets_pdf", func(t *testing.T) {
+		resp := get(t, "/api/v1/reports/dashboard", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+		defer resp.Body.Close()
+
+		ValidatePDFResponse(t, resp)
+	})
+
+	t.Run("html_parameter", func(t *testing.T) {
+		resp := get(t, "/api/v1/reports/class-teachers?format=html", "")
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+			t.Fatalf("Expected HTML, got %s", contentType)
+		}
+		if disposition := resp.Header.Get("Content-Disposition"); !strings.HasPrefix(disposition, "inline") {
+			t.Errorf("Expected the page to be shown inline, got %s", disposition)
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("Failed to read response: %v", err)
+		}
+		for _, want := range []string{"<h1>Class Teacher Assignments</h1>", `<td class="unassigned">UNASSIGNED</td>`, "Edna Krabappel *"} {
+			if !strings.Contains(string(body), want) {
+				t.Errorf("Expected the page to contain %q", want)
+			}
+		}
+	})
+
+	t.Run("not_acceptable", func(t *testing.T) {
+		resp := get(t, "/api/v1/students/2/report", "image/png")
+		defer resp.Body.Close()
+
+		ValidateErrorResponse(t, resp, http.StatusNotAcceptable, "No acceptable report format")
+	})
+
+	t.Run("unsupported_format", func(t *testing.T) {
+		resp := get(t, "/api/v1/notices/bulletin?format=docx", "")
+		defer resp.Body.Close()
+
+		ValidateErrorResponse(t, resp, http.StatusBadRequest, "Unsupported report format")
+	})
+}
+
+// TestReportVerification tests that issued reports can be checked by the
+// verification ID printed on them, without authentication
+func TestReportVerification(t *testing.T) {
+	// Start mock Node.js server
+	mockServer := MockNodejsServer()
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+
+	// Set up environment
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+
+	// Start Go service test server
+	testServer := CreateTestServer()
+	defer testServer.Close()
+
+	// Issue the same student report twice
+	var ids []string
+	var bodies [][]byte
+	for i := 0; i < 2; i++ {
+		req, err := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/students/2/report", nil, config)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		body := ValidatePDFResponse(t, resp)
+		resp.Body.Close()
+
+		ids = append(ids, PDFVerificationID(t, body))
+		bodies = append(bodies, body)
+	}
+
+	verifyDocument := func(t *testing.T, id string) (int, map[string]interface{}) {
+		resp, err := http.Get(testServer.URL + "/api/v1/verify/" + id)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		return resp.StatusCode, result
+	}
+
+	t.Run("superseded_report", func(t *testing.T) {
+		status, result := verifyDocument(t, ids[0])
+		if status != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %v", status, result)
+		}
+		if result["report"] != "student_report" || result["subjectId"] != "2" || result["subject"] != "A*** J***" {
+			t.Errorf("Expected the report of student 2 with the name masked, got %v", result)
+		}
+		if result["issuedAt"] == nil || result["superseded"] != true || result["supersededBy"] != ids[1] {
+			t.Errorf("Expected the first report to be superseded by %s, got %v", ids[1], result)
+		}
+		sum := sha256.Sum256(bodies[0])
+		if result["sha256"] != hex.EncodeToString(sum[:]) {
+			t.Errorf("Expected the hash of the issued PDF, got %v", result["sha256"])
+		}
+	})
+
+	t.Run("current_report", func(t *testing.T) {
+		status, result := verifyDocument(t, strings.ToLower(ids[1]))
+		if status != http.StatusOK || result["superseded"] != false || result["id"] != ids[1] {
+			t.Errorf("Expected the latest report to be current, got %d: %v", status, result)
+		}
+	})
+
+	t.Run("unknown_id", func(t *testing.T) {
+		resp, err := http.Get(testServer.URL + "/api/v1/verify/00000-00000")
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		ValidateErrorResponse(t, resp, http.StatusNotFound, "No report was issued with this verification ID")
+	})
+}
+
+// TestReportModes tests draft and official reports: drafts are watermarked
+// and not issued, and official reports need an elevated role and carry a
+// serial number
+func TestReportModes(t *testing.T) {
+	// Start mock Node.js server
+	mockServer := MockNodejsServer()
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+
+	// Set up environment
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+
+	// Start Go service test server
+	testServer := CreateTestServer()
+	defer testServer.Close()
+
+	request := func(t *testing.T, path, accessToken string) *http.Response {
+		t.Helper()
+		roleConfig := *config
+		if accessToken != "" {
+			roleConfig.TestAccessToken = accessToken
+		}
+		req, err := MakeAuthenticatedRequest("GET", testServer.URL+path, nil, &roleConfig)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("draft", func(t *testing.T) {
+		resp := request(t, "/api/v1/students/2/report?mode=draft", "")
+		defer resp.Body.Close()
+
+		content := PDFContent(ValidatePDFResponse(t, resp))
+		if !bytes.Contains(content, []byte("(DRAFT) Tj")) {
+			t.Error("Expected the draft watermark")
+		}
+		if bytes.Contains(content, []byte("Verification ID")) || bytes.Contains(content, []byte("Serial No.")) {
+			t.Error("Expected a draft not to be issued")
+		}
+	})
+
+	t.Run("official", func(t *testing.T) {
+		resp := request(t, "/api/v1/classes/Grade%2010/sections/A/roster?mode=official", "")
+		defer resp.Body.Close()
+
+		body := ValidatePDFResponse(t, resp)
+		match := regexp.MustCompile(`Serial No\. (\d{4}-\d{6})`).FindSubmatch(PDFContent(body))
+		if match == nil {
+			t.Fatal("Expected a serial number on the official report")
+		}
+
+		verifyResp, err := http.Get(testServer.URL + "/api/v1/verify/" + PDFVerificationID(t, body))
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer verifyResp.Body.Close()
+		var result map[string]interface{}
+		json.NewDecoder(verifyResp.Body).Decode(&result)
+		if result["serial"] != string(match[1]) {
+			t.Errorf("Expected the serial number %s to be recorded, got %v", match[1], result)
+		}
+	})
+
+	t.Run("official_without_elevated_role", func(t *testing.T) {
+		for _, token := range []string{
+			MockAccessToken(7, MockTeacherRoleID, "teacher"),
+			MockAccessToken(2, MockStudentRoleID, "student"),
+		} {
+			resp := request(t, "/api/v1/students/2/report?mode=official", token)
+			ValidateErrorResponse(t, resp, http.StatusForbidden, "Official reports require an elevated role")
+			resp.Body.Close()
+		}
+	})
+
+	t.Run("invalid_mode", func(t *testing.T) {
+		resp := request(t, "/api/v1/students/2/report?mode=final", "")
+		ValidateErrorResponse(t, resp, http.StatusBadRequest, "Unknown report mode")
+		resp.Body.Close()
+
+		resp = request(t, "/api/v1/students/2/report?mode=draft&format=csv", "")
+		ValidateErrorResponse(t, resp, http.StatusBadRequest, "only available for PDF reports")
+		resp.Body.Close()
+	})
+}
+
+// TestReportProtection tests password protected student reports, with the
+// password derived from the student's date of birth or supplied by the caller
+func TestReportProtection(t *testing.T) {
+	// Start mock Node.js server
+	mockServer := MockNodejsServer()
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+
+	// Set up environment, deriving passwords from the date of birth
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+	os.Setenv("REPORT_PASSWORD_RULE", "{dob:02012006}")
+	defer os.Unsetenv("REPORT_PASSWORD_RULE")
+
+	// Start Go service test server
+	testServer := CreateTestServer()
+	defer testServer.Close()
+
+	request := func(t *testing.T, method, path, password string, body io.Reader) *http.Response {
+		t.Helper()
+		req, err := MakeAuthenticatedRequest(method, testServer.URL+path, body, config)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		if password != "" {
+			req.Header.Set("X-Report-Password", password)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		return resp
+	}
+
+	t.Run("password_from_rule", func(t *testing.T) {
+		resp := request(t, "GET", "/api/v1/students/2/report?protect=true&deny=copy,modify", "", nil)
+		defer resp.Body.Close()
+
+		body := ValidatePDFResponse(t, resp)
+		if !PDFOpensWith(body, "15082005") {
+			t.Error("Expected the report to open with Alice's date of birth")
+		}
+		if bytes.Contains(PDFContent(body), []byte("555-0103")) {
+			t.Error("Expected the guardian phone number to be encrypted")
+		}
+	})
+
+	t.Run("password_from_request", func(t *testing.T) {
+		resp := request(t, "GET", "/api/v1/students/2/report", "correct horse", nil)
+		defer resp.Body.Close()
+
+		body := ValidatePDFResponse(t, resp)
+		if !PDFOpensWith(body, "correct horse") || PDFOpensWith(body, "15082005") {
+			t.Error("Expected the report to open with the supplied password only")
+		}
+	})
+
+	t.Run("bulk", func(t *testing.T) {
+		resp := request(t, "POST", "/api/v1/reports/students/bulk?protect=true", "", strings.NewReader(`{"studentIds":[2,5]}`))
+		defer resp.Body.Close()
+
+		files, manifest := ReadBulkArchive(t, resp)
+		if len(manifest.Succeeded) != 2 {
+			t.Fatalf("Expected both reports, got %+v", manifest)
+		}
+		if !PDFOpensWith(files["student_2_report.pdf"], "15082005") || !PDFOpensWith(files["student_5_report.pdf"], "01012005") {
+			t.Error("Expected every report to open with its student's date of birth")
+		}
+	})
+
+	t.Run("invalid_protection", func(t *testing.T) {
+		resp := request(t, "GET", "/api/v1/students/2/report?protect=true&format=csv", "", nil)
+		ValidateErrorResponse(t, resp, http.StatusBadRequest, "only available for PDF reports")
+		resp.Body.Close()
+
+		resp = request(t, "GET", "/api/v1/students/2/report?deny=copy,share", "", nil)
+		ValidateErrorResponse(t, resp, http.StatusBadRequest, "Unknown report permission")
+		resp.Body.Close()
+	})
+}
+
+// TestReportRedaction tests that student reports hide the fields each role's
+// redaction policy covers, in every format
+func TestReportRedaction(t *testing.T) {
+	// Start mock Node.js server
+	mockServer := MockNodejsServer()
+	defer mockServer.Close()
+
+	// Configure test to use mock server
+	config := DefaultTestConfig()
+	config.NodejsAPIURL = mockServer.URL
+	config.UseRealBackend = false
+
+	// Set up environment with a policy that lets admins see everything,
+	// masks guardian phones and removes addresses for teachers, and removes
+	// every contact field for anyone else
+	cleanup := SetupTestEnvironment(config)
+	defer cleanup()
+	policyFile := filepath.Join(t.TempDir(), "redaction.json")
+	policy := `{
+		"roles": [
+			{"role": "admin", "permission": "PUT /api/v1/students/:id"},
+			{"role": "teacher", "permission": "GET /api/v1/students/:id",
+			 "mask": ["fatherPhone", "motherPhone", "guardianPhone"], "remove": ["currentAddress", "permanentAddress"]}
+		],
+		"default": {"remove": ["phone", "fatherPhone", "motherPhone", "guardianPhone", "currentAddress", "permanentAddress"]}
+	}`
+	if err := os.WriteFile(policyFile, []byte(policy), 0o644); err != nil {
+		t.Fatalf("Failed to write redaction policy: %v", err)
+	}
+	os.Setenv("REDACTION_POLICY", policyFile)
+	defer os.Unsetenv("REDACTION_POLICY")
+
+	// Start Go service test server
+	testServer := CreateTestServer()
+	defer testServer.Close()
+
+	// studentAs fetches Alice's report as JSON with the access token of a role
+	studentAs := func(t *testing.T, accessToken string) models.Student {
+		t.Helper()
+		roleConfig := *config
+		roleConfig.TestAccessToken = accessToken
+		req, err := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/students/2/report?format=json", nil, &roleConfig)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got: %d", resp.StatusCode)
+		}
+
+		var student models.Student
+		if err := json.NewDecoder(resp.Body).Decode(&student); err != nil {
+			t.Fatalf("Failed to decode report: %v", err)
+		}
+		return student
 	}
+
+	t.Run("admin", func(t *testing.T) {
+		student := studentAs(t, MockAccessToken(1, MockAdminRoleID, "admin"))
+		if student.FatherPhone != "555-0103" || student.CurrentAddress != "456 Oak Ave, Springfield, IL 62701" {
+			t.Errorf("Expected admins to see contact details, got %+v", student)
+		}
+	})
+
+	t.Run("teacher", func(t *testing.T) {
+		student := studentAs(t, MockAccessToken(7, MockTeacherRoleID, "teacher"))
+		if student.FatherPhone != "***-*103" || student.GuardianPhone != "***-*103" {
+			t.Errorf("Expected teachers to see masked guardian phones, got %q and %q", student.FatherPhone, student.GuardianPhone)
+		}
+		if student.CurrentAddress != "" || student.PermanentAddress != "" {
+			t.Errorf("Expected teachers not to see addresses, got %+v", student)
+		}
+		if student.Phone != "555-0102" {
+			t.Errorf("Expected teachers to see the student's phone, got %q", student.Phone)
+		}
+	})
+
+	t.Run("student", func(t *testing.T) {
+		student := studentAs(t, MockAccessToken(2, MockStudentRoleID, "student"))
+		if student.Phone != "" || student.FatherPhone != "" || student.MotherPhone != "" || student.CurrentAddress != "" {
+			t.Errorf("Expected other roles to see no contact details, got %+v", student)
+		}
+		if student.Name != "Alice Johnson" {
+			t.Errorf("Expected the rest of the report, got %+v", student)
+		}
+	})
+
+	t.Run("roster", func(t *testing.T) {
+		roleConfig := *config
+		roleConfig.TestAccessToken = MockAccessToken(7, MockTeacherRoleID, "teacher")
+		req, err := MakeAuthenticatedRequest("GET", testServer.URL+"/api/v1/classes/Grade%2010/sections/A/roster?format=csv", nil, &roleConfig)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || bytes.Contains(body, []byte("555-0103")) || !bytes.Contains(body, []byte("***-*103")) {
+			t.Errorf("Expected the roster to mask guardian phones, got %d: %s", resp.StatusCode, body)
+		}
+	})
 }
 
 // TestWithRealBackend tests integration with the real Node.js backend
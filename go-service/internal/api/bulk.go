@@ -0,0 +1,316 @@
+package api
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-service/internal/client"
+	"go-service/internal/export"
+	"go-service/internal/pdf"
+	"go-service/internal/redaction"
+)
+
+const (
+	// bulkReportWorkers bounds the number of reports generated concurrently
+	bulkReportWorkers = 4
+	// maxBulkReportStudents caps the number of students in a single bulk export
+	maxBulkReportStudents = 1000
+	// bulkManifestName is the name of the manifest entry inside the ZIP archive
+	bulkManifestName = "manifest.json"
+)
+
+// bulkReportRequest is the body accepted by the bulk student report endpoint.
+// Either StudentIDs or ClassName and Section must be set.
+type bulkReportRequest struct {
+	StudentIDs []int  `json:"studentIds"`
+	ClassName  string `json:"className"`
+	Section    string `json:"section"`
+}
+
+// bulkReportEntry records a report successfully added to the archive
+type bulkReportEntry struct {
+	StudentID string `json:"studentId"`
+	File      string `json:"file"`
+}
+
+// bulkReportFailure records a student whose report could not be generated
+type bulkReportFailure struct {
+	StudentID string `json:"studentId"`
+	Error     string `json:"error"`
+}
+
+// bulkManifest describes the contents of a bulk report archive
+type bulkManifest struct {
+	GeneratedAt time.Time           `json:"generatedAt"`
+	Requested   int                 `json:"requested"`
+	Succeeded   []bulkReportEntry   `json:"succeeded"`
+	Failures    []bulkReportFailure `json:"failures"`
+}
+
+// bulkReportResult is the outcome of generating a single report
+type bulkReportResult struct {
+	studentID string
+	pdfBytes  []byte
+	err       error
+}
+
+// HandleBulkStudentReports generates reports for many students and streams
+// them back as a ZIP archive
+func (s *Service) HandleBulkStudentReports(w http.ResponseWriter, r *http.Request) {
+	template, ok := s.selectTemplate(w, r, pdf.TemplateKindStudent)
+	if !ok {
+		return
+	}
+
+	mode, ok := s.selectMode(w, r, export.FormatPDF)
+	if !ok {
+		return
+	}
+
+	protection, ok := s.selectProtection(w, r, export.FormatPDF)
+	if !ok {
+		return
+	}
+
+	redact, ok := s.selectRedaction(w, r)
+	if !ok {
+		return
+	}
+
+	studentIDs, ok := s.decodeBulkReportRequest(w, r)
+	if !ok {
+		return
+	}
+
+	filename := fmt.Sprintf("student_reports_%s.zip", time.Now().Format("20060102_150405"))
+
+	// Large batches can be generated in the background and polled for
+	if wantsAsync(r) {
+		s.submitJob(w, r, "bulk-student-reports", "application/zip", filename, func(ctx context.Context, w io.Writer, progress func(done, total int)) error {
+			_, err := s.writeStudentReportArchive(ctx, w, studentIDs, template, mode, protection, redact, progress)
+			return err
+		})
+		return
+	}
+
+	// Set response headers for ZIP download
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	manifest, err := s.writeStudentReportArchive(r.Context(), w, studentIDs, template, mode, protection, redact, nil)
+	if err != nil {
+		// Headers are already sent, so the truncated archive is all the caller gets
+		fmt.Printf("Error writing bulk report archive: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Successfully generated bulk report archive (%d succeeded, %d failed)\n", len(manifest.Succeeded), len(manifest.Failures))
+}
+
+// decodeBulkReportRequest parses and validates a bulk report request and
+// resolves it to a list of student IDs. It writes the error response and
+// returns false when the request cannot be served.
+func (s *Service) decodeBulkReportRequest(w http.ResponseWriter, r *http.Request) ([]string, bool) {
+	var body bulkReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"Invalid request body"}`, http.StatusBadRequest)
+		return nil, false
+	}
+
+	hasIDs := len(body.StudentIDs) > 0
+	hasClass := body.ClassName != "" || body.Section != ""
+	if hasIDs == hasClass {
+		http.Error(w, `{"error":"Provide either studentIds or className and section"}`, http.StatusBadRequest)
+		return nil, false
+	}
+	if hasClass && (body.ClassName == "" || body.Section == "") {
+		http.Error(w, `{"error":"Class and section are required"}`, http.StatusBadRequest)
+		return nil, false
+	}
+
+	studentIDs, err := s.resolveBulkStudentIDs(r.Context(), body)
+	if err != nil {
+		fmt.Printf("Error resolving students for bulk report: %v\n", err)
+		writeBackendError(w, err, "No students found", "Failed to fetch student data")
+		return nil, false
+	}
+
+	if len(studentIDs) == 0 {
+		http.Error(w, `{"error":"No students found"}`, http.StatusNotFound)
+		return nil, false
+	}
+	if len(studentIDs) > maxBulkReportStudents {
+		http.Error(w, fmt.Sprintf(`{"error":"At most %d students can be exported at once"}`, maxBulkReportStudents), http.StatusBadRequest)
+		return nil, false
+	}
+
+	return studentIDs, true
+}
+
+// resolveBulkStudentIDs returns the explicit student IDs of a bulk request,
+// or lists the students of the requested class section
+func (s *Service) resolveBulkStudentIDs(ctx context.Context, body bulkReportRequest) ([]string, error) {
+	if len(body.StudentIDs) > 0 {
+		// Drop duplicates while preserving the requested order
+		seen := make(map[int]bool)
+		var studentIDs []string
+		for _, id := range body.StudentIDs {
+			if !seen[id] {
+				seen[id] = true
+				studentIDs = append(studentIDs, strconv.Itoa(id))
+			}
+		}
+		return studentIDs, nil
+	}
+
+	students, err := s.NodejsClient.GetStudents(ctx, client.StudentFilter{
+		ClassName: body.ClassName,
+		Section:   body.Section,
+	})
+	if err != nil {
+		// The Node.js API answers 404 when no students match the filters
+		if errors.Is(err, client.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	studentIDs := make([]string, len(students))
+	for i, student := range students {
+		studentIDs[i] = strconv.Itoa(student.ID)
+	}
+	return studentIDs, nil
+}
+
+// writeStudentReportArchive generates a report for every student, laid out by
+// template, issued in mode, protected by protection if it is not nil and
+// redacted by redact, with a bounded worker pool and writes them to w as a
+// ZIP archive. Students whose report fails are listed in the archive manifest
+// instead of aborting the batch. progress, if not nil, is called after each
+// student is processed.
+func (s *Service) writeStudentReportArchive(ctx context.Context, w io.Writer, studentIDs []string, template *pdf.Template, mode string, protection *export.Protection, redact *redaction.Rule, progress func(done, total int)) (*bulkManifest, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan string)
+	results := make(chan bulkReportResult)
+
+	// Start worker goroutines
+	var wg sync.WaitGroup
+	for i := 0; i < bulkReportWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for studentID := range jobs {
+				pdfBytes, err := s.generateStudentReport(ctx, studentID, template, mode, protection, redact)
+				select {
+				case results <- bulkReportResult{studentID: studentID, pdfBytes: pdfBytes, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	// Feed student IDs to the workers
+	go func() {
+		defer close(jobs)
+		for _, studentID := range studentIDs {
+			select {
+			case jobs <- studentID:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	manifest := &bulkManifest{
+		GeneratedAt: time.Now(),
+		Requested:   len(studentIDs),
+		Succeeded:   []bulkReportEntry{},
+		Failures:    []bulkReportFailure{},
+	}
+
+	// Write reports to the archive as they complete
+	archive := zip.NewWriter(w)
+	for result := range results {
+		if result.err != nil {
+			manifest.Failures = append(manifest.Failures, bulkReportFailure{
+				StudentID: result.studentID,
+				Error:     result.err.Error(),
+			})
+		} else {
+			filename := fmt.Sprintf("student_%s_report.pdf", result.studentID)
+			entry, err := archive.Create(filename)
+			if err == nil {
+				_, err = entry.Write(result.pdfBytes)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to write %s to archive: %w", filename, err)
+			}
+			manifest.Succeeded = append(manifest.Succeeded, bulkReportEntry{StudentID: result.studentID, File: filename})
+		}
+
+		if progress != nil {
+			progress(len(manifest.Succeeded)+len(manifest.Failures), len(studentIDs))
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Finish with the manifest so it reflects every student
+	entry, err := archive.Create(bulkManifestName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write manifest to archive: %w", err)
+	}
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return nil, fmt.Errorf("failed to write manifest to archive: %w", err)
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// generateStudentReport fetches a student and renders their PDF report in
+// mode, protected by protection if it is not nil and redacted by redact
+func (s *Service) generateStudentReport(ctx context.Context, studentID string, template *pdf.Template, mode string, protection *export.Protection, redact *redaction.Rule) ([]byte, error) {
+	student, err := s.NodejsClient.GetStudent(ctx, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch student: %w", err)
+	}
+
+	protection, err = s.protectStudentReport(protection, student)
+	if err != nil {
+		return nil, fmt.Errorf("failed to protect report: %w", err)
+	}
+	redact.Apply(student)
+
+	report := &export.Report{Data: pdf.Profile{Template: template, Record: student}, Mode: mode, Protection: protection}
+	content, _, err := s.Renderers.Render(ctx, export.FormatPDF, report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate PDF: %w", err)
+	}
+	defer export.Close(content)
+
+	return io.ReadAll(content)
+}
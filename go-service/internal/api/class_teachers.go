@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-service/internal/client"
+	"go-service/internal/export"
+	"go-service/pkg/models"
+)
+
+// HandleClassTeacherReport generates and returns the class teacher of every
+// class section as a class by section matrix, as a PDF or in another export
+// format chosen by ?format= or the Accept header
+func (s *Service) HandleClassTeacherReport(w http.ResponseWriter, r *http.Request) {
+	format, ok := s.negotiateFormat(w, r, export.ReportFormats, "report")
+	if !ok {
+		return
+	}
+
+	mode, ok := s.selectMode(w, r, format)
+	if !ok {
+		return
+	}
+
+	matrix, err := s.fetchClassTeacherMatrix(r.Context())
+	if err != nil {
+		fmt.Printf("Error fetching class teachers: %v\n", err)
+		writeBackendError(w, err, "Class teachers not found", "Failed to fetch class teachers")
+		return
+	}
+
+	report := &export.Report{
+		Name:   "class_teachers_" + time.Now().Format("2006-01-02"),
+		Data:   matrix,
+		Tables: []export.Table{classTeacherTable(matrix)},
+		Mode:   mode,
+	}
+	if !s.writeReport(w, r, format, report) {
+		return
+	}
+
+	fmt.Printf("Successfully generated class teacher report (%d classes, %d unassigned sections)\n", len(matrix.Rows), len(matrix.Unassigned))
+}
+
+// classTeacherTable lays the matrix out with a row per class and a column per
+// section. A cell holds the section's teachers, UNASSIGNED when the class
+// runs the section without a class teacher, or nothing when it does not run
+// it. The last column flags the row's unassigned sections and the teachers
+// assigned to several sections.
+func classTeacherTable(matrix models.ClassTeacherMatrix) export.Table {
+	table := export.Table{Title: "Class Teachers", Columns: append(append([]string{"Class"}, matrix.Sections...), "Flags")}
+
+	for _, row := range matrix.Rows {
+		record := []string{row.Class}
+		var unassigned, several []string
+		flagged := make(map[string]bool)
+		for i, cell := range row.Cells {
+			if cell.Unassigned() {
+				record = append(record, "UNASSIGNED")
+				unassigned = append(unassigned, matrix.Sections[i])
+				continue
+			}
+			record = append(record, strings.Join(cell.Teachers, "; "))
+			for _, teacher := range cell.Teachers {
+				if matrix.TeachesSeveral(teacher) && !flagged[teacher] {
+					flagged[teacher] = true
+					several = append(several, teacher)
+				}
+			}
+		}
+
+		var flags []string
+		if len(unassigned) > 0 {
+			flags = append(flags, "Unassigned: "+strings.Join(unassigned, ", "))
+		}
+		if len(several) > 0 {
+			flags = append(flags, "Multiple sections: "+strings.Join(several, ", "))
+		}
+		table.Rows = append(table.Rows, append(record, strings.Join(flags, "; ")))
+	}
+	return table
+}
+
+// fetchClassTeacherMatrix fetches the classes, sections and class teacher
+// assignments and lays them out as a matrix. The Node.js API responds with
+// 404 when any of them is empty, which is an empty list rather than an error.
+func (s *Service) fetchClassTeacherMatrix(ctx context.Context) (models.ClassTeacherMatrix, error) {
+	classes, err := s.NodejsClient.GetClasses(ctx)
+	if err != nil && !errors.Is(err, client.ErrNotFound) {
+		return models.ClassTeacherMatrix{}, err
+	}
+
+	sections, err := s.NodejsClient.GetSections(ctx)
+	if err != nil && !errors.Is(err, client.ErrNotFound) {
+		return models.ClassTeacherMatrix{}, err
+	}
+
+	assignments, err := s.NodejsClient.GetClassTeachers(ctx)
+	if err != nil && !errors.Is(err, client.ErrNotFound) {
+		return models.ClassTeacherMatrix{}, err
+	}
+
+	return models.BuildClassTeacherMatrix(classes, sections, assignments), nil
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"reflect"
+	"testing"
+
+	"go-service/internal/export"
+	"go-service/pkg/models"
+)
+
+// TestClassTeacherMatrixCSV tests the matrix layout of the CSV export and
+// the flags column
+func TestClassTeacherMatrixCSV(t *testing.T) {
+	matrix := models.BuildClassTeacherMatrix(
+		[]models.Class{{Name: "Grade 8", Sections: "A,B"}, {Name: "Grade 9", Sections: "A"}},
+		[]models.Section{{Name: "A"}, {Name: "B"}},
+		[]models.ClassTeacher{
+			{ID: 1, Class: "Grade 8", Section: "A", Teacher: "Edna Krabappel"},
+			{ID: 2, Class: "Grade 9", Section: "A", Teacher: "Edna Krabappel"},
+		},
+	)
+
+	report := &export.Report{Data: matrix, Tables: []export.Table{classTeacherTable(matrix)}}
+	content, _, err := newRenderers(nil, nil, nil, "", nil).Render(context.Background(), export.FormatCSV, report)
+	if err != nil {
+		t.Fatalf("Expected CSV to be written, got error: %v", err)
+	}
+
+	records, err := csv.NewReader(content).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected valid CSV, got error: %v", err)
+	}
+
+	want := [][]string{
+		{"Class", "A", "B", "Flags"},
+		{"Grade 8", "Edna Krabappel", "UNASSIGNED", "Unassigned: B; Multiple sections: Edna Krabappel"},
+		{"Grade 9", "Edna Krabappel", "", "Multiple sections: Edna Krabappel"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("Expected %v, got %v", want, records)
+	}
+}
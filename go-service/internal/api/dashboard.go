@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-service/internal/export"
+	"go-service/pkg/models"
+)
+
+// HandleDashboardReport generates and returns a one-page executive summary
+// of the caller's dashboard as a PDF, or in another export format chosen by
+// ?format= or the Accept header
+func (s *Service) HandleDashboardReport(w http.ResponseWriter, r *http.Request) {
+	format, ok := s.negotiateFormat(w, r, export.ReportFormats, "report")
+	if !ok {
+		return
+	}
+
+	mode, ok := s.selectMode(w, r, format)
+	if !ok {
+		return
+	}
+
+	summary, err := s.NodejsClient.GetDashboard(r.Context())
+	if err != nil {
+		fmt.Printf("Error fetching dashboard: %v\n", err)
+		writeBackendError(w, err, "Dashboard data not found", "Failed to fetch dashboard data")
+		return
+	}
+
+	// The dashboard is the caller's own, and the Node.js API has just
+	// accepted the token its user ID comes from
+	claims, ok := s.NodejsClient.Claims(r.Context())
+	if !ok || claims.UserID == 0 {
+		writeError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	summary.UserID = claims.UserID
+
+	now := time.Now()
+	report := &export.Report{
+		Name:        "dashboard_" + now.Format("2006-01-02"),
+		Data:        summary,
+		Tables:      dashboardTables(summary),
+		GeneratedAt: now,
+		Mode:        mode,
+	}
+	if !s.writeReport(w, r, format, report) {
+		return
+	}
+
+	fmt.Printf("Successfully generated %s dashboard summary\n", strings.ToUpper(format))
+}
+
+// dashboardTables lays the dashboard out as tables. Unlike the one-page PDF
+// they are not capped, so every row the Node.js API returned is listed.
+func dashboardTables(summary *models.DashboardSummary) []export.Table {
+	counts := export.Table{Title: "Head Counts", Columns: []string{"Group", "This Year", "Last Year", "Change", "Percent Change"}}
+	for _, group := range []struct {
+		name  string
+		count models.DashboardCount
+	}{
+		{"Students", summary.Students},
+		{"Teachers", summary.Teachers},
+		{"Parents", summary.Parents},
+	} {
+		counts.Rows = append(counts.Rows, []string{
+			group.name,
+			strconv.Itoa(group.count.CurrentYear),
+			strconv.Itoa(group.count.PreviousYear()),
+			strconv.Itoa(group.count.Change),
+			strconv.FormatFloat(group.count.PercentChange, 'f', -1, 64),
+		})
+	}
+
+	policies := export.Table{Title: "Leave Days Used", Columns: []string{"Policy", "Days Used"}}
+	for _, policy := range summary.LeavePolicies {
+		policies.Rows = append(policies.Rows, []string{policy.Name, policy.TotalDaysUsed.String()})
+	}
+
+	upcoming := export.Table{Title: "Upcoming Leave", Columns: []string{"Name", "Leave Type", "From", "To"}}
+	for _, leave := range summary.OneMonthLeave {
+		upcoming.Rows = append(upcoming.Rows, []string{leave.User, leave.LeaveType, tableDate(leave.FromDate.Time), tableDate(leave.ToDate.Time)})
+	}
+
+	notices := export.Table{Title: "Recent Notices", Columns: []string{"Title", "Author", "Created", "Status"}}
+	for _, notice := range summary.Notices {
+		notices.Rows = append(notices.Rows, []string{notice.Title, notice.Author, tableDate(notice.CreatedDate.Time), notice.Status})
+	}
+
+	celebrations := export.Table{Title: "Celebrations", Columns: []string{"Name", "Event", "Date"}}
+	for _, celebration := range summary.Celebrations {
+		celebrations.Rows = append(celebrations.Rows, []string{celebration.User, celebration.Event, tableDate(celebration.EventDate.Time)})
+	}
+
+	return []export.Table{counts, policies, upcoming, notices, celebrations}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go-service/internal/client"
+)
+
+// writeError writes a JSON error response in the {"error": "..."} format used by all handlers
+func writeError(w http.ResponseWriter, status int, message string) {
+	body, _ := json.Marshal(map[string]string{"error": message})
+	http.Error(w, string(body), status)
+}
+
+// writeBackendError maps an error from the Node.js API to our response:
+// authentication and authorization failures are passed through to the caller,
+// a missing resource becomes notFoundMessage and anything else a 500 with
+// failureMessage
+func writeBackendError(w http.ResponseWriter, err error, notFoundMessage, failureMessage string) {
+	switch {
+	case errors.Is(err, client.ErrUnauthorized):
+		writeError(w, http.StatusUnauthorized, "Authentication required")
+	case errors.Is(err, client.ErrForbidden):
+		writeError(w, http.StatusForbidden, "Access denied")
+	case errors.Is(err, client.ErrNotFound):
+		writeError(w, http.StatusNotFound, notFoundMessage)
+	default:
+		writeError(w, http.StatusInternalServerError, failureMessage)
+	}
+}
+
+// missingID returns a handler answering a report path whose ID was left out
+// with a 400 and message
+func missingID(message string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusBadRequest, message)
+	}
+}
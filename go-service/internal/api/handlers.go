@@ -1,20 +1,62 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"go-service/internal/client"
+	"go-service/internal/export"
+	"go-service/internal/jobs"
 	"go-service/internal/pdf"
+	"go-service/internal/redaction"
+	"go-service/internal/signing"
+	"go-service/internal/verify"
 
 	"github.com/gorilla/mux"
 )
 
+// reportJobWorkers is the number of report jobs that run concurrently
+const reportJobWorkers = 2
+
+// defaultJobRetention is how long finished report jobs and their artifacts
+// are kept unless JOB_RETENTION says otherwise
+const defaultJobRetention = 24 * time.Hour
+
+// defaultVerifyRetention is how long issued documents without a serial number
+// can be verified unless VERIFY_RETENTION says otherwise
+const defaultVerifyRetention = 365 * 24 * time.Hour
+
 // Service holds the dependencies for handlers
 type Service struct {
 	NodejsClient *client.NodejsClient
+	// Jobs runs asynchronous report jobs; nil to disable them
+	Jobs *jobs.Manager
+	// Templates holds the student and staff report layouts selectable with ?template=
+	Templates *pdf.TemplateSet
+	// Fonts holds the UTF-8 fonts embedded in reports; nil to use the core fonts
+	Fonts *pdf.FontSet
+	// Branding serves the school branding, reloaded when its config changes
+	Branding *pdf.BrandingStore
+	// Renderers renders reports in each format they can be written in
+	Renderers *export.Registry
+	// Verifier records issued PDFs for verification; nil if the store is unavailable
+	Verifier *verify.Store
+	// AcademicYearStart is the month academic years begin in
+	AcademicYearStart time.Month
+	// OfficialPermission is the Node.js API permission, such as
+	// PUT /api/v1/students/:id, a caller's role needs to request official
+	// reports
+	OfficialPermission string
+	// PasswordRule derives the password of protected student reports from
+	// the student; nil if callers must supply the password
+	PasswordRule *pdf.PasswordRule
+	// Redaction hides the student fields each role may not see in reports;
+	// nil to show every field
+	Redaction *redaction.Policy
 }
 
 // NewService creates a new service with initialized dependencies
@@ -25,12 +67,176 @@ func NewService() *Service {
 		nodejsURL = "http://localhost:5007"
 	}
 
-	return &Service{
-		NodejsClient: client.NewNodejsClient(nodejsURL),
+	// Get job store directory from environment or use default
+	jobsDir := os.Getenv("JOBS_DIR")
+	if jobsDir == "" {
+		jobsDir = "data/jobs"
+	}
+
+	// Get how long finished jobs are kept from environment or use default
+	jobRetention := defaultJobRetention
+	if value := os.Getenv("JOB_RETENTION"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			jobRetention = parsed
+		} else {
+			fmt.Printf("Invalid JOB_RETENTION %q, keeping jobs for %s\n", value, jobRetention)
+		}
+	}
+
+	// The job store is only opened when the first job is submitted or
+	// looked up; synchronous endpoints keep working without it
+	jobManager := jobs.NewManager(jobsDir, reportJobWorkers, jobRetention)
+
+	// Get issued document store directory and the URL reports are verified
+	// at from environment or use defaults
+	verifyDir := os.Getenv("VERIFY_DIR")
+	if verifyDir == "" {
+		verifyDir = "data/verifications"
+	}
+	verifyBaseURL := os.Getenv("VERIFY_BASE_URL")
+	if verifyBaseURL == "" {
+		verifyBaseURL = "http://localhost:8080"
+	}
+	verifyRetention := defaultVerifyRetention
+	if value := os.Getenv("VERIFY_RETENTION"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			verifyRetention = parsed
+		} else {
+			fmt.Printf("Invalid VERIFY_RETENTION %q, keeping issued documents for %s\n", value, verifyRetention)
+		}
+	}
+
+	// Without the store reports are issued without a verification code
+	verifier, err := verify.NewStore(verifyDir, verifyRetention)
+	if err != nil {
+		fmt.Printf("Report verification disabled: %v\n", err)
+		verifier = nil
+	}
+
+	// Reports are signed only when a signing certificate and key are
+	// configured, and issued unsigned if they cannot be loaded
+	var signer *signing.Signer
+	certFile, keyFile := os.Getenv("SIGNING_CERT_FILE"), os.Getenv("SIGNING_KEY_FILE")
+	if certFile != "" || keyFile != "" {
+		signer, err = signing.LoadSigner(certFile, keyFile)
+		if err != nil {
+			fmt.Printf("PDF signing disabled: %v\n", err)
+			signer = nil
+		} else {
+			fmt.Printf("Signing PDF reports as %s\n", signer.Name())
+		}
+	}
+
+	// Get the permission official reports require from environment or use
+	// default, which is held by the staff who maintain student records
+	officialPermission := os.Getenv("OFFICIAL_REPORT_PERMISSION")
+	if officialPermission == "" {
+		officialPermission = "PUT /api/v1/students/:id"
+	}
+
+	// Without a password rule callers supply the password of protected
+	// reports themselves
+	var passwordRule *pdf.PasswordRule
+	if rule := os.Getenv("REPORT_PASSWORD_RULE"); rule != "" {
+		passwordRule, err = pdf.ParsePasswordRule(rule)
+		if err != nil {
+			fmt.Printf("Report password rule disabled: %v\n", err)
+			passwordRule = nil
+		}
+	}
+
+	// Student fields are only redacted when a policy is configured, and a
+	// policy that cannot be loaded withholds contact details from everyone
+	var redactionPolicy *redaction.Policy
+	if path := os.Getenv("REDACTION_POLICY"); path != "" {
+		redactionPolicy, err = redaction.LoadPolicy(path)
+		if err != nil {
+			fmt.Printf("Error loading redaction policy, removing contact details from every report: %v\n", err)
+			redactionPolicy = redaction.Restrictive()
+		}
+	}
+
+	// Get report template directory from environment or use default
+	templatesDir := os.Getenv("REPORT_TEMPLATES_DIR")
+	if templatesDir == "" {
+		templatesDir = "templates"
+	}
+
+	// Fall back to the built-in layouts rather than refusing to start
+	templates, err := pdf.LoadTemplates(templatesDir)
+	if err != nil {
+		fmt.Printf("Error loading report templates, using built-in templates only: %v\n", err)
+		templates = pdf.BuiltinTemplates()
+	}
+
+	// Get fonts directory from environment or use default
+	fontsDir := os.Getenv("PDF_FONTS_DIR")
+	if fontsDir == "" {
+		fontsDir = "fonts"
+	}
+
+	// Without UTF-8 fonts reports fall back to the Latin-1 core fonts
+	fonts, err := pdf.LoadFonts(fontsDir)
+	if err != nil {
+		fmt.Printf("Error loading fonts, using core fonts only: %v\n", err)
+		fonts = nil
+	}
+
+	// Get branding config path from environment or use default
+	brandingConfig := os.Getenv("BRANDING_CONFIG")
+	if brandingConfig == "" {
+		brandingConfig = "branding.json"
+	}
+
+	academicYearStart, err := loadAcademicYearStart()
+	if err != nil {
+		fmt.Printf("Using default academic year start: %v\n", err)
+	}
+
+	// Calls made on behalf of the service itself authenticate as the
+	// service account, if one is configured
+	nodejsClient := client.NewNodejsClient(nodejsURL)
+	serviceAccount, err := loadServiceAccount()
+	if err != nil {
+		fmt.Printf("Service account disabled: %v\n", err)
+	} else if serviceAccount != nil {
+		nodejsClient.SetServiceAccount(serviceAccount.Username, serviceAccount.Password)
+		fmt.Printf("Using service account %s for background calls\n", serviceAccount.Username)
 	}
+
+	branding := pdf.NewBrandingStore(brandingConfig)
+	service := &Service{
+		NodejsClient:       nodejsClient,
+		Jobs:               jobManager,
+		Templates:          templates,
+		Fonts:              fonts,
+		Branding:           branding,
+		Renderers:          newRenderers(fonts, branding, verifier, verifyBaseURL, signer),
+		Verifier:           verifier,
+		AcademicYearStart:  academicYearStart,
+		OfficialPermission: officialPermission,
+		PasswordRule:       passwordRule,
+		Redaction:          redactionPolicy,
+	}
+
+	// Scheduled leave digests are fetched with the service account, so
+	// they need one
+	schedule, err := loadLeaveDigestSchedule()
+	switch {
+	case err != nil:
+		fmt.Printf("Leave digest schedule disabled: %v\n", err)
+	case schedule != nil && serviceAccount == nil:
+		fmt.Printf("Leave digest schedule disabled: no service account configured\n")
+	case schedule != nil:
+		go service.scheduleLeaveDigest(context.Background(), *schedule)
+		fmt.Printf("Writing leave digests to %s every %s\n", schedule.Dir, schedule.Interval)
+	}
+
+	return service
 }
 
-// HandleStudentReport generates and returns a PDF report for a student
+// HandleStudentReport generates and returns a report for a student as a PDF,
+// or in another export format chosen by ?format= or the Accept header
 func (s *Service) HandleStudentReport(w http.ResponseWriter, r *http.Request) {
 	// Extract student ID from URL
 	vars := mux.Vars(r)
@@ -41,54 +247,92 @@ func (s *Service) HandleStudentReport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// TODO: In next task, we'll add authentication handling
-	// For now, we'll make the request without authentication
-	
-	// Fetch student data from Node.js API
-	student, err := s.NodejsClient.GetStudent(studentID)
+	format, ok := s.negotiateFormat(w, r, export.ReportFormats, "report")
+	if !ok {
+		return
+	}
+
+	mode, ok := s.selectMode(w, r, format)
+	if !ok {
+		return
+	}
+
+	protection, ok := s.selectProtection(w, r, format)
+	if !ok {
+		return
+	}
+
+	template, ok := s.selectTemplate(w, r, pdf.TemplateKindStudent)
+	if !ok {
+		return
+	}
+
+	redact, ok := s.selectRedaction(w, r)
+	if !ok {
+		return
+	}
+
+	// Fetch student data from Node.js API using the caller's credentials
+	// attached to the request context by AuthMiddleware
+	student, err := s.NodejsClient.GetStudent(r.Context(), studentID)
 	if err != nil {
 		// Log the error for debugging
 		fmt.Printf("Error fetching student %s: %v\n", studentID, err)
 		
-		// Return appropriate error response based on status code
-		errorMsg := err.Error()
-		if strings.Contains(errorMsg, "status 404") {
-			http.Error(w, `{"error":"Student not found"}`, http.StatusNotFound)
-			return
-		}
-		
-		http.Error(w, `{"error":"Failed to fetch student data"}`, http.StatusInternalServerError)
+		// Return appropriate error response based on the backend status code
+		writeBackendError(w, err, "Student not found", "Failed to fetch student data")
 		return
 	}
 
-	// Generate PDF report
-	generator := pdf.NewGenerator()
-	pdfBytes, err := generator.GenerateStudentReport(student)
+	protection, err = s.protectStudentReport(protection, student)
 	if err != nil {
-		fmt.Printf("Error generating PDF for student %s: %v\n", studentID, err)
-		http.Error(w, `{"error":"Failed to generate PDF report"}`, http.StatusInternalServerError)
+		fmt.Printf("Error protecting report for student %s: %v\n", studentID, err)
+		http.Error(w, `{"error":"Report password cannot be derived for this student"}`, http.StatusUnprocessableEntity)
 		return
 	}
 
-	// Set response headers for PDF download
-	w.Header().Set("Content-Type", "application/pdf")
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=student_%s_report.pdf", studentID))
-	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(pdfBytes)))
+	// Fields the caller's role may not see are hidden in every format, once
+	// the password has been derived from the full record
+	redact.Apply(student)
 
-	// Write PDF to response
-	_, err = w.Write(pdfBytes)
+	// The spreadsheet formats list the same fields as the PDF
+	values, err := template.Values(student)
 	if err != nil {
-		fmt.Printf("Error writing PDF response for student %s: %v\n", studentID, err)
+		fmt.Printf("Error generating report for student %s: %v\n", studentID, err)
+		http.Error(w, `{"error":"Failed to generate report"}`, http.StatusInternalServerError)
 		return
 	}
 
-	fmt.Printf("Successfully generated PDF report for student %s\n", studentID)
+	report := &export.Report{
+		Name:       fmt.Sprintf("student_%s_report", studentID),
+		Data:       pdf.Profile{Template: template, Record: student},
+		Tables:     []export.Table{profileTable("Student", values)},
+		Mode:       mode,
+		Protection: protection,
+	}
+	if !s.writeReport(w, r, format, report) {
+		return
+	}
+
+	fmt.Printf("Successfully generated %s report for student %s\n", strings.ToUpper(format), studentID)
+}
+
+// selectTemplate returns the report template of the given kind named by the
+// ?template= query parameter, or the default template of that kind. It writes
+// the error response and returns false when the template does not exist.
+func (s *Service) selectTemplate(w http.ResponseWriter, r *http.Request, kind string) (*pdf.Template, bool) {
+	template, ok := s.Templates.Get(kind, r.URL.Query().Get("template"))
+	if !ok {
+		http.Error(w, `{"error":"Unknown report template"}`, http.StatusBadRequest)
+		return nil, false
+	}
+	return template, true
 }
 
 // HandleHealth provides a health check endpoint
 func (s *Service) HandleHealth(w http.ResponseWriter, r *http.Request) {
 	// Check if Node.js API is accessible
-	err := s.NodejsClient.HealthCheck()
+	err := s.NodejsClient.HealthCheck(r.Context())
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusServiceUnavailable)
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go-service/internal/client"
+	"go-service/internal/jobs"
+
+	"github.com/gorilla/mux"
+)
+
+// jobAcceptedResponse is returned when a report request is queued as a job
+type jobAcceptedResponse struct {
+	JobID     string      `json:"jobId"`
+	Status    jobs.Status `json:"status"`
+	StatusURL string      `json:"statusUrl"`
+	ResultURL string      `json:"resultUrl"`
+}
+
+// wantsAsync reports whether the caller asked for the report to be generated as a job
+func wantsAsync(r *http.Request) bool {
+	return r.URL.Query().Get("async") == "true"
+}
+
+// submitJob queues task as a report job owned by the caller and answers 202
+// with the job's status and result URLs. The task runs with the caller's
+// credentials.
+func (s *Service) submitJob(w http.ResponseWriter, r *http.Request, kind, contentType, filename string, task jobs.Task) {
+	owner, ok := s.checkJobAccess(w, r)
+	if !ok {
+		return
+	}
+
+	// The request context ends with the response, so only its credentials
+	// are carried over to the job
+	creds, _ := client.CredentialsFromContext(r.Context())
+	job, err := s.Jobs.Submit(owner, kind, contentType, filename, func(ctx context.Context, w io.Writer, progress func(done, total int)) error {
+		return task(client.WithCredentials(ctx, creds), w, progress)
+	})
+	if err != nil {
+		fmt.Printf("Error submitting %s job: %v\n", kind, err)
+		if errors.Is(err, jobs.ErrQueueFull) {
+			http.Error(w, `{"error":"Too many report jobs queued, try again later"}`, http.StatusServiceUnavailable)
+			return
+		}
+		if errors.Is(err, jobs.ErrUnavailable) {
+			http.Error(w, `{"error":"Report jobs are unavailable"}`, http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(w, `{"error":"Failed to queue report job"}`, http.StatusInternalServerError)
+		return
+	}
+
+	statusURL := fmt.Sprintf("/api/v1/jobs/%s", job.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", statusURL)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(jobAcceptedResponse{
+		JobID:     job.ID,
+		Status:    job.Status,
+		StatusURL: statusURL,
+		ResultURL: statusURL + "/result",
+	})
+
+	fmt.Printf("Queued %s job\n", kind)
+}
+
+// HandleJobStatus returns the state and progress of a report job
+func (s *Service) HandleJobStatus(w http.ResponseWriter, r *http.Request) {
+	owner, ok := s.checkJobAccess(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := s.Jobs.Get(mux.Vars(r)["id"], owner)
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		fmt.Printf("Error writing %s job status: %v\n", job.Kind, err)
+	}
+}
+
+// HandleJobResult downloads the artifact of a finished report job
+func (s *Service) HandleJobResult(w http.ResponseWriter, r *http.Request) {
+	owner, ok := s.checkJobAccess(w, r)
+	if !ok {
+		return
+	}
+
+	file, job, err := s.Jobs.OpenResult(mux.Vars(r)["id"], owner)
+	if err != nil {
+		if errors.Is(err, jobs.ErrNotReady) {
+			http.Error(w, fmt.Sprintf(`{"error":"Job is %s","status":"%s"}`, job.Status, job.Status), http.StatusConflict)
+			return
+		}
+		writeJobError(w, err)
+		return
+	}
+	defer file.Close()
+
+	// Set response headers for the artifact download
+	w.Header().Set("Content-Type", job.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", job.Filename))
+	if info, err := file.Stat(); err == nil {
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", info.Size()))
+	}
+
+	if _, err := io.Copy(w, file); err != nil {
+		fmt.Printf("Error writing %s job result: %v\n", job.Kind, err)
+	}
+}
+
+// writeJobError writes the error response for a job that cannot be read
+func writeJobError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		http.Error(w, `{"error":"Job not found"}`, http.StatusNotFound)
+	case errors.Is(err, jobs.ErrUnavailable):
+		http.Error(w, `{"error":"Report jobs are unavailable"}`, http.StatusServiceUnavailable)
+	default:
+		fmt.Printf("Error reading job: %v\n", err)
+		http.Error(w, `{"error":"Failed to read job result"}`, http.StatusInternalServerError)
+	}
+}
+
+// checkJobAccess verifies that job endpoints are usable and returns the ID of
+// the authenticated caller, who only has access to their own jobs. It writes
+// the error response and returns false when the request cannot be served.
+func (s *Service) checkJobAccess(w http.ResponseWriter, r *http.Request) (int, bool) {
+	if s.Jobs == nil {
+		http.Error(w, `{"error":"Report jobs are unavailable"}`, http.StatusServiceUnavailable)
+		return 0, false
+	}
+
+	// Unlike report endpoints these never reach the Node.js API, which would
+	// otherwise reject unauthenticated callers
+	if creds, _ := client.CredentialsFromContext(r.Context()); creds.AccessToken == "" {
+		http.Error(w, `{"error":"Authentication required"}`, http.StatusUnauthorized)
+		return 0, false
+	}
+
+	// The claims of the access token are not verified here, so the Node.js
+	// API checks the token before its user ID is trusted with a job. A role
+	// without any permissions is still a valid user.
+	if _, err := s.NodejsClient.GetMyPermissions(r.Context()); err != nil && !errors.Is(err, client.ErrNotFound) {
+		fmt.Printf("Error authenticating job caller: %v\n", err)
+		writeBackendError(w, err, "Authentication required", "Failed to authenticate")
+		return 0, false
+	}
+
+	// The token may have been refreshed by the check above
+	creds, _ := client.CredentialsFromContext(r.Context())
+	claims, ok := creds.Claims()
+	if !ok || claims.UserID == 0 {
+		http.Error(w, `{"error":"Authentication required"}`, http.StatusUnauthorized)
+		return 0, false
+	}
+
+	return claims.UserID, true
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"go-service/internal/client"
+	"go-service/internal/export"
+	"go-service/pkg/models"
+
+	"github.com/gorilla/mux"
+)
+
+// defaultAcademicYearStart is the month academic years begin in unless
+// ACADEMIC_YEAR_START_MONTH says otherwise
+const defaultAcademicYearStart = time.April
+
+// loadAcademicYearStart reads the month academic years begin in from
+// ACADEMIC_YEAR_START_MONTH (1-12)
+func loadAcademicYearStart() (time.Month, error) {
+	value := os.Getenv("ACADEMIC_YEAR_START_MONTH")
+	if value == "" {
+		return defaultAcademicYearStart, nil
+	}
+
+	month, err := strconv.Atoi(value)
+	if err != nil || month < 1 || month > 12 {
+		return defaultAcademicYearStart, fmt.Errorf("ACADEMIC_YEAR_START_MONTH must be a month number from 1 to 12, got %q", value)
+	}
+	return time.Month(month), nil
+}
+
+// HandleLeaveReport generates and returns a PDF of a user's leave history and
+// the days taken per policy for an academic year, or another export format
+// chosen by ?format= or the Accept header. The Node.js API only serves the
+// caller's own leave, so the user ID must be "me" or the caller's own ID.
+func (s *Service) HandleLeaveReport(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+	if userID == "" {
+		http.Error(w, `{"error":"User ID is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	// The academic year is named by the calendar year it begins in and
+	// defaults to the current one
+	year := models.AcademicYearOf(time.Now(), s.AcademicYearStart)
+	if value := r.URL.Query().Get("year"); value != "" {
+		start, err := strconv.Atoi(value)
+		if err != nil || start < 1900 || start > 9999 {
+			http.Error(w, `{"error":"Invalid academic year"}`, http.StatusBadRequest)
+			return
+		}
+		year = models.NewAcademicYear(start, s.AcademicYearStart)
+	}
+
+	format, ok := s.negotiateFormat(w, r, export.ReportFormats, "report")
+	if !ok {
+		return
+	}
+
+	mode, ok := s.selectMode(w, r, format)
+	if !ok {
+		return
+	}
+
+	creds, _ := client.CredentialsFromContext(r.Context())
+	claims, ok := creds.Claims()
+	if userID != "me" && (!ok || userID != strconv.Itoa(claims.UserID)) {
+		http.Error(w, `{"error":"Leave reports are only available for your own account"}`, http.StatusForbidden)
+		return
+	}
+	if ok {
+		userID = strconv.Itoa(claims.UserID)
+	}
+
+	// The Node.js API responds with 404 when the user has no requests or
+	// policies, which is an empty report rather than an error
+	history, err := s.NodejsClient.GetLeaveHistory(r.Context())
+	if err != nil && !errors.Is(err, client.ErrNotFound) {
+		fmt.Printf("Error fetching leave history for user %s: %v\n", userID, err)
+		writeBackendError(w, err, "Leave history not found", "Failed to fetch leave data")
+		return
+	}
+	policies, err := s.NodejsClient.GetLeavePolicies(r.Context())
+	if err != nil && !errors.Is(err, client.ErrNotFound) {
+		fmt.Printf("Error fetching leave policies for user %s: %v\n", userID, err)
+		writeBackendError(w, err, "Leave policies not found", "Failed to fetch leave data")
+		return
+	}
+
+	var requests []models.LeaveRequest
+	for _, request := range history {
+		if year.Overlaps(request) {
+			requests = append(requests, request)
+		}
+	}
+	summaries := models.SummarizeLeave(requests, policies, year)
+
+	// Every request carries the requester's name; fall back to the ID for a
+	// user without any
+	userName := "User " + userID
+	if len(history) > 0 && history[0].User != "" {
+		userName = history[0].User
+	}
+
+	report := &export.Report{
+		Name: fmt.Sprintf("leave_%s_%d", sanitizeFilename(userID), year.Start.Year()),
+		Data: models.LeaveReport{
+			UserID:       claims.UserID,
+			User:         userName,
+			AcademicYear: year.String(),
+			Period:       year,
+			Requests:     requests,
+			Policies:     summaries,
+		},
+		Tables: leaveReportTables(requests, summaries),
+		Mode:   mode,
+	}
+	if !s.writeReport(w, r, format, report) {
+		return
+	}
+
+	fmt.Printf("Successfully generated leave report for user %s (%s, %d requests)\n", userID, year, len(requests))
+}
+
+// leaveReportTables lays the leave report out as its PDF does: the requests,
+// then the days taken per policy
+func leaveReportTables(requests []models.LeaveRequest, summaries []models.LeavePolicySummary) []export.Table {
+	requestTable := export.Table{Title: "Leave Requests", Columns: []string{"From", "To", "Policy", "Days", "Status", "Approver"}}
+	for _, request := range requests {
+		requestTable.Rows = append(requestTable.Rows, []string{
+			tableDate(request.From),
+			tableDate(request.To),
+			request.Policy,
+			strconv.Itoa(request.DayCount()),
+			request.Status,
+			request.Approver,
+		})
+	}
+
+	summaryTable := export.Table{Title: "Days Taken Per Policy", Columns: []string{"Policy", "Requests", "Days Approved", "Days On Review"}}
+	for _, summary := range summaries {
+		summaryTable.Rows = append(summaryTable.Rows, []string{
+			summary.Policy,
+			strconv.Itoa(summary.Requests),
+			strconv.Itoa(summary.ApprovedDays),
+			strconv.Itoa(summary.PendingDays),
+		})
+	}
+
+	return []export.Table{requestTable, summaryTable}
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-service/internal/client"
+	"go-service/internal/export"
+	"go-service/pkg/models"
+)
+
+// HandleLeaveDigest generates and returns a digest of the leave requests
+// waiting for the caller's approval as a PDF, or in another format chosen by
+// ?format= or the Accept header
+func (s *Service) HandleLeaveDigest(w http.ResponseWriter, r *http.Request) {
+	format, ok := s.negotiateFormat(w, r, export.ReportFormats, "digest")
+	if !ok {
+		return
+	}
+
+	mode, ok := s.selectMode(w, r, format)
+	if !ok {
+		return
+	}
+
+	digest, err := s.fetchLeaveDigest(r.Context(), time.Now())
+	if err != nil {
+		fmt.Printf("Error fetching pending leave requests: %v\n", err)
+		writeBackendError(w, err, "Pending leave requests not found", "Failed to fetch pending leave requests")
+		return
+	}
+
+	report := leaveDigestReport(digest)
+	report.Mode = mode
+	if !s.writeReport(w, r, format, report) {
+		return
+	}
+
+	fmt.Printf("Successfully generated leave digest (%d pending requests)\n", digest.Requests)
+}
+
+// fetchLeaveDigest fetches the pending leave requests and builds the digest
+// of those the caller, or the service account, may review. The Node.js API
+// responds with 404 when nothing is pending, which is an empty digest rather
+// than an error.
+func (s *Service) fetchLeaveDigest(ctx context.Context, now time.Time) (models.LeaveDigest, error) {
+	requests, err := s.NodejsClient.GetPendingLeaves(ctx)
+	if err != nil && !errors.Is(err, client.ErrNotFound) {
+		return models.LeaveDigest{}, err
+	}
+
+	// The Node.js API lists every pending request to anyone allowed to see
+	// the list, and has just accepted the token, so its user ID can be
+	// trusted to narrow the list down
+	claims, ok := s.NodejsClient.Claims(ctx)
+	if !ok || claims.UserID == 0 {
+		return models.LeaveDigest{}, fmt.Errorf("no approver to build the digest for: %w", client.ErrUnauthorized)
+	}
+	return models.BuildLeaveDigest(requests, claims.UserID, now), nil
+}
+
+// leaveDigestReport defines the digest report, named after the time it was
+// generated
+func leaveDigestReport(digest models.LeaveDigest) *export.Report {
+	requests := export.Table{Title: "Pending Requests", Columns: []string{"Requester", "Department", "Policy", "From", "To", "Days", "Submitted", "Overlaps With"}}
+	for _, requester := range digest.Requesters {
+		for _, policy := range requester.Policies {
+			for _, request := range policy.Requests {
+				requests.Rows = append(requests.Rows, []string{
+					requester.Name,
+					requester.Department,
+					policy.Policy,
+					tableDate(request.From),
+					tableDate(request.To),
+					strconv.Itoa(request.DayCount()),
+					tableDate(request.Submitted),
+					strings.Join(request.OverlapsWith, ", "),
+				})
+			}
+		}
+	}
+
+	overlaps := export.Table{Title: "Overlapping Absences", Columns: []string{"Department", "From", "To", "First Requester", "First Policy", "Second Requester", "Second Policy"}}
+	for _, overlap := range digest.Overlaps {
+		overlaps.Rows = append(overlaps.Rows, []string{
+			overlap.Department,
+			tableDate(overlap.From),
+			tableDate(overlap.To),
+			overlap.First.User,
+			overlap.First.Policy,
+			overlap.Second.User,
+			overlap.Second.Policy,
+		})
+	}
+
+	return &export.Report{
+		Name:   "leave_digest_" + digest.GeneratedAt.Format("20060102-1504"),
+		Data:   digest,
+		Tables: []export.Table{requests, overlaps},
+	}
+}
+
+// leaveDigestSchedule configures the periodic leave digest
+type leaveDigestSchedule struct {
+	Interval time.Duration
+	// Dir receives a digest file on every run
+	Dir    string
+	Format string
+}
+
+// loadLeaveDigestSchedule reads the periodic leave digest configuration from
+// LEAVE_DIGEST_INTERVAL, LEAVE_DIGEST_DIR and LEAVE_DIGEST_FORMAT. It returns
+// nil when no interval is set.
+func loadLeaveDigestSchedule() (*leaveDigestSchedule, error) {
+	value := os.Getenv("LEAVE_DIGEST_INTERVAL")
+	if value == "" {
+		return nil, nil
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		return nil, fmt.Errorf("LEAVE_DIGEST_INTERVAL must be a positive duration such as 24h, got %q", value)
+	}
+
+	schedule := &leaveDigestSchedule{
+		Interval: interval,
+		Dir:      os.Getenv("LEAVE_DIGEST_DIR"),
+		Format:   os.Getenv("LEAVE_DIGEST_FORMAT"),
+	}
+	if schedule.Dir == "" {
+		schedule.Dir = "data/digests"
+	}
+	if schedule.Format == "" {
+		schedule.Format = export.FormatPDF
+	}
+	if schedule.Format != export.FormatPDF && schedule.Format != export.FormatHTML {
+		return nil, fmt.Errorf("LEAVE_DIGEST_FORMAT must be pdf or html, got %q", schedule.Format)
+	}
+	return schedule, nil
+}
+
+// scheduleLeaveDigest writes a leave digest into the schedule's directory
+// every interval until ctx is done. The digests are fetched with the service
+// account, so they cover the requests the service account may approve.
+func (s *Service) scheduleLeaveDigest(ctx context.Context, schedule leaveDigestSchedule) {
+	ticker := time.NewTicker(schedule.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			path, err := s.writeLeaveDigest(ctx, schedule, now)
+			if err != nil {
+				fmt.Printf("Error writing scheduled leave digest: %v\n", err)
+				continue
+			}
+			fmt.Printf("Wrote scheduled leave digest %s\n", path)
+		}
+	}
+}
+
+// writeLeaveDigest builds a digest and writes it into the schedule's
+// directory, returning the path of the new file. The file is written under a
+// temporary name first so readers never see a partial digest.
+func (s *Service) writeLeaveDigest(ctx context.Context, schedule leaveDigestSchedule, now time.Time) (string, error) {
+	digest, err := s.fetchLeaveDigest(ctx, now)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch pending leave requests: %w", err)
+	}
+
+	report := leaveDigestReport(digest)
+	content, _, err := s.Renderers.Render(ctx, schedule.Format, report)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate leave digest: %w", err)
+	}
+	defer export.Close(content)
+
+	if err := os.MkdirAll(schedule.Dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create digest directory: %w", err)
+	}
+
+	path := filepath.Join(schedule.Dir, report.Name+"."+schedule.Format)
+	if err := writeFile(path+".tmp", content); err != nil {
+		os.Remove(path + ".tmp")
+		return "", fmt.Errorf("failed to write leave digest: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return "", fmt.Errorf("failed to write leave digest: %w", err)
+	}
+	return path, nil
+}
+
+// writeFile streams content into a new file at path
+func writeFile(path string, content io.Reader) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-service/internal/client"
+)
+
+// TestLoadLeaveDigestSchedule tests reading the leave digest schedule from the environment
+func TestLoadLeaveDigestSchedule(t *testing.T) {
+	testCases := []struct {
+		name     string
+		env      map[string]string
+		interval time.Duration
+		format   string
+		errorMsg string
+	}{
+		{"not_configured", nil, 0, "", ""},
+		{"defaults", map[string]string{"LEAVE_DIGEST_INTERVAL": "24h"}, 24 * time.Hour, "pdf", ""},
+		{"html", map[string]string{"LEAVE_DIGEST_INTERVAL": "1h", "LEAVE_DIGEST_FORMAT": "html"}, time.Hour, "html", ""},
+		{"invalid_interval", map[string]string{"LEAVE_DIGEST_INTERVAL": "daily"}, 0, "", "positive duration"},
+		{"invalid_format", map[string]string{"LEAVE_DIGEST_INTERVAL": "24h", "LEAVE_DIGEST_FORMAT": "docx"}, 0, "", "pdf or html"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, name := range []string{"LEAVE_DIGEST_INTERVAL", "LEAVE_DIGEST_DIR", "LEAVE_DIGEST_FORMAT"} {
+				t.Setenv(name, tc.env[name])
+			}
+
+			schedule, err := loadLeaveDigestSchedule()
+			if tc.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errorMsg) {
+					t.Errorf("Expected error containing %q, got %v", tc.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if tc.interval == 0 {
+				if schedule != nil {
+					t.Errorf("Expected no schedule, got %+v", schedule)
+				}
+				return
+			}
+			if schedule == nil || schedule.Interval != tc.interval || schedule.Format != tc.format || schedule.Dir != "data/digests" {
+				t.Errorf("Unexpected schedule %+v", schedule)
+			}
+		})
+	}
+}
+
+// TestWriteLeaveDigest tests that a scheduled run writes a complete digest
+// of the requests its account may review, and that nothing pending still
+// produces a digest
+func TestWriteLeaveDigest(t *testing.T) {
+	var empty atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if empty.Load() {
+			http.Error(w, `{"error":"Pending leave requests not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"pendingLeaves":[{"id":1,"policy":"Sick Leave","policyId":1,"from":"2024-05-06T00:00:00.000Z","to":"2024-05-08T00:00:00.000Z","note":null,"submitted":"2024-05-01T08:00:00.000Z","updated":null,"user":"Edna Krabappel","reporterId":4,"roleId":2,"department":"Primary","days":"3"},{"id":2,"policy":"Sick Leave","policyId":1,"from":"2024-05-06T00:00:00.000Z","to":"2024-05-08T00:00:00.000Z","note":null,"submitted":"2024-05-01T08:00:00.000Z","updated":null,"user":"Seymour Skinner","reporterId":5,"roleId":2,"department":"Primary","days":"3"}]}`))
+	}))
+	defer server.Close()
+
+	service := &Service{NodejsClient: client.NewNodejsClient(server.URL), Renderers: newRenderers(nil, nil, nil, "", nil)}
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"id":4,"role":"teacher","roleId":2}`))
+	service.NodejsClient.SetAuthTokens("header."+claims+".signature", "csrf")
+	schedule := leaveDigestSchedule{Interval: time.Hour, Dir: filepath.Join(t.TempDir(), "digests"), Format: "html"}
+	now := time.Date(2024, time.May, 2, 8, 0, 0, 0, time.UTC)
+
+	path, err := service.writeLeaveDigest(context.Background(), schedule, now)
+	if err != nil {
+		t.Fatalf("Expected digest to be written, got error: %v", err)
+	}
+	if filepath.Base(path) != "leave_digest_20240502-0800.html" {
+		t.Errorf("Unexpected digest file name %s", path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read digest: %v", err)
+	}
+	if !strings.Contains(string(content), "Edna Krabappel") {
+		t.Error("Expected digest to list the pending request")
+	}
+	if strings.Contains(string(content), "Seymour Skinner") {
+		t.Error("Expected digest to leave out the request reporting to someone else")
+	}
+
+	empty.Store(true)
+	path, err = service.writeLeaveDigest(context.Background(), schedule, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Expected empty digest to be written, got error: %v", err)
+	}
+	if content, _ := os.ReadFile(path); !strings.Contains(string(content), "No leave requests are waiting for approval") {
+		t.Error("Expected empty digest to say no requests are waiting")
+	}
+
+	entries, _ := os.ReadDir(schedule.Dir)
+	if len(entries) != 2 {
+		t.Errorf("Expected 2 digest files and no temporary files, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-service/internal/client"
+	"go-service/internal/export"
+	"go-service/pkg/models"
+)
+
+// noticeFetchConcurrency bounds the number of concurrent notice detail requests
+const noticeFetchConcurrency = 5
+
+// bulletinDays is the number of days a bulletin covers when no range is given
+const bulletinDays = 7
+
+// bulletinFilter selects the notices printed on a bulletin
+type bulletinFilter struct {
+	// From and To are the first and last published day, inclusive
+	From time.Time
+	To   time.Time
+	// RecipientType is one of the models.NoticeRecipient types, or empty for any
+	RecipientType string
+	// RoleID keeps the notices that reach users of the role, or 0 for any
+	RoleID int
+}
+
+// HandleNoticeBulletin generates and returns a printable bulletin board PDF of
+// the approved notices published in a date range, optionally narrowed to a
+// recipient type or the notices reaching a role. Other export formats can be
+// chosen by ?format= or the Accept header.
+func (s *Service) HandleNoticeBulletin(w http.ResponseWriter, r *http.Request) {
+	filter, message := parseBulletinFilter(r, time.Now())
+	if message != "" {
+		writeError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	format, ok := s.negotiateFormat(w, r, export.ReportFormats, "report")
+	if !ok {
+		return
+	}
+
+	mode, ok := s.selectMode(w, r, format)
+	if !ok {
+		return
+	}
+
+	bulletin, err := s.fetchBulletin(r.Context(), filter)
+	if err != nil {
+		fmt.Printf("Error fetching notices: %v\n", err)
+		writeBackendError(w, err, "Notice not found", "Failed to fetch notices")
+		return
+	}
+
+	report := &export.Report{
+		Name:   fmt.Sprintf("notices_%s_%s", filter.From.Format("2006-01-02"), filter.To.Format("2006-01-02")),
+		Data:   bulletin,
+		Tables: []export.Table{bulletinTable(bulletin)},
+		Mode:   mode,
+	}
+	if !s.writeReport(w, r, format, report) {
+		return
+	}
+
+	fmt.Printf("Successfully generated notice bulletin (%d notices)\n", len(bulletin.Notices))
+}
+
+// bulletinTable lists the notices of a bulletin with a row per notice
+func bulletinTable(bulletin models.NoticeBulletin) export.Table {
+	table := export.Table{Title: "Notices", Columns: []string{"Published", "Title", "Author", "Reviewer", "Recipients", "Description"}}
+	for _, notice := range bulletin.Notices {
+		table.Rows = append(table.Rows, []string{
+			tableDate(notice.PublishedDate()),
+			notice.Title,
+			notice.Author,
+			notice.ReviewerName,
+			notice.Recipients,
+			notice.Description,
+		})
+	}
+	return table
+}
+
+// parseBulletinFilter reads the from, to, recipientType and roleId query
+// parameters. The range defaults to the week ending today. It returns an error
+// message for the caller when a parameter is invalid.
+func parseBulletinFilter(r *http.Request, now time.Time) (bulletinFilter, string) {
+	query := r.URL.Query()
+	filter := bulletinFilter{
+		To:            time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		RecipientType: query.Get("recipientType"),
+	}
+
+	if value := query.Get("to"); value != "" {
+		to, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return filter, "Invalid date, expected YYYY-MM-DD"
+		}
+		filter.To = to
+	}
+	filter.From = filter.To.AddDate(0, 0, 1-bulletinDays)
+	if value := query.Get("from"); value != "" {
+		from, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return filter, "Invalid date, expected YYYY-MM-DD"
+		}
+		filter.From = from
+	}
+	if filter.From.After(filter.To) {
+		return filter, "The from date must not be after the to date"
+	}
+
+	switch filter.RecipientType {
+	case "", models.NoticeRecipientEveryone, models.NoticeRecipientSpecific:
+	default:
+		return filter, "Invalid recipient type, expected EV or SP"
+	}
+
+	if value := query.Get("roleId"); value != "" {
+		roleID, err := strconv.Atoi(value)
+		if err != nil || roleID <= 0 {
+			return filter, "Invalid role ID"
+		}
+		filter.RoleID = roleID
+	}
+
+	return filter, ""
+}
+
+// fetchBulletin collects the approved notices matching filter. The notice list
+// omits the recipients, so each notice published in the range is fetched in
+// full before the recipient filters are applied.
+func (s *Service) fetchBulletin(ctx context.Context, filter bulletinFilter) (models.NoticeBulletin, error) {
+	bulletin := models.NoticeBulletin{From: filter.From, To: filter.To}
+
+	notices, err := s.NodejsClient.GetNotices(ctx)
+	if err != nil && !errors.Is(err, client.ErrNotFound) {
+		return bulletin, err
+	}
+
+	end := filter.To.AddDate(0, 0, 1)
+	var published []models.Notice
+	for _, notice := range notices {
+		date := notice.PublishedDate()
+		if notice.StatusID == models.NoticeStatusApproved && !date.Before(filter.From) && date.Before(end) {
+			published = append(published, notice)
+		}
+	}
+
+	if err := s.fetchNoticeRecipients(ctx, published); err != nil {
+		return bulletin, err
+	}
+
+	// Role names only make the recipients readable, so a caller who may
+	// not list roles still gets the bulletin
+	roles := make(map[int]string)
+	if list, err := s.NodejsClient.GetRoles(ctx); err == nil {
+		for _, role := range list {
+			roles[role.ID] = role.Name
+		}
+	}
+	roleName := func(id int) string {
+		if name, ok := roles[id]; ok {
+			return name
+		}
+		return fmt.Sprintf("Role %d", id)
+	}
+
+	for _, notice := range published {
+		if filter.RecipientType != "" && notice.RecipientType != filter.RecipientType {
+			continue
+		}
+		if filter.RoleID != 0 && !notice.AddressedTo(filter.RoleID) {
+			continue
+		}
+
+		recipients := "Everyone"
+		if notice.RecipientType != models.NoticeRecipientEveryone {
+			recipients = roleName(notice.RecipientRole)
+			if notice.FirstField != "" {
+				recipients += " - " + notice.FirstField
+			}
+		}
+		bulletin.Notices = append(bulletin.Notices, models.BulletinNotice{Notice: notice, Recipients: recipients})
+	}
+
+	switch {
+	case filter.RoleID != 0:
+		bulletin.Audience = roleName(filter.RoleID)
+	case filter.RecipientType == models.NoticeRecipientEveryone:
+		bulletin.Audience = "Everyone"
+	case filter.RecipientType == models.NoticeRecipientSpecific:
+		bulletin.Audience = "Specific recipients"
+	}
+
+	return bulletin, nil
+}
+
+// fetchNoticeRecipients fills in the recipients of each notice from its detail
+func (s *Service) fetchNoticeRecipients(ctx context.Context, notices []models.Notice) error {
+	errs := make([]error, len(notices))
+	sem := make(chan struct{}, noticeFetchConcurrency)
+	var wg sync.WaitGroup
+
+	for i := range notices {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			detail, err := s.NodejsClient.GetNotice(ctx, notices[i].ID)
+			if err != nil {
+				errs[i] = fmt.Errorf("notice %d: %w", notices[i].ID, err)
+				return
+			}
+			notices[i].RecipientType = detail.RecipientType
+			notices[i].RecipientRole = detail.RecipientRole
+			notices[i].FirstField = detail.FirstField
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
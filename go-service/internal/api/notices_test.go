@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-service/internal/client"
+)
+
+// TestParseBulletinFilter tests the bulletin query parameters and their defaults
+func TestParseBulletinFilter(t *testing.T) {
+	now := time.Date(2024, time.May, 10, 15, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		query    string
+		from, to string
+		errorMsg string
+	}{
+		{"default_week", "", "2024-05-04", "2024-05-10", ""},
+		{"week_ending", "to=2024-04-30", "2024-04-24", "2024-04-30", ""},
+		{"range", "from=2024-04-01&to=2024-04-30&recipientType=SP&roleId=2", "2024-04-01", "2024-04-30", ""},
+		{"invalid_date", "from=01/04/2024", "", "", "Invalid date"},
+		{"reversed_range", "from=2024-05-01&to=2024-04-01", "", "", "must not be after"},
+		{"invalid_recipient_type", "recipientType=ALL", "", "", "Invalid recipient type"},
+		{"invalid_role", "roleId=teacher", "", "", "Invalid role ID"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/v1/notices/bulletin?"+tc.query, nil)
+			filter, message := parseBulletinFilter(r, now)
+			if tc.errorMsg != "" {
+				if !strings.Contains(message, tc.errorMsg) {
+					t.Errorf("Expected error containing %q, got %q", tc.errorMsg, message)
+				}
+				return
+			}
+			if message != "" {
+				t.Fatalf("Expected no error, got %q", message)
+			}
+			if from := filter.From.Format("2006-01-02"); from != tc.from {
+				t.Errorf("Expected from %s, got %s", tc.from, from)
+			}
+			if to := filter.To.Format("2006-01-02"); to != tc.to {
+				t.Errorf("Expected to %s, got %s", tc.to, to)
+			}
+		})
+	}
+}
+
+// TestFetchBulletin tests that only approved notices published in the range
+// and reaching the requested role are kept, with readable recipients
+func TestFetchBulletin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/notices":
+			w.Write([]byte(`{"notices":[
+				{"id":1,"title":"Sports Day","description":"All classes","author":"Seymour Skinner","createdDate":"2024-05-01T09:00:00.000Z","reviewerName":"Gary Chalmers","reviewedDate":"2024-05-06T09:00:00.000Z","status":"Approved","statusId":5},
+				{"id":2,"title":"Staff Meeting","description":"Room 4","author":"Seymour Skinner","createdDate":"2024-05-07T09:00:00.000Z","reviewerName":"Gary Chalmers","reviewedDate":"2024-05-07T10:00:00.000Z","status":"Approved","statusId":5},
+				{"id":3,"title":"Field Trip","description":"Grade 9","author":"Edna Krabappel","createdDate":"2024-05-08T09:00:00.000Z","reviewerName":"Gary Chalmers","reviewedDate":"2024-05-08T10:00:00.000Z","status":"Approved","statusId":5},
+				{"id":4,"title":"Draft","description":"Not yet","author":"Edna Krabappel","createdDate":"2024-05-08T09:00:00.000Z","reviewerName":null,"reviewedDate":null,"status":"Draft","statusId":1},
+				{"id":5,"title":"Last Month","description":"Old","author":"Edna Krabappel","createdDate":"2024-04-01T09:00:00.000Z","reviewerName":"Gary Chalmers","reviewedDate":"2024-04-02T09:00:00.000Z","status":"Approved","statusId":5}
+			]}`))
+		case "/api/v1/notices/1":
+			w.Write([]byte(`{"id":1,"status":5,"recipientType":"EV","recipientRole":null,"firstField":null}`))
+		case "/api/v1/notices/2":
+			w.Write([]byte(`{"id":2,"status":5,"recipientType":"SP","recipientRole":2,"firstField":"3"}`))
+		case "/api/v1/notices/3":
+			w.Write([]byte(`{"id":3,"status":5,"recipientType":"SP","recipientRole":3,"firstField":"Grade 9"}`))
+		case "/api/v1/roles":
+			http.Error(w, `{"error":"Forbidden"}`, http.StatusForbidden)
+		default:
+			http.Error(w, fmt.Sprintf(`{"error":"Unexpected request for %s"}`, r.URL.Path), http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	service := &Service{NodejsClient: client.NewNodejsClient(server.URL)}
+	filter := bulletinFilter{
+		From:   time.Date(2024, time.May, 6, 0, 0, 0, 0, time.UTC),
+		To:     time.Date(2024, time.May, 12, 0, 0, 0, 0, time.UTC),
+		RoleID: 2,
+	}
+
+	bulletin, err := service.fetchBulletin(context.Background(), filter)
+	if err != nil {
+		t.Fatalf("Expected bulletin to be fetched, got error: %v", err)
+	}
+
+	if len(bulletin.Notices) != 2 {
+		t.Fatalf("Expected the notices for everyone and for role 2, got %+v", bulletin.Notices)
+	}
+	if notice := bulletin.Notices[0]; notice.ID != 1 || notice.Recipients != "Everyone" {
+		t.Errorf("Unexpected first notice %+v", notice)
+	}
+	if notice := bulletin.Notices[1]; notice.ID != 2 || notice.Recipients != "Role 2 - 3" || notice.ReviewerName != "Gary Chalmers" {
+		t.Errorf("Unexpected second notice %+v", notice)
+	}
+	if bulletin.Audience != "Role 2" {
+		t.Errorf("Expected audience Role 2, got %q", bulletin.Audience)
+	}
+}
@@ -0,0 +1,257 @@
+package api
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"go-service/internal/client"
+	"go-service/internal/export"
+	"go-service/internal/html"
+	"go-service/internal/pdf"
+	"go-service/internal/redaction"
+	"go-service/internal/signing"
+	"go-service/internal/verify"
+	"go-service/pkg/models"
+)
+
+// newRenderers creates the report renderers: the PDF and HTML documents,
+// drawn with the service's fonts and the branding current at the time of
+// each report, next to the table formats. PDFs are made verifiable at
+// verifyBaseURL when a verifier is given, and signed when a signer is.
+func newRenderers(fonts *pdf.FontSet, branding *pdf.BrandingStore, verifier *verify.Store, verifyBaseURL string, signer *signing.Signer) *export.Registry {
+	renderers := export.NewRegistry()
+	pdfRenderer := pdf.NewRenderer(func() []pdf.Option {
+		return []pdf.Option{pdf.WithFonts(fonts), pdf.WithBranding(branding.Current())}
+	})
+	if verifier != nil {
+		pdfRenderer.SetVerifier(verifier, func(id string) string {
+			return strings.TrimSuffix(verifyBaseURL, "/") + "/api/v1/verify/" + id
+		})
+	}
+	if signer != nil {
+		pdfRenderer.SetSigner(signer)
+	}
+	renderers.Register(export.FormatPDF, pdf.ContentType, pdfRenderer)
+	renderers.Register(export.FormatHTML, html.ContentType, html.NewRenderer(branding.Current))
+	return renderers
+}
+
+// negotiateFormat selects the format of a report from formats by the
+// ?format= parameter or the Accept header. It writes the error response and
+// returns false when none can be used; name names the report in the message.
+func (s *Service) negotiateFormat(w http.ResponseWriter, r *http.Request, formats []string, name string) (string, bool) {
+	format, err := s.Renderers.Negotiate(r, formats)
+	switch {
+	case errors.Is(err, export.ErrUnsupportedFormat):
+		http.Error(w, fmt.Sprintf(`{"error":"Unsupported %s format"}`, name), http.StatusBadRequest)
+		return "", false
+	case err != nil:
+		http.Error(w, `{"error":"No acceptable report format, expected one of `+strings.Join(formats, ", ")+`"}`, http.StatusNotAcceptable)
+		return "", false
+	}
+	return format, true
+}
+
+// selectMode returns the document mode named by the ?mode= query parameter,
+// or an empty mode for a regular document. Modes only apply to PDFs, and an
+// official document may only be requested by callers whose role holds the
+// service's official report permission. It writes the error response and
+// returns false when the mode cannot be used.
+func (s *Service) selectMode(w http.ResponseWriter, r *http.Request, format string) (string, bool) {
+	mode := r.URL.Query().Get("mode")
+	switch mode {
+	case "":
+		return "", true
+	case export.ModeDraft, export.ModeOfficial:
+	default:
+		http.Error(w, `{"error":"Unknown report mode, expected draft or official"}`, http.StatusBadRequest)
+		return "", false
+	}
+
+	if format != export.FormatPDF {
+		http.Error(w, `{"error":"Report modes are only available for PDF reports"}`, http.StatusBadRequest)
+		return "", false
+	}
+	if mode == export.ModeDraft {
+		return mode, true
+	}
+
+	// Official documents are numbered by the verification store
+	if s.Verifier == nil {
+		http.Error(w, `{"error":"Official reports are unavailable"}`, http.StatusServiceUnavailable)
+		return "", false
+	}
+
+	permissions, ok := s.callerPermissions(w, r)
+	if !ok {
+		return "", false
+	}
+	method, path, _ := strings.Cut(s.OfficialPermission, " ")
+	if permissions == nil || !permissions.AllowsAPI(method, path) {
+		http.Error(w, `{"error":"Official reports require an elevated role"}`, http.StatusForbidden)
+		return "", false
+	}
+	return mode, true
+}
+
+// selectRedaction returns the redaction rule of the caller's role, selected
+// by the permissions the Node.js API grants it, or nil when the service has
+// no redaction policy. It writes the error response and returns false when
+// the permissions cannot be checked.
+func (s *Service) selectRedaction(w http.ResponseWriter, r *http.Request) (*redaction.Rule, bool) {
+	if s.Redaction == nil {
+		return nil, true
+	}
+
+	permissions, ok := s.callerPermissions(w, r)
+	if !ok {
+		return nil, false
+	}
+	return s.Redaction.Select(permissions), true
+}
+
+// callerPermissions fetches the permissions of the caller's role, which are
+// nil for a role without any. It writes the error response and returns false
+// when they cannot be fetched.
+func (s *Service) callerPermissions(w http.ResponseWriter, r *http.Request) (*models.Permissions, bool) {
+	// The Node.js API answers 404 for a role without any permissions
+	permissions, err := s.NodejsClient.GetMyPermissions(r.Context())
+	if err != nil && !errors.Is(err, client.ErrNotFound) {
+		fmt.Printf("Error fetching caller permissions: %v\n", err)
+		writeBackendError(w, err, "Failed to check permissions", "Failed to check permissions")
+		return nil, false
+	}
+	return permissions, true
+}
+
+// reportPasswordHeader carries the password a caller protects a PDF report
+// with, which is kept out of the URL so it is not logged
+const reportPasswordHeader = "X-Report-Password"
+
+// selectProtection returns the protection requested for a PDF report, or nil
+// for an unprotected report. A report is protected when the caller supplies
+// its password in the X-Report-Password header, asks for ?protect=true, or
+// withholds permissions with ?deny=copy,modify. Without a supplied password
+// the password is left empty, to be derived from the record by the
+// service's password rule. It writes the error response and returns false
+// when the protection cannot be used.
+func (s *Service) selectProtection(w http.ResponseWriter, r *http.Request, format string) (*export.Protection, bool) {
+	password := r.Header.Get(reportPasswordHeader)
+	deny := r.URL.Query().Get("deny")
+	if password == "" && deny == "" && r.URL.Query().Get("protect") != "true" {
+		return nil, true
+	}
+
+	if format != export.FormatPDF {
+		http.Error(w, `{"error":"Password protection is only available for PDF reports"}`, http.StatusBadRequest)
+		return nil, false
+	}
+
+	protection := &export.Protection{Password: password}
+	if deny != "" {
+		for _, permission := range strings.Split(deny, ",") {
+			permission = strings.TrimSpace(permission)
+			if !slices.Contains(export.Permissions, permission) {
+				http.Error(w, `{"error":"Unknown report permission, expected one of `+strings.Join(export.Permissions, ", ")+`"}`, http.StatusBadRequest)
+				return nil, false
+			}
+			protection.Deny = append(protection.Deny, permission)
+		}
+	}
+
+	if password == "" && s.PasswordRule == nil {
+		http.Error(w, `{"error":"A report password is required"}`, http.StatusBadRequest)
+		return nil, false
+	}
+	return protection, true
+}
+
+// protectStudentReport returns the protection of a student's report, with the
+// password derived by the service's password rule unless the caller supplied
+// one. It returns nil for an unprotected report.
+func (s *Service) protectStudentReport(protection *export.Protection, student *models.Student) (*export.Protection, error) {
+	if protection == nil || protection.Password != "" {
+		return protection, nil
+	}
+
+	password, err := s.PasswordRule.Password(student)
+	if err != nil {
+		return nil, err
+	}
+	protected := *protection
+	protected.Password = password
+	return &protected, nil
+}
+
+// writeReport renders a report in format and streams it as the response.
+// HTML is served inline for the browser and every other format as a
+// download. It writes the error response and returns false when the report
+// cannot be rendered.
+func (s *Service) writeReport(w http.ResponseWriter, r *http.Request, format string, report *export.Report) bool {
+	content, contentType, err := s.Renderers.Render(r.Context(), format, report)
+	if err == nil {
+		defer export.Close(content)
+	}
+	size := -1
+	if sized, ok := content.(interface{ Len() int }); ok {
+		size = sized.Len()
+	}
+
+	// Wait for the start of the document before writing the headers, so a
+	// renderer that fails while drawing it can still be reported
+	body := bufio.NewReader(content)
+	if err == nil {
+		if _, err = body.Peek(1); err == io.EOF {
+			err = nil
+		}
+	}
+	switch {
+	case errors.Is(err, export.ErrUnsupportedFormat):
+		http.Error(w, `{"error":"Unsupported report format"}`, http.StatusBadRequest)
+		return false
+	case err != nil:
+		fmt.Printf("Error generating %s report %s: %v\n", format, report.Name, err)
+		http.Error(w, fmt.Sprintf(`{"error":"Failed to generate %s report"}`, strings.ToUpper(format)), http.StatusInternalServerError)
+		return false
+	}
+
+	disposition := "attachment"
+	if format == export.FormatHTML {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("%s; filename=%s.%s", disposition, report.Name, format))
+	if size >= 0 {
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", size))
+	}
+
+	if _, err := io.Copy(w, body); err != nil {
+		fmt.Printf("Error writing %s report %s: %v\n", format, report.Name, err)
+		return false
+	}
+	return true
+}
+
+// profileTable lays the fields of a single-record report out as a table in
+// the order of its template
+func profileTable(title string, values []pdf.FieldValue) export.Table {
+	table := export.Table{Title: title, Columns: []string{"Section", "Field", "Value"}}
+	for _, value := range values {
+		table.Rows = append(table.Rows, []string{value.Section, value.Label, value.Value})
+	}
+	return table
+}
+
+// tableDate formats a date for a report table, leaving unknown dates empty
+func tableDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
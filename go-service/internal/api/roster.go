@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go-service/internal/client"
+	"go-service/internal/export"
+	"go-service/internal/pdf"
+	"go-service/internal/redaction"
+	"go-service/pkg/models"
+
+	"github.com/gorilla/mux"
+)
+
+// rosterFetchConcurrency bounds the number of concurrent student detail requests
+const rosterFetchConcurrency = 5
+
+// HandleClassRoster generates and returns a PDF roster for a class section,
+// or another export format chosen by ?format= or the Accept header
+func (s *Service) HandleClassRoster(w http.ResponseWriter, r *http.Request) {
+	// Extract class and section from URL
+	vars := mux.Vars(r)
+	className := vars["class"]
+	section := vars["section"]
+
+	if className == "" || section == "" {
+		http.Error(w, `{"error":"Class and section are required"}`, http.StatusBadRequest)
+		return
+	}
+
+	format, ok := s.negotiateFormat(w, r, export.ReportFormats, "report")
+	if !ok {
+		return
+	}
+
+	mode, ok := s.selectMode(w, r, format)
+	if !ok {
+		return
+	}
+
+	redact, ok := s.selectRedaction(w, r)
+	if !ok {
+		return
+	}
+
+	// Optional name and roll filters are passed through to the Node.js API
+	filter := client.StudentFilter{
+		ClassName: className,
+		Section:   section,
+		Name:      r.URL.Query().Get("name"),
+		Roll:      r.URL.Query().Get("roll"),
+	}
+
+	// Large rosters can be generated in the background and polled for
+	if wantsAsync(r) {
+		filename := fmt.Sprintf("roster_%s_%s.%s", sanitizeFilename(className), sanitizeFilename(section), format)
+		s.submitJob(w, r, "class-roster", s.Renderers.ContentType(format), filename, func(ctx context.Context, w io.Writer, progress func(done, total int)) error {
+			students, err := s.fetchRoster(ctx, filter)
+			if err != nil {
+				return fmt.Errorf("failed to fetch students: %w", err)
+			}
+			redactRoster(redact, students)
+
+			report := rosterReport(filter, students)
+			report.Mode = mode
+			content, _, err := s.Renderers.Render(ctx, format, report)
+			if err != nil {
+				return err
+			}
+			defer export.Close(content)
+
+			_, err = io.Copy(w, content)
+			return err
+		})
+		return
+	}
+
+	students, err := s.fetchRoster(r.Context(), filter)
+	if err != nil {
+		fmt.Printf("Error fetching roster for class %s section %s: %v\n", className, section, err)
+		writeBackendError(w, err, "Student not found", "Failed to fetch student data")
+		return
+	}
+	redactRoster(redact, students)
+
+	report := rosterReport(filter, students)
+	report.Mode = mode
+	if !s.writeReport(w, r, format, report) {
+		return
+	}
+
+	fmt.Printf("Successfully generated roster for class %s section %s (%d students)\n", className, section, len(students))
+}
+
+// redactRoster hides the fields redact covers from every student of a roster
+func redactRoster(redact *redaction.Rule, students []models.Student) {
+	for i := range students {
+		redact.Apply(&students[i])
+	}
+}
+
+// rosterReport defines the roster report of the class section students were
+// listed for by filter, with the same columns in every format
+func rosterReport(filter client.StudentFilter, students []models.Student) *export.Report {
+	columns, rows := pdf.RosterTable(students)
+	return &export.Report{
+		Name: fmt.Sprintf("roster_%s_%s", sanitizeFilename(filter.ClassName), sanitizeFilename(filter.Section)),
+		Data: models.ClassRoster{
+			Class:      filter.ClassName,
+			Section:    filter.Section,
+			Students:   students,
+			NameFilter: filter.Name,
+			RollFilter: filter.Roll,
+		},
+		Tables: []export.Table{{Title: "Roster", Columns: columns, Rows: rows}},
+	}
+}
+
+// fetchRoster lists the students matching filter and fetches each student's
+// full profile, since the list endpoint only returns summary fields.
+// Students removed after the list was fetched are left out; any other
+// failure stops the remaining fetches and fails the roster. Students are
+// returned ordered by roll number.
+func (s *Service) fetchRoster(ctx context.Context, filter client.StudentFilter) ([]models.Student, error) {
+	list, err := s.NodejsClient.GetStudents(ctx, filter)
+	if err != nil {
+		// The Node.js API answers 404 when no students match the filters
+		if errors.Is(err, client.ErrNotFound) {
+			return []models.Student{}, nil
+		}
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	students := make([]*models.Student, len(list))
+	sem := make(chan struct{}, rosterFetchConcurrency)
+	var wg sync.WaitGroup
+	var failed sync.Once
+	var fetchErr error
+
+	for i := range list {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				return
+			}
+
+			student, err := s.NodejsClient.GetStudent(ctx, strconv.Itoa(list[i].ID))
+			if errors.Is(err, client.ErrNotFound) {
+				fmt.Printf("Leaving student %d out of the roster: %v\n", list[i].ID, err)
+				return
+			}
+			if err != nil {
+				failed.Do(func() {
+					fetchErr = fmt.Errorf("student %d: %w", list[i].ID, err)
+					cancel()
+				})
+				return
+			}
+			students[i] = student
+		}(i)
+	}
+	wg.Wait()
+
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	// Fetches skipped because the request was cancelled leave gaps
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	roster := make([]models.Student, 0, len(students))
+	for _, student := range students {
+		if student != nil {
+			roster = append(roster, *student)
+		}
+	}
+	sort.SliceStable(roster, func(i, j int) bool {
+		return roster[i].Roll < roster[j].Roll
+	})
+
+	return roster, nil
+}
+
+// sanitizeFilename replaces characters that are unsafe in a download filename
+func sanitizeFilename(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"go-service/internal/client"
+)
+
+// TestFetchRoster tests that students removed after the list was fetched are
+// left out of the roster, and that any other failure fails it and stops the
+// remaining fetches
+func TestFetchRoster(t *testing.T) {
+	var fetched atomic.Int32
+	failWith := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/students" {
+			var students []string
+			for id := 1; id <= 40; id++ {
+				students = append(students, fmt.Sprintf(`{"id":%d,"name":"Student %d"}`, id, id))
+			}
+			fmt.Fprintf(w, "[%s]", strings.Join(students, ","))
+			return
+		}
+
+		fetched.Add(1)
+		var id int
+		fmt.Sscanf(r.URL.Path, "/api/v1/students/%d", &id)
+		switch {
+		case id == 3:
+			http.Error(w, `{"error":"Student not found"}`, http.StatusNotFound)
+		case id == failWith:
+			http.Error(w, `{"error":"Internal server error"}`, http.StatusInternalServerError)
+		default:
+			fmt.Fprintf(w, `{"id":%d,"name":"Student %d","roll":%d}`, id, id, 41-id)
+		}
+	}))
+	defer server.Close()
+
+	service := &Service{NodejsClient: client.NewNodejsClient(server.URL)}
+
+	students, err := service.fetchRoster(context.Background(), client.StudentFilter{})
+	if err != nil {
+		t.Fatalf("Expected the roster to be fetched, got error: %v", err)
+	}
+	if len(students) != 39 {
+		t.Fatalf("Expected the 39 students still on record, got %d", len(students))
+	}
+	if students[0].ID != 40 || students[38].ID != 1 {
+		t.Errorf("Expected students in roll number order, got %d first and %d last", students[0].ID, students[38].ID)
+	}
+
+	fetched.Store(0)
+	failWith = 1
+	if _, err := service.fetchRoster(context.Background(), client.StudentFilter{}); err == nil || !strings.Contains(err.Error(), "student 1") {
+		t.Fatalf("Expected the failure of student 1, got %v", err)
+	}
+	if count := fetched.Load(); count >= 40 {
+		t.Errorf("Expected the remaining fetches to stop after the failure, got %d", count)
+	}
+}
@@ -1,8 +1,6 @@
 package api
 
 import (
-	"os"
-	
 	"github.com/gorilla/mux"
 )
 
@@ -11,11 +9,6 @@ func NewRouter() *mux.Router {
 	// Initialize service with dependencies
 	service := NewService()
 	
-	// For development/testing, set test tokens if AUTH_MODE=test
-	if os.Getenv("AUTH_MODE") == "test" {
-		service.SetTestTokens()
-	}
-	
 	router := mux.NewRouter()
 
 	// API v1 routes
@@ -23,7 +16,38 @@ func NewRouter() *mux.Router {
 	
 	// Students routes with authentication middleware
 	api.HandleFunc("/students/{id}/report", service.AuthMiddleware(service.HandleStudentReport)).Methods("GET")
+
+	// Staff routes with authentication middleware
+	api.HandleFunc("/staffs/{id}/report", service.AuthMiddleware(service.HandleStaffReport)).Methods("GET")
+
+	// Leave routes with authentication middleware
+	api.HandleFunc("/leave/users/{id}/report", service.AuthMiddleware(service.HandleLeaveReport)).Methods("GET")
+	api.HandleFunc("/leave/pending/digest", service.AuthMiddleware(service.HandleLeaveDigest)).Methods("GET")
+
+	// Notice routes with authentication middleware
+	api.HandleFunc("/notices/bulletin", service.AuthMiddleware(service.HandleNoticeBulletin)).Methods("GET")
+
+	// Report routes with authentication middleware
+	api.HandleFunc("/reports/students/bulk", service.AuthMiddleware(service.HandleBulkStudentReports)).Methods("POST")
+	api.HandleFunc("/reports/dashboard", service.AuthMiddleware(service.HandleDashboardReport)).Methods("GET")
+	api.HandleFunc("/reports/class-teachers", service.AuthMiddleware(service.HandleClassTeacherReport)).Methods("GET")
+
+	// Class routes with authentication middleware
+	api.HandleFunc("/classes/{class}/sections/{section}/roster", service.AuthMiddleware(service.HandleClassRoster)).Methods("GET")
 	
+	// Report job routes with authentication middleware
+	api.HandleFunc("/jobs/{id}", service.AuthMiddleware(service.HandleJobStatus)).Methods("GET")
+	api.HandleFunc("/jobs/{id}/result", service.AuthMiddleware(service.HandleJobResult)).Methods("GET")
+
+	// A missing ID leaves an empty path segment, which the router cleans away
+	// by redirecting to these paths
+	api.HandleFunc("/students/report", missingID("Student ID is required")).Methods("GET")
+	api.HandleFunc("/staffs/report", missingID("Staff ID is required")).Methods("GET")
+	api.HandleFunc("/leave/users/report", missingID("User ID is required")).Methods("GET")
+
+	// Report verification for printed copies (no auth required)
+	api.HandleFunc("/verify/{id}", service.HandleVerifyDocument).Methods("GET")
+
 	// Health check endpoint (no auth required)
 	router.HandleFunc("/health", service.HandleHealth).Methods("GET")
 
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go-service/internal/export"
+	"go-service/internal/pdf"
+
+	"github.com/gorilla/mux"
+)
+
+// HandleStaffReport generates and returns an HR profile for a staff member as
+// a PDF, or in another export format chosen by ?format= or the Accept header
+func (s *Service) HandleStaffReport(w http.ResponseWriter, r *http.Request) {
+	staffID := mux.Vars(r)["id"]
+	if staffID == "" {
+		http.Error(w, `{"error":"Staff ID is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	format, ok := s.negotiateFormat(w, r, export.ReportFormats, "report")
+	if !ok {
+		return
+	}
+
+	mode, ok := s.selectMode(w, r, format)
+	if !ok {
+		return
+	}
+
+	template, ok := s.selectTemplate(w, r, pdf.TemplateKindStaff)
+	if !ok {
+		return
+	}
+
+	// Fetch the staff profile using the caller's credentials
+	staff, err := s.NodejsClient.GetStaff(r.Context(), staffID)
+	if err != nil {
+		fmt.Printf("Error fetching staff %s: %v\n", staffID, err)
+		writeBackendError(w, err, "Staff not found", "Failed to fetch staff data")
+		return
+	}
+
+	// The spreadsheet formats list the same fields as the PDF
+	values, err := template.Values(staff)
+	if err != nil {
+		fmt.Printf("Error generating report for staff %s: %v\n", staffID, err)
+		http.Error(w, `{"error":"Failed to generate report"}`, http.StatusInternalServerError)
+		return
+	}
+
+	report := &export.Report{
+		Name:   fmt.Sprintf("staff_%s_report", staffID),
+		Data:   pdf.Profile{Template: template, Record: staff},
+		Tables: []export.Table{profileTable("Staff", values)},
+		Mode:   mode,
+	}
+	if !s.writeReport(w, r, format, report) {
+		return
+	}
+
+	fmt.Printf("Successfully generated %s report for staff %s\n", strings.ToUpper(format), staffID)
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"go-service/internal/verify"
+
+	"github.com/gorilla/mux"
+)
+
+// personalReports are the kinds of report whose subject names a person
+var personalReports = map[string]bool{
+	"student_report": true,
+	"staff_report":   true,
+	"leave_report":   true,
+}
+
+// verificationResponse confirms an issued document: what it was issued for,
+// when, and whether a newer one has replaced it
+type verificationResponse struct {
+	verify.Document
+	// Subject replaces the document's subject, masked when it names a person
+	Subject    string `json:"subject"`
+	Superseded bool   `json:"superseded"`
+}
+
+// HandleVerifyDocument looks up the verification ID printed on a report. It
+// needs no authentication, so parents and other schools can check a printed
+// copy; the ID itself is the secret.
+func (s *Service) HandleVerifyDocument(w http.ResponseWriter, r *http.Request) {
+	if s.Verifier == nil {
+		http.Error(w, `{"error":"Report verification is unavailable"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	doc, err := s.Verifier.Get(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, `{"error":"No report was issued with this verification ID"}`, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	subject := doc.Subject
+	if personalReports[doc.Report] {
+		subject = maskName(subject)
+	}
+	if err := json.NewEncoder(w).Encode(verificationResponse{Document: doc, Subject: subject, Superseded: doc.Superseded()}); err != nil {
+		fmt.Printf("Error writing verification of document %s: %v\n", doc.ID, err)
+	}
+}
+
+// maskName keeps the initial of every word of a name, so a printed copy can be
+// matched without the endpoint giving names away: Alice Johnson is masked as
+// A*** J***. Anything but letters, such as an academic year, is kept.
+func maskName(name string) string {
+	var masked strings.Builder
+	inWord := false
+	for _, r := range name {
+		switch {
+		case !unicode.IsLetter(r):
+			inWord = false
+			masked.WriteRune(r)
+		case !inWord:
+			inWord = true
+			masked.WriteRune(r)
+			masked.WriteString("***")
+		}
+	}
+	return masked.String()
+}
@@ -0,0 +1,19 @@
+package client
+
+import (
+	"context"
+
+	"go-service/pkg/models"
+)
+
+// GetMyPermissions fetches the access controls granted to the role of the
+// user the credentials belong to. The Node.js API responds with ErrNotFound
+// when the role has none.
+func (c *NodejsClient) GetMyPermissions(ctx context.Context) (*models.Permissions, error) {
+	var response models.PermissionsResponse
+	if err := c.getJSON(ctx, "/api/v1/access-controls/me", &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Permissions, nil
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// TokenClaims holds the claims of a Node.js API access token
+type TokenClaims struct {
+	UserID int    `json:"id"`
+	Role   string `json:"role"`
+	RoleID int    `json:"roleId"`
+	// Expiry is the Unix time at which the token expires
+	Expiry int64 `json:"exp"`
+}
+
+// ExpiresAt returns the expiry time of the token, or the zero time if unknown
+func (c TokenClaims) ExpiresAt() time.Time {
+	if c.Expiry == 0 {
+		return time.Time{}
+	}
+	return time.Unix(c.Expiry, 0)
+}
+
+// Claims decodes the claims of the access token. The signature is not
+// verified: the Node.js API verifies the token on every call, so the claims
+// are only used to shape requests and responses for the caller, never to
+// grant access to data the API would not return.
+func (c Credentials) Claims() (TokenClaims, bool) {
+	return parseClaims(c.AccessToken)
+}
+
+// Claims returns the claims of the access token calls made with ctx are
+// authenticated with: the caller's, else the service account's, else the
+// default token's
+func (c *NodejsClient) Claims(ctx context.Context) (TokenClaims, bool) {
+	creds, err := c.credentials(ctx)
+	if err != nil {
+		return TokenClaims{}, false
+	}
+	return creds.Claims()
+}
+
+// parseClaims decodes the payload of a JWT
+func parseClaims(token string) (TokenClaims, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return TokenClaims{}, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return TokenClaims{}, false
+	}
+
+	var claims TokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return TokenClaims{}, false
+	}
+	return claims, true
+}
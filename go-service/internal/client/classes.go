@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+
+	"go-service/pkg/models"
+)
+
+// GetClasses fetches the classes defined in the Node.js API, sorted by name.
+// The Node.js API responds with ErrNotFound when there are none.
+func (c *NodejsClient) GetClasses(ctx context.Context) ([]models.Class, error) {
+	var list models.ClassList
+	if err := c.getJSON(ctx, "/api/v1/classes", &list); err != nil {
+		return nil, err
+	}
+
+	return list.Classes, nil
+}
+
+// GetSections fetches the sections defined in the Node.js API. The Node.js
+// API responds with ErrNotFound when there are none.
+func (c *NodejsClient) GetSections(ctx context.Context) ([]models.Section, error) {
+	var list models.SectionList
+	if err := c.getJSON(ctx, "/api/v1/sections", &list); err != nil {
+		return nil, err
+	}
+
+	return list.Sections, nil
+}
+
+// GetClassTeachers fetches the class teacher assignments. The Node.js API
+// responds with ErrNotFound when there are none.
+func (c *NodejsClient) GetClassTeachers(ctx context.Context) ([]models.ClassTeacher, error) {
+	var list models.ClassTeacherList
+	if err := c.getJSON(ctx, "/api/v1/class-teachers", &list); err != nil {
+		return nil, err
+	}
+
+	return list.ClassTeachers, nil
+}
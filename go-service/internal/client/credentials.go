@@ -0,0 +1,44 @@
+package client
+
+import "context"
+
+// Credentials holds the authentication tokens forwarded to the Node.js API
+// on behalf of a single caller
+type Credentials struct {
+	AccessToken string
+	CSRFToken   string
+	// RefreshToken, if set, is used to renew an expired access token
+	RefreshToken string
+}
+
+// credentialsKey is the context key under which the request session is stored
+type credentialsKey struct{}
+
+// WithCredentials returns a copy of ctx that carries the caller's credentials.
+// Every NodejsClient call made with the returned context authenticates as that
+// caller, so a single shared client can safely serve concurrent users.
+func WithCredentials(ctx context.Context, creds Credentials) context.Context {
+	return WithSession(ctx, NewSession(creds))
+}
+
+// WithSession returns a copy of ctx that carries session. Calls made with the
+// returned context share the session, so a token refreshed by one of them is
+// used by all the others.
+func WithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, session)
+}
+
+// SessionFromContext returns the session stored in ctx, if any
+func SessionFromContext(ctx context.Context) (*Session, bool) {
+	session, ok := ctx.Value(credentialsKey{}).(*Session)
+	return session, ok
+}
+
+// CredentialsFromContext returns the current credentials stored in ctx, if any
+func CredentialsFromContext(ctx context.Context) (Credentials, bool) {
+	session, ok := SessionFromContext(ctx)
+	if !ok {
+		return Credentials{}, false
+	}
+	return session.Credentials(), true
+}
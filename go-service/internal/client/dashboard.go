@@ -0,0 +1,18 @@
+package client
+
+import (
+	"context"
+
+	"go-service/pkg/models"
+)
+
+// GetDashboard fetches the dashboard data of the user the credentials belong
+// to. HealthCheck calls the same endpoint but only checks that it answers.
+func (c *NodejsClient) GetDashboard(ctx context.Context) (*models.DashboardSummary, error) {
+	var summary models.DashboardSummary
+	if err := c.getJSON(ctx, "/api/v1/dashboard", &summary); err != nil {
+		return nil, err
+	}
+
+	return &summary, nil
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors matched by *APIError, for use with errors.Is
+var (
+	// ErrNotFound is matched by responses with status 404
+	ErrNotFound = errors.New("resource not found")
+	// ErrUnauthorized is matched by responses with status 401
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden is matched by responses with status 403
+	ErrForbidden = errors.New("forbidden")
+)
+
+// maxErrorBodySize bounds how much of an error response body is read
+const maxErrorBodySize = 4096
+
+// APIError is returned when the Node.js API answers with a non-200 status
+type APIError struct {
+	StatusCode int
+	// Message is the backend's error message, or the raw body if it was not JSON
+	Message string
+	// URL is the request URL without its query string
+	URL string
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API request to %s failed with status %d: %s", e.URL, e.StatusCode, e.Message)
+}
+
+// Is reports whether the error matches one of the sentinel errors
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	default:
+		return false
+	}
+}
+
+// newAPIError builds an APIError from a failed response. The Node.js API
+// reports errors as {"error": "..."}.
+func newAPIError(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+
+	message := strings.TrimSpace(string(body))
+	var payload struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && payload.Error != "" {
+		message = payload.Error
+	}
+	if message == "" {
+		message = http.StatusText(resp.StatusCode)
+	}
+
+	// Drop the query string, which may carry filters such as student names
+	requestURL := ""
+	if resp.Request != nil && resp.Request.URL != nil {
+		u := *resp.Request.URL
+		u.RawQuery = ""
+		requestURL = u.String()
+	}
+
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    message,
+		URL:        requestURL,
+	}
+}
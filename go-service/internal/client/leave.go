@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+
+	"go-service/pkg/models"
+)
+
+// GetLeaveHistory fetches the leave requests of the user the credentials
+// belong to. The Node.js API responds with ErrNotFound when there are none.
+func (c *NodejsClient) GetLeaveHistory(ctx context.Context) ([]models.LeaveRequest, error) {
+	var history models.LeaveHistory
+	if err := c.getJSON(ctx, "/api/v1/leave/request", &history); err != nil {
+		return nil, err
+	}
+
+	return history.LeaveHistory, nil
+}
+
+// GetLeavePolicies fetches the leave policies assigned to the user the
+// credentials belong to. The Node.js API responds with ErrNotFound when there
+// are none.
+func (c *NodejsClient) GetLeavePolicies(ctx context.Context) ([]models.LeavePolicy, error) {
+	var list models.LeavePolicyList
+	if err := c.getJSON(ctx, "/api/v1/leave/policies/me", &list); err != nil {
+		return nil, err
+	}
+
+	return list.LeavePolicies, nil
+}
+
+// GetPendingLeaves fetches the leave requests waiting for approval. The
+// Node.js API responds with ErrNotFound when there are none.
+func (c *NodejsClient) GetPendingLeaves(ctx context.Context) ([]models.LeaveRequest, error) {
+	var list models.PendingLeaveList
+	if err := c.getJSON(ctx, "/api/v1/leave/pending", &list); err != nil {
+		return nil, err
+	}
+
+	return list.PendingLeaves, nil
+}
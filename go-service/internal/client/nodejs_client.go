@@ -1,10 +1,13 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 
 	"go-service/pkg/models"
@@ -14,9 +17,14 @@ import (
 type NodejsClient struct {
 	BaseURL    string
 	HTTPClient *http.Client
-	// Authentication tokens will be added here
-	AccessToken string
-	CSRFToken   string
+	// Default authentication tokens, used only when the request context
+	// carries no caller credentials (see WithCredentials)
+	AccessToken  string
+	CSRFToken    string
+	RefreshToken string
+
+	// serviceAccount, if set, takes the place of the default tokens
+	serviceAccount *ServiceAccount
 }
 
 // NewNodejsClient creates a new client for the Node.js backend API
@@ -29,113 +37,188 @@ func NewNodejsClient(baseURL string) *NodejsClient {
 	}
 }
 
-// SetAuthTokens sets the authentication tokens for API requests
+// SetAuthTokens sets the default authentication tokens for API requests.
+// It is meant to be called once at startup; per-caller tokens must travel
+// with the request context instead.
 func (c *NodejsClient) SetAuthTokens(accessToken, csrfToken string) {
 	c.AccessToken = accessToken
 	c.CSRFToken = csrfToken
 }
 
-// GetStudent fetches a single student by ID from the Node.js API
-func (c *NodejsClient) GetStudent(studentID string) (*models.Student, error) {
-	url := fmt.Sprintf("%s/api/v1/students/%s", c.BaseURL, studentID)
-	
-	req, err := http.NewRequest("GET", url, nil)
+// SetRefreshToken sets the default refresh token, which the Node.js API
+// requires next to the default access token
+func (c *NodejsClient) SetRefreshToken(refreshToken string) {
+	c.RefreshToken = refreshToken
+}
+
+// credentials returns the credentials to authenticate a request made with ctx:
+// the caller's, else the service account's, else the default tokens
+func (c *NodejsClient) credentials(ctx context.Context) (Credentials, error) {
+	if creds, ok := CredentialsFromContext(ctx); ok {
+		return creds, nil
+	}
+	if c.serviceAccount != nil {
+		return c.serviceAccount.Credentials(ctx)
+	}
+	return Credentials{AccessToken: c.AccessToken, CSRFToken: c.CSRFToken, RefreshToken: c.RefreshToken}, nil
+}
+
+// renewCredentials replaces an access token rejected by the Node.js API. A
+// caller's session is refreshed with its refresh token; the service account
+// refreshes its session or logs in again.
+func (c *NodejsClient) renewCredentials(ctx context.Context, rejected string) error {
+	if session, ok := SessionFromContext(ctx); ok {
+		return c.refreshSession(ctx, session, rejected)
+	}
+	if c.serviceAccount != nil {
+		return c.serviceAccount.replaceRejected(ctx, rejected)
+	}
+	return errNoRefreshToken
+}
+
+// newRequest creates a request against the Node.js API authenticated with creds
+func (c *NodejsClient) newRequest(ctx context.Context, method, path string, creds Credentials) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	// Add authentication headers if tokens are available
-	if c.AccessToken != "" {
-		req.Header.Set("Cookie", fmt.Sprintf("accessToken=%s", c.AccessToken))
+	// Add authentication headers if tokens are available. The Node.js API
+	// rejects requests that carry the access token without the refresh token.
+	if creds.AccessToken != "" {
+		req.AddCookie(&http.Cookie{Name: AccessTokenCookie, Value: creds.AccessToken})
+	}
+	if creds.RefreshToken != "" {
+		req.AddCookie(&http.Cookie{Name: RefreshTokenCookie, Value: creds.RefreshToken})
 	}
-	if c.CSRFToken != "" {
-		req.Header.Set("X-CSRF-Token", c.CSRFToken)
+	if creds.CSRFToken != "" {
+		req.Header.Set("X-CSRF-Token", creds.CSRFToken)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
+	return req, nil
+}
+
+// getJSON performs a GET request against the Node.js API and decodes the JSON
+// response body into v. Non-200 responses are returned as *APIError. When the
+// access token has expired, the credentials are renewed once and the request
+// retried.
+func (c *NodejsClient) getJSON(ctx context.Context, path string, v interface{}) error {
+	creds, err := c.credentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = c.doJSON(ctx, path, creds, v)
+	if !errors.Is(err, ErrUnauthorized) {
+		return err
+	}
+
+	if renewErr := c.renewCredentials(ctx, creds.AccessToken); renewErr != nil {
+		// Keep the original 401 so callers still see the request as unauthorized
+		return fmt.Errorf("%w (token refresh failed: %v)", err, renewErr)
+	}
+	if creds, err = c.credentials(ctx); err != nil {
+		return err
+	}
+
+	return c.doJSON(ctx, path, creds, v)
+}
+
+// doJSON performs a single GET request authenticated with creds and decodes
+// the JSON response body into v
+func (c *NodejsClient) doJSON(ctx context.Context, path string, creds Credentials, v interface{}) error {
+	req, err := c.newRequest(ctx, "GET", path, creds)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return newAPIError(resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	return nil
+}
+
+// GetStudent fetches a single student by ID from the Node.js API
+func (c *NodejsClient) GetStudent(ctx context.Context, studentID string) (*models.Student, error) {
 	var student models.Student
-	if err := json.Unmarshal(body, &student); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal student data: %w", err)
+	if err := c.getJSON(ctx, "/api/v1/students/"+url.PathEscape(studentID), &student); err != nil {
+		return nil, err
 	}
 
 	return &student, nil
 }
 
-// GetStudents fetches all students from the Node.js API (optional, for future use)
-func (c *NodejsClient) GetStudents() (models.StudentList, error) {
-	url := fmt.Sprintf("%s/api/v1/students", c.BaseURL)
-	
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+// StudentFilter holds the query filters supported by the Node.js students list endpoint
+type StudentFilter struct {
+	Name      string
+	ClassName string
+	Section   string
+	Roll      string
+}
 
-	// Add authentication headers if tokens are available
-	if c.AccessToken != "" {
-		req.Header.Set("Cookie", fmt.Sprintf("accessToken=%s", c.AccessToken))
+// encode returns the filter as a URL query string, omitting empty filters
+func (f StudentFilter) encode() string {
+	query := url.Values{}
+	if f.Name != "" {
+		query.Set("name", f.Name)
 	}
-	if c.CSRFToken != "" {
-		req.Header.Set("X-CSRF-Token", c.CSRFToken)
+	if f.ClassName != "" {
+		query.Set("className", f.ClassName)
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+	if f.Section != "" {
+		query.Set("section", f.Section)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	if f.Roll != "" {
+		query.Set("roll", f.Roll)
 	}
+	return query.Encode()
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+// GetStudents fetches the students matching filter from the Node.js API.
+// The list endpoint only returns summary fields (id, name, email, system access);
+// use GetStudent for the full profile.
+func (c *NodejsClient) GetStudents(ctx context.Context, filter StudentFilter) (models.StudentList, error) {
+	path := "/api/v1/students"
+	if query := filter.encode(); query != "" {
+		path += "?" + query
 	}
 
 	var students models.StudentList
-	if err := json.Unmarshal(body, &students); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal students data: %w", err)
+	if err := c.getJSON(ctx, path, &students); err != nil {
+		return nil, err
 	}
 
 	return students, nil
 }
 
 // HealthCheck verifies the Node.js API is accessible
-func (c *NodejsClient) HealthCheck() error {
-	url := fmt.Sprintf("%s/api/v1/dashboard", c.BaseURL)
-	
-	req, err := http.NewRequest("GET", url, nil)
+func (c *NodejsClient) HealthCheck(ctx context.Context) error {
+	creds, err := c.credentials(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create health check request: %w", err)
+		return err
 	}
 
-	// Add authentication headers if tokens are available
-	if c.AccessToken != "" {
-		req.Header.Set("Cookie", fmt.Sprintf("accessToken=%s", c.AccessToken))
-	}
-	if c.CSRFToken != "" {
-		req.Header.Set("X-CSRF-Token", c.CSRFToken)
+	req, err := c.newRequest(ctx, "GET", "/api/v1/dashboard", creds)
+	if err != nil {
+		return fmt.Errorf("failed to create health check request: %w", err)
 	}
 
 	resp, err := c.HTTPClient.Do(req)
@@ -145,8 +228,8 @@ func (c *NodejsClient) HealthCheck() error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Node.js API health check failed with status: %d", resp.StatusCode)
+		return newAPIError(resp)
 	}
 
 	return nil
-} 
\ No newline at end of file
+}
@@ -1,7 +1,17 @@
 package client
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // TestNewNodejsClient tests the creation of a new Node.js client
@@ -40,5 +50,383 @@ func TestSetAuthTokens(t *testing.T) {
 	}
 }
 
+// TestRequestCredentialsFromContext tests that credentials carried by the
+// context take precedence over the client's default tokens
+func TestRequestCredentialsFromContext(t *testing.T) {
+	var gotCookie, gotCSRF string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCookie = r.Header.Get("Cookie")
+		gotCSRF = r.Header.Get("X-CSRF-Token")
+		w.Write([]byte(`{"id":1,"name":"Test Student"}`))
+	}))
+	defer server.Close()
+
+	client := NewNodejsClient(server.URL)
+	client.SetAuthTokens("default-access-token", "default-csrf-token")
+	client.SetRefreshToken("default-refresh-token")
+
+	// Without credentials in the context the default tokens are used
+	if _, err := client.GetStudent(context.Background(), "1"); err != nil {
+		t.Fatalf("Expected request to succeed, got error: %v", err)
+	}
+	if gotCookie != "accessToken=default-access-token; refreshToken=default-refresh-token" || gotCSRF != "default-csrf-token" {
+		t.Errorf("Expected default tokens, got cookie %q and CSRF %q", gotCookie, gotCSRF)
+	}
+
+	// Credentials in the context override the defaults
+	ctx := WithCredentials(context.Background(), Credentials{
+		AccessToken:  "caller-access-token",
+		CSRFToken:    "caller-csrf-token",
+		RefreshToken: "caller-refresh-token",
+	})
+	if _, err := client.GetStudent(ctx, "1"); err != nil {
+		t.Fatalf("Expected request to succeed, got error: %v", err)
+	}
+	if gotCookie != "accessToken=caller-access-token; refreshToken=caller-refresh-token" || gotCSRF != "caller-csrf-token" {
+		t.Errorf("Expected caller tokens, got cookie %q and CSRF %q", gotCookie, gotCSRF)
+	}
+
+	// Per-request credentials never modify the shared client
+	if client.AccessToken != "default-access-token" || client.CSRFToken != "default-csrf-token" {
+		t.Error("Expected default tokens to be left untouched")
+	}
+}
+
+// TestAPIErrors tests that failed responses are returned as *APIError values
+// matching the sentinel errors
+func TestAPIErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		status   int
+		body     string
+		sentinel error
+		message  string
+	}{
+		{"not_found", http.StatusNotFound, `{"error":"Student not found"}`, ErrNotFound, "Student not found"},
+		{"unauthorized", http.StatusUnauthorized, `{"error":"Unauthorized. Please provide a valid token."}`, ErrUnauthorized, "Unauthorized. Please provide a valid token."},
+		{"forbidden", http.StatusForbidden, `{"error":"Invalid csrf token"}`, ErrForbidden, "Invalid csrf token"},
+		{"server_error", http.StatusInternalServerError, "upstream failed", nil, "upstream failed"},
+		{"empty_body", http.StatusBadGateway, "", nil, "Bad Gateway"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			client := NewNodejsClient(server.URL)
+			_, err := client.GetStudents(context.Background(), StudentFilter{Name: "Bart"})
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Expected *APIError, got %v", err)
+			}
+			if apiErr.StatusCode != tc.status {
+				t.Errorf("Expected status %d, got %d", tc.status, apiErr.StatusCode)
+			}
+			if apiErr.Message != tc.message {
+				t.Errorf("Expected message %q, got %q", tc.message, apiErr.Message)
+			}
+			if apiErr.URL != server.URL+"/api/v1/students" {
+				t.Errorf("Expected URL without query string, got %q", apiErr.URL)
+			}
+
+			for _, sentinel := range []error{ErrNotFound, ErrUnauthorized, ErrForbidden} {
+				if got, want := errors.Is(err, sentinel), sentinel == tc.sentinel; got != want {
+					t.Errorf("errors.Is(err, %v) = %v, want %v", sentinel, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestSessionRefresh tests that concurrent requests with an expired access
+// token share a single refresh and are retried with the rotated tokens
+func TestSessionRefresh(t *testing.T) {
+	var refreshes atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/auth/refresh" {
+			refreshes.Add(1)
+			if cookie, err := r.Cookie(RefreshTokenCookie); err != nil || cookie.Value != "refresh-token" {
+				http.Error(w, `{"error":"Invalid refresh token"}`, http.StatusUnauthorized)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: AccessTokenCookie, Value: "new-access-token", Domain: "backend.internal"})
+			http.SetCookie(w, &http.Cookie{Name: CSRFTokenCookie, Value: "new-csrf-token"})
+			return
+		}
+
+		cookie, err := r.Cookie(AccessTokenCookie)
+		if err != nil || cookie.Value != "new-access-token" || r.Header.Get("X-CSRF-Token") != "new-csrf-token" {
+			http.Error(w, `{"error":"Token expired"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"id":1,"name":"Test Student"}`))
+	}))
+	defer server.Close()
+
+	client := NewNodejsClient(server.URL)
+	session := NewSession(Credentials{AccessToken: "old-access-token", CSRFToken: "old-csrf-token", RefreshToken: "refresh-token"})
+	ctx := WithSession(context.Background(), session)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetStudent(ctx, "1"); err != nil {
+				t.Errorf("Expected request to succeed after refresh, got error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := refreshes.Load(); got != 1 {
+		t.Errorf("Expected a single refresh, got %d", got)
+	}
+	if creds := session.Credentials(); creds.AccessToken != "new-access-token" || creds.CSRFToken != "new-csrf-token" || creds.RefreshToken != "refresh-token" {
+		t.Errorf("Unexpected session credentials after refresh: %+v", creds)
+	}
+
+	cookies := session.RotatedCookies()
+	if len(cookies) != 2 {
+		t.Fatalf("Expected 2 rotated cookies, got %d", len(cookies))
+	}
+	for _, cookie := range cookies {
+		if cookie.Domain != "" {
+			t.Errorf("Expected the backend domain to be dropped from cookie %s", cookie.Name)
+		}
+	}
+
+	// A rejected refresh leaves the request unauthorized and is not retried
+	refreshes.Store(0)
+	ctx = WithCredentials(context.Background(), Credentials{AccessToken: "old-access-token", RefreshToken: "revoked"})
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetStudent(ctx, "1"); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("Expected ErrUnauthorized after a failed refresh, got %v", err)
+		}
+	}
+	if got := refreshes.Load(); got != 1 {
+		t.Errorf("Expected a failed refresh to be attempted once, got %d", got)
+	}
+}
+
+// testJWT returns an unsigned JWT whose exp claim is expiresAt
+func testJWT(id int, expiresAt time.Time) string {
+	encode := func(v interface{}) string {
+		data, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	return encode(map[string]string{"alg": "HS256"}) + "." + encode(map[string]int64{"id": int64(id), "exp": expiresAt.Unix()}) + ".signature"
+}
+
+// TestServiceAccount tests that the service account logs in on first use,
+// refreshes its session before it expires and logs in again when the refresh
+// is rejected
+func TestServiceAccount(t *testing.T) {
+	var (
+		mu            sync.Mutex
+		issued        int
+		valid         = make(map[string]bool)
+		rejectRefresh bool
+		logins        int
+		refreshes     int
+	)
+	issue := func(w http.ResponseWriter) {
+		issued++
+		token := testJWT(issued, time.Now().Add(15*time.Minute))
+		valid[token] = true
+		http.SetCookie(w, &http.Cookie{Name: AccessTokenCookie, Value: token})
+		http.SetCookie(w, &http.Cookie{Name: CSRFTokenCookie, Value: fmt.Sprintf("csrf-%d", issued)})
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.URL.Path {
+		case "/api/v1/auth/login":
+			logins++
+			var body struct{ Username, Password string }
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.Username != "reports" || body.Password != "secret" {
+				http.Error(w, `{"error":"Invalid credential"}`, http.StatusUnauthorized)
+				return
+			}
+			issue(w)
+			http.SetCookie(w, &http.Cookie{Name: RefreshTokenCookie, Value: "refresh-token"})
+		case "/api/v1/auth/refresh":
+			refreshes++
+			if rejectRefresh {
+				http.Error(w, `{"error":"Invalid refresh token"}`, http.StatusUnauthorized)
+				return
+			}
+			issue(w)
+		default:
+			// Like the Node.js API, require the refresh token cookie next to
+			// a valid access token
+			if cookie, err := r.Cookie(AccessTokenCookie); err != nil || !valid[cookie.Value] {
+				http.Error(w, `{"error":"Unauthorized"}`, http.StatusUnauthorized)
+				return
+			}
+			if cookie, err := r.Cookie(RefreshTokenCookie); err != nil || cookie.Value != "refresh-token" {
+				http.Error(w, `{"error":"Unauthorized"}`, http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"id":1,"name":"Test Student"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewNodejsClient(server.URL)
+	client.SetServiceAccount("reports", "secret")
+
+	steps := []struct {
+		name          string
+		setup         func()
+		wantLogins    int
+		wantRefreshes int
+	}{
+		{"logs_in_on_first_use", func() {}, 1, 0},
+		{"reuses_session", func() {}, 1, 0},
+		{"refreshes_rejected_token", func() { clear(valid) }, 1, 1},
+		{"logs_in_again_when_refresh_fails", func() { clear(valid); rejectRefresh = true }, 2, 2},
+		{"refreshes_before_expiry", func() {
+			rejectRefresh = false
+			client.serviceAccount.expiresAt = time.Now().Add(30 * time.Second)
+		}, 2, 3},
+	}
+
+	for _, step := range steps {
+		mu.Lock()
+		step.setup()
+		mu.Unlock()
+
+		if _, err := client.GetStudent(context.Background(), "1"); err != nil {
+			t.Fatalf("%s: expected request to succeed, got error: %v", step.name, err)
+		}
+
+		mu.Lock()
+		if logins != step.wantLogins || refreshes != step.wantRefreshes {
+			t.Errorf("%s: expected %d logins and %d refreshes, got %d and %d", step.name, step.wantLogins, step.wantRefreshes, logins, refreshes)
+		}
+		mu.Unlock()
+	}
+
+	// The expiry is read from the access token's exp claim
+	if remaining := time.Until(client.serviceAccount.expiresAt); remaining < 14*time.Minute || remaining > 15*time.Minute {
+		t.Errorf("Expected the session to expire in about 15 minutes, got %v", remaining)
+	}
+
+	// Caller credentials always take precedence over the service account
+	ctx := WithCredentials(context.Background(), Credentials{AccessToken: "caller-token"})
+	if _, err := client.GetStudent(ctx, "1"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected the caller's token to be used, got %v", err)
+	}
+
+	// A wrong password surfaces as an authentication failure
+	client = NewNodejsClient(server.URL)
+	client.SetServiceAccount("reports", "wrong")
+	if _, err := client.GetStudent(context.Background(), "1"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized for a failed login, got %v", err)
+	}
+}
+
+// TestGetStaffs tests decoding the staff list and encoding its filters
+func TestGetStaffs(t *testing.T) {
+	var gotQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		w.Write([]byte(`{"staffs":[{"id":7,"name":"Edna Krabappel","email":"edna@school.edu","role":"Teacher","systemAccess":true,"lastLogin":null}]}`))
+	}))
+	defer server.Close()
+
+	client := NewNodejsClient(server.URL)
+	staffs, err := client.GetStaffs(context.Background(), StaffFilter{RoleID: "2", Name: "Edna Krabappel"})
+	if err != nil {
+		t.Fatalf("Expected request to succeed, got error: %v", err)
+	}
+
+	if gotQuery != "name=Edna+Krabappel&roleId=2" {
+		t.Errorf("Unexpected query string %q", gotQuery)
+	}
+	if len(staffs) != 1 || staffs[0].ID != 7 || staffs[0].Role != "Teacher" || !staffs[0].SystemAccess {
+		t.Errorf("Unexpected staff list: %+v", staffs)
+	}
+}
+
+// TestGetLeaveHistory tests decoding the leave history as sent by the
+// Node.js API, where pending requests have no approval date
+func TestGetLeaveHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/leave/request" {
+			http.Error(w, `{"error":"Not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"leaveHistory":[{"id":3,"policy":"Sick Leave","policyId":1,"from":"2024-05-06T00:00:00.000Z","to":"2024-05-07T00:00:00.000Z","note":"Flu","statusId":1,"status":"On Review","submitted":"2024-05-05T08:00:00.000Z","updated":null,"approved":null,"approver":null,"user":"Edna Krabappel","days":"1"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewNodejsClient(server.URL)
+	history, err := client.GetLeaveHistory(context.Background())
+	if err != nil {
+		t.Fatalf("Expected request to succeed, got error: %v", err)
+	}
+
+	if len(history) != 1 || history[0].PolicyID != 1 || history[0].Status != "On Review" || !history[0].Approved.IsZero() {
+		t.Fatalf("Unexpected leave history: %+v", history)
+	}
+	if days := history[0].DayCount(); days != 2 {
+		t.Errorf("Expected 2 days, got %d", days)
+	}
+
+	if _, err := client.GetLeavePolicies(context.Background()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for missing policies, got %v", err)
+	}
+}
+
+// TestGetMyPermissions tests decoding the caller's permissions as sent by the
+// Node.js API and matching API permissions by method and path
+func TestGetMyPermissions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/access-controls/me" {
+			http.Error(w, `{"error":"Not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"permissions":{"menus":[{"id":9,"name":"Students","path":"students_parent","subMenus":[]}],` +
+			`"apis":[{"id":83,"name":"Update student detail","path":"/api/v1/students/:id","icon":null,"parent_path":"students_parent","hierarchy_id":null,"type":"api","method":"PUT"}],` +
+			`"uis":[{"id":9,"name":"Students","path":"students_parent","type":"menu","method":null}]}}`))
+	}))
+	defer server.Close()
+
+	permissions, err := NewNodejsClient(server.URL).GetMyPermissions(context.Background())
+	if err != nil {
+		t.Fatalf("Expected request to succeed, got error: %v", err)
+	}
+
+	if !permissions.AllowsAPI("put", "/api/v1/students/:id") {
+		t.Errorf("Expected the student update permission, got %+v", permissions)
+	}
+	if permissions.AllowsAPI("GET", "/api/v1/students/:id") || permissions.AllowsAPI("PUT", "/api/v1/staffs/:id") {
+		t.Errorf("Expected only the granted API to be allowed, got %+v", permissions)
+	}
+}
+
+// TestCredentialsClaims tests decoding the claims of an access token
+func TestCredentialsClaims(t *testing.T) {
+	expiry := time.Unix(1752009434, 0)
+	claims, ok := Credentials{AccessToken: testJWT(42, expiry)}.Claims()
+	if !ok || claims.UserID != 42 || !claims.ExpiresAt().Equal(expiry) {
+		t.Errorf("Unexpected claims %+v", claims)
+	}
+
+	if _, ok := (Credentials{AccessToken: "not-a-jwt"}).Claims(); ok {
+		t.Error("Expected an opaque token to have no claims")
+	}
+}
+
 // Note: Integration tests would require the Node.js backend to be running
 // For now, we'll test the basic functionality without actual HTTP calls 
\ No newline at end of file
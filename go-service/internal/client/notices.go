@@ -0,0 +1,39 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"go-service/pkg/models"
+)
+
+// GetNotices fetches the notices visible to the user the credentials belong
+// to, newest first. The Node.js API responds with ErrNotFound when there are none.
+func (c *NodejsClient) GetNotices(ctx context.Context) ([]models.Notice, error) {
+	var list models.NoticeList
+	if err := c.getJSON(ctx, "/api/v1/notices", &list); err != nil {
+		return nil, err
+	}
+
+	return list.Notices, nil
+}
+
+// GetNotice fetches a single notice, including its recipients, by ID
+func (c *NodejsClient) GetNotice(ctx context.Context, noticeID int) (*models.NoticeDetail, error) {
+	var notice models.NoticeDetail
+	if err := c.getJSON(ctx, fmt.Sprintf("/api/v1/notices/%d", noticeID), &notice); err != nil {
+		return nil, err
+	}
+
+	return &notice, nil
+}
+
+// GetRoles fetches the roles defined in the Node.js API
+func (c *NodejsClient) GetRoles(ctx context.Context) ([]models.Role, error) {
+	var list models.RoleList
+	if err := c.getJSON(ctx, "/api/v1/roles", &list); err != nil {
+		return nil, err
+	}
+
+	return list.Roles, nil
+}
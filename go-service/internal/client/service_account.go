@@ -0,0 +1,116 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// serviceAccountRefreshMargin is how long before its expiry the service
+// account's access token is refreshed
+const serviceAccountRefreshMargin = time.Minute
+
+// ServiceAccount keeps a Node.js API session for calls made on behalf of the
+// service itself, such as scheduled and batch jobs, rather than a caller.
+// The session is opened on first use, refreshed shortly before the access
+// token expires and opened again if the refresh fails.
+type ServiceAccount struct {
+	client   *NodejsClient
+	username string
+	password string
+
+	mu        sync.Mutex
+	creds     Credentials
+	expiresAt time.Time
+}
+
+// SetServiceAccount makes the client authenticate as the given account for
+// calls whose context carries no caller credentials
+func (c *NodejsClient) SetServiceAccount(username, password string) {
+	c.serviceAccount = &ServiceAccount{client: c, username: username, password: password}
+}
+
+// Credentials returns the service account's credentials, logging in or
+// refreshing the session when it is missing or about to expire
+func (a *ServiceAccount) Credentials(ctx context.Context) (Credentials, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.creds.AccessToken != "" && (a.expiresAt.IsZero() || time.Until(a.expiresAt) > serviceAccountRefreshMargin) {
+		return a.creds, nil
+	}
+	return a.renew(ctx)
+}
+
+// replaceRejected renews the session after the Node.js API rejected the
+// rejected access token, unless another call already replaced it
+func (a *ServiceAccount) replaceRejected(ctx context.Context, rejected string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.creds.AccessToken != "" && a.creds.AccessToken != rejected {
+		return nil
+	}
+	_, err := a.renew(ctx)
+	return err
+}
+
+// renew refreshes the session with its refresh token, falling back to a new
+// login when there is none or the refresh is rejected. The caller must hold a.mu.
+func (a *ServiceAccount) renew(ctx context.Context) (Credentials, error) {
+	if a.creds.RefreshToken != "" {
+		if cookies, err := a.client.refresh(ctx, a.creds.RefreshToken); err == nil {
+			a.apply(cookies)
+			return a.creds, nil
+		}
+	}
+
+	cookies, err := a.client.login(ctx, a.username, a.password)
+	if err != nil {
+		a.creds = Credentials{}
+		a.expiresAt = time.Time{}
+		return Credentials{}, fmt.Errorf("service account login failed: %w", err)
+	}
+
+	a.creds = Credentials{}
+	a.apply(cookies)
+	return a.creds, nil
+}
+
+// apply stores the tokens set by a login or refresh response
+func (a *ServiceAccount) apply(cookies []*http.Cookie) {
+	applyCookies(&a.creds, cookies)
+	claims, _ := a.creds.Claims()
+	a.expiresAt = claims.ExpiresAt()
+}
+
+// login opens a session with a username and password and returns the
+// authentication cookies set by the Node.js API
+func (c *NodejsClient) login(ctx context.Context, username, password string) ([]*http.Cookie, error) {
+	body, err := json.Marshal(map[string]string{"username": username, "password": password})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode login request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/api/v1/auth/login", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create login request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to log in: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	return authCookies(resp)
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Names of the authentication cookies issued by the Node.js API
+const (
+	AccessTokenCookie  = "accessToken"
+	CSRFTokenCookie    = "csrfToken"
+	RefreshTokenCookie = "refreshToken"
+)
+
+// errNoRefreshToken is returned when an expired session cannot be refreshed
+var errNoRefreshToken = errors.New("no refresh token")
+
+// Session holds the credentials of a single caller for the duration of a
+// request. When the access token expires the client refreshes it once using
+// the refresh token, and the session keeps the rotated cookies so they can be
+// returned to the caller.
+type Session struct {
+	mu         sync.Mutex
+	creds      Credentials
+	refreshed  bool
+	refreshErr error
+	cookies    []*http.Cookie
+}
+
+// NewSession creates a session for creds
+func NewSession(creds Credentials) *Session {
+	return &Session{creds: creds}
+}
+
+// Credentials returns the session's current credentials
+func (s *Session) Credentials() Credentials {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.creds
+}
+
+// RotatedCookies returns the authentication cookies set by the Node.js API
+// when the session was refreshed, or nil if it was not
+func (s *Session) RotatedCookies() []*http.Cookie {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cookies
+}
+
+// refreshSession renews the access token of session after a request made with
+// the expired token was rejected. Concurrent callers share a single refresh,
+// and a session is refreshed at most once.
+func (c *NodejsClient) refreshSession(ctx context.Context, session *Session, expired string) error {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	// Another request already replaced the expired token
+	if session.creds.AccessToken != expired {
+		return nil
+	}
+	if session.refreshed {
+		if session.refreshErr != nil {
+			return session.refreshErr
+		}
+		return errors.New("access token rejected after refresh")
+	}
+	session.refreshed = true
+
+	if session.creds.RefreshToken == "" {
+		session.refreshErr = errNoRefreshToken
+		return session.refreshErr
+	}
+
+	cookies, err := c.refresh(ctx, session.creds.RefreshToken)
+	if err != nil {
+		session.refreshErr = err
+		return err
+	}
+
+	applyCookies(&session.creds, cookies)
+	session.cookies = cookies
+
+	return nil
+}
+
+// refresh exchanges a refresh token for a new access token and returns the
+// authentication cookies set by the Node.js API
+func (c *NodejsClient) refresh(ctx context.Context, refreshToken string) ([]*http.Cookie, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.BaseURL+"/api/v1/auth/refresh", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refresh request: %w", err)
+	}
+	req.AddCookie(&http.Cookie{Name: RefreshTokenCookie, Value: refreshToken})
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	return authCookies(resp)
+}
+
+// authCookies returns the authentication cookies set by a login or refresh
+// response
+func authCookies(resp *http.Response) ([]*http.Cookie, error) {
+	var cookies []*http.Cookie
+	hasAccessToken := false
+	for _, cookie := range resp.Cookies() {
+		switch cookie.Name {
+		case AccessTokenCookie, CSRFTokenCookie, RefreshTokenCookie:
+			// The cookies may be returned to our caller, who reaches us on
+			// our own domain rather than the backend's
+			cookie.Domain = ""
+			cookies = append(cookies, cookie)
+			hasAccessToken = hasAccessToken || (cookie.Name == AccessTokenCookie && cookie.Value != "")
+		}
+	}
+	if !hasAccessToken {
+		return nil, errors.New("response did not set an access token")
+	}
+
+	return cookies, nil
+}
+
+// applyCookies copies the tokens carried by authentication cookies into creds
+func applyCookies(creds *Credentials, cookies []*http.Cookie) {
+	for _, cookie := range cookies {
+		switch cookie.Name {
+		case AccessTokenCookie:
+			creds.AccessToken = cookie.Value
+		case CSRFTokenCookie:
+			creds.CSRFToken = cookie.Value
+		case RefreshTokenCookie:
+			creds.RefreshToken = cookie.Value
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"net/url"
+
+	"go-service/pkg/models"
+)
+
+// GetStaff fetches a single staff profile by ID from the Node.js API
+func (c *NodejsClient) GetStaff(ctx context.Context, staffID string) (*models.Staff, error) {
+	var staff models.Staff
+	if err := c.getJSON(ctx, "/api/v1/staffs/"+url.PathEscape(staffID), &staff); err != nil {
+		return nil, err
+	}
+
+	return &staff, nil
+}
+
+// StaffFilter holds the query filters supported by the Node.js staff list endpoint
+type StaffFilter struct {
+	UserID string
+	RoleID string
+	// Name must match the staff member's name exactly
+	Name string
+}
+
+// encode returns the filter as a URL query string, omitting empty filters
+func (f StaffFilter) encode() string {
+	query := url.Values{}
+	if f.UserID != "" {
+		query.Set("userId", f.UserID)
+	}
+	if f.RoleID != "" {
+		query.Set("roleId", f.RoleID)
+	}
+	if f.Name != "" {
+		query.Set("name", f.Name)
+	}
+	return query.Encode()
+}
+
+// GetStaffs fetches the staff matching filter from the Node.js API. The list
+// endpoint excludes students and only returns summary fields; use GetStaff for
+// the full profile.
+func (c *NodejsClient) GetStaffs(ctx context.Context, filter StaffFilter) ([]models.StaffSummary, error) {
+	path := "/api/v1/staffs"
+	if query := filter.encode(); query != "" {
+		path += "?" + query
+	}
+
+	var list models.StaffList
+	if err := c.getJSON(ctx, path, &list); err != nil {
+		return nil, err
+	}
+
+	return list.Staffs, nil
+}
@@ -0,0 +1,462 @@
+// Package encryption encrypts finished PDF documents with the standard
+// security handler's AES-256 encryption (revision 6, as defined by PDF 2.0),
+// so they can only be opened with their user password. The PDF generator only
+// offers 40-bit RC4, which can be broken in minutes, so documents are
+// generated unencrypted and encrypted afterwards by rewriting every object.
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"regexp"
+	"strconv"
+)
+
+// Permissions are the operations readers of an encrypted document are
+// allowed, as the flags of the encryption dictionary's /P entry
+type Permissions uint32
+
+const (
+	// AllowPrint allows printing at full quality
+	AllowPrint Permissions = 1<<2 | 1<<11
+	// AllowModify allows changing the document and assembling its pages
+	AllowModify Permissions = 1<<3 | 1<<10
+	// AllowCopy allows copying text and graphics out of the document
+	AllowCopy Permissions = 1 << 4
+	// AllowAnnotate allows adding annotations and filling in form fields
+	AllowAnnotate Permissions = 1<<5 | 1<<8
+)
+
+// requiredPermissions are the bits of /P that must be set whatever is
+// allowed; they include extracting text for accessibility, which readers
+// for the visually impaired rely on
+const requiredPermissions = 0xFFFFF0C0 | 1<<9
+
+// maxPasswordLength is the number of UTF-8 bytes of a password that count
+const maxPasswordLength = 127
+
+var (
+	// startXrefPattern matches the offset of the last cross-reference table
+	startXrefPattern = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+	// trailerPattern matches an entry of the trailer dictionary
+	trailerPattern = regexp.MustCompile(`/(Size|Root|Info) (\d+)`)
+	// objectPattern matches the header of an indirect object
+	objectPattern = regexp.MustCompile(`^(\d+) (\d+) obj\s*`)
+	// lengthPattern matches the length of a stream in its dictionary
+	lengthPattern = regexp.MustCompile(`/Length\s+(\d+)(\s+\d+\s+R)?`)
+	// headerPattern matches the version header of a document
+	headerPattern = regexp.MustCompile(`^%PDF-\d\.\d`)
+	// permissionsPattern matches the permissions of an encryption dictionary
+	permissionsPattern = regexp.MustCompile(`/P (-?\d+)`)
+	// objectStreamPattern matches the type of a stream that holds other
+	// objects or the cross-reference table
+	objectStreamPattern = regexp.MustCompile(`/Type\s*/(ObjStm|XRef)\b`)
+)
+
+// Cipher encrypts and decrypts the strings and streams of a document with
+// its file key. Revision 6 uses the same key for every object.
+type Cipher struct {
+	key []byte
+}
+
+// Encrypt returns document, a complete PDF with a single classic
+// cross-reference table as the generator writes them, encrypted with AES-256.
+// Readers open it with userPassword and are allowed the operations of allow;
+// ownerPassword lifts the restrictions. Documents with object streams are
+// rejected, and the first element of an existing /ID is kept.
+func Encrypt(document []byte, userPassword, ownerPassword string, allow Permissions) ([]byte, error) {
+	match := startXrefPattern.FindSubmatch(document)
+	if match == nil {
+		return nil, errors.New("PDF has no cross-reference table")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if xref >= len(document) {
+		return nil, errors.New("PDF cross-reference table is out of range")
+	}
+	offsets, err := objectOffsets(document, xref)
+	if err != nil {
+		return nil, err
+	}
+	trailer := make(map[string]int)
+	for _, entry := range trailerPattern.FindAllSubmatch(document[xref:], -1) {
+		trailer[string(entry[1])], _ = strconv.Atoi(string(entry[2]))
+	}
+	size, root, info := trailer["Size"], trailer["Root"], trailer["Info"]
+	if size == 0 || root == 0 {
+		return nil, errors.New("PDF trailer has no catalog")
+	}
+	if bytes.Contains(document[xref:], []byte("/Encrypt")) {
+		return nil, errors.New("PDF is already encrypted")
+	}
+	if bytes.Contains(document[xref:], []byte("/XRefStm")) {
+		return nil, errors.New("PDF has object streams, which are not supported")
+	}
+
+	if !headerPattern.Match(document) {
+		return nil, errors.New("PDF has no header")
+	}
+
+	key, err := randomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cipher{key: key}
+	dict, err := c.dictionary(userPassword, ownerPassword, allow)
+	if err != nil {
+		return nil, err
+	}
+
+	// AES-256 needs PDF 1.7 with Adobe's extension level 8
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+
+	written := make([]int, size+1)
+	for number := 1; number < size; number++ {
+		offset, ok := offsets[number]
+		if !ok {
+			continue
+		}
+		body, err := c.encryptObject(document, offset, number)
+		if err != nil {
+			return nil, err
+		}
+		if number == root {
+			body = appendEntry(body, "/Extensions", "<</ADBE <</BaseVersion /1.7 /ExtensionLevel 8>>>>")
+		}
+		written[number] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", number, body)
+	}
+	written[size] = out.Len()
+	fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", size, dict)
+
+	xrefOffset := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", size+1)
+	for number := 1; number <= size; number++ {
+		if written[number] == 0 {
+			out.WriteString("0000000000 65535 f \n")
+			continue
+		}
+		fmt.Fprintf(&out, "%010d 00000 n \n", written[number])
+	}
+	// The second element of /ID changes with every revision of a document,
+	// while the first stays the same once it has been set
+	id, err := randomBytes(16)
+	if err != nil {
+		return nil, err
+	}
+	permanent, ok := documentID(document[xref:])
+	if !ok || len(permanent) == 0 {
+		permanent = id
+	}
+	fmt.Fprintf(&out, "trailer\n<<\n/Size %d\n/Root %d 0 R\n", size+1, root)
+	if info != 0 {
+		fmt.Fprintf(&out, "/Info %d 0 R\n", info)
+	}
+	fmt.Fprintf(&out, "/Encrypt %d 0 R\n/ID [<%x><%x>]\n>>\nstartxref\n%d\n%%%%EOF\n", size, permanent, id, xrefOffset)
+	return out.Bytes(), nil
+}
+
+// Open returns the cipher of a document encrypted with AES-256, given its
+// encryption dictionary and its user or owner password
+func Open(dict []byte, password string) (*Cipher, error) {
+	if !bytes.Contains(dict, []byte("/Filter /Standard")) || !bytes.Contains(dict, []byte("/V 5")) || !bytes.Contains(dict, []byte("/R 6")) {
+		return nil, errors.New("PDF encryption is not supported")
+	}
+	owner, okOwner := stringEntry(dict, "/O")
+	user, okUser := stringEntry(dict, "/U")
+	ownerKey, okOwnerKey := stringEntry(dict, "/OE")
+	userKey, okUserKey := stringEntry(dict, "/UE")
+	perms, okPerms := stringEntry(dict, "/Perms")
+	if !okOwner || !okUser || !okOwnerKey || !okUserKey || !okPerms ||
+		len(owner) < 48 || len(user) < 48 || len(ownerKey) != 32 || len(userKey) != 32 || len(perms) != 16 {
+		return nil, errors.New("PDF encryption dictionary is malformed")
+	}
+
+	secret := []byte(password)
+	if len(secret) > maxPasswordLength {
+		secret = secret[:maxPasswordLength]
+	}
+
+	// The salts follow the 32 byte hashes of /U and /O
+	var intermediate, wrapped []byte
+	switch {
+	case bytes.Equal(passwordHash(secret, user[32:40], nil), user[:32]):
+		intermediate, wrapped = passwordHash(secret, user[40:48], nil), userKey
+	case bytes.Equal(passwordHash(secret, owner[32:40], user[:48]), owner[:32]):
+		intermediate, wrapped = passwordHash(secret, owner[40:48], user[:48]), ownerKey
+	default:
+		return nil, errors.New("wrong password for encrypted PDF")
+	}
+
+	block, _ := aes.NewCipher(intermediate)
+	key := make([]byte, 32)
+	cipher.NewCBCDecrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(key, wrapped)
+	c := &Cipher{key: key}
+
+	// /Perms holds the permissions encrypted with the file key, which
+	// confirms the key is right
+	block, _ = aes.NewCipher(key)
+	check := make([]byte, 16)
+	block.Decrypt(check, perms)
+	p := permissionsPattern.FindSubmatch(dict)
+	if string(check[9:12]) != "adb" || p == nil {
+		return nil, errors.New("PDF encryption dictionary is malformed")
+	}
+	if want, _ := strconv.ParseInt(string(p[1]), 10, 32); binary.LittleEndian.Uint32(check) != uint32(int32(want)) {
+		return nil, errors.New("PDF permissions do not match the encryption dictionary")
+	}
+	return c, nil
+}
+
+// EncryptString returns text encrypted as a hex string
+func (c *Cipher) EncryptString(text []byte) (string, error) {
+	encrypted, err := c.encrypt(text)
+	if err != nil {
+		return "", err
+	}
+	return "<" + hex.EncodeToString(encrypted) + ">", nil
+}
+
+// Decrypt decrypts a string or stream of the document
+func (c *Cipher) Decrypt(data []byte) ([]byte, error) {
+	if len(data) < 2*aes.BlockSize || len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("encrypted data has an invalid length")
+	}
+	block, _ := aes.NewCipher(c.key)
+	out := make([]byte, len(data)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, data[:aes.BlockSize]).CryptBlocks(out, data[aes.BlockSize:])
+
+	padding := int(out[len(out)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(out[len(out)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, errors.New("encrypted data has invalid padding")
+	}
+	return out[:len(out)-padding], nil
+}
+
+// encrypt encrypts data with AES-256 in CBC mode behind a random
+// initialization vector, padded as PKCS#5 requires
+func (c *Cipher) encrypt(data []byte) ([]byte, error) {
+	iv, err := randomBytes(aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	padding := aes.BlockSize - len(data)%aes.BlockSize
+	out := make([]byte, aes.BlockSize+len(data)+padding)
+	copy(out, iv)
+	copy(out[aes.BlockSize:], data)
+	copy(out[aes.BlockSize+len(data):], bytes.Repeat([]byte{byte(padding)}, padding))
+
+	block, _ := aes.NewCipher(c.key)
+	cipher.NewCBCEncrypter(block, out[:aes.BlockSize]).CryptBlocks(out[aes.BlockSize:], out[aes.BlockSize:])
+	return out, nil
+}
+
+// dictionary returns the encryption dictionary that lets userPassword and
+// ownerPassword unlock the cipher's file key
+func (c *Cipher) dictionary(userPassword, ownerPassword string, allow Permissions) (string, error) {
+	if userPassword == "" {
+		return "", errors.New("a user password is required")
+	}
+	user := []byte(userPassword)
+	owner := []byte(ownerPassword)
+	if len(user) > maxPasswordLength {
+		user = user[:maxPasswordLength]
+	}
+	if len(owner) > maxPasswordLength {
+		owner = owner[:maxPasswordLength]
+	}
+
+	// /U and /O are a hash of the password followed by a validation salt and
+	// a key salt; /UE and /OE hold the file key encrypted with a hash of the
+	// password and the key salt
+	salts, err := randomBytes(36)
+	if err != nil {
+		return "", err
+	}
+	userSalts, ownerSalts := salts[:16], salts[16:32]
+	u := append(passwordHash(user, userSalts[:8], nil), userSalts...)
+	ue := wrapKey(passwordHash(user, userSalts[8:], nil), c.key)
+	o := append(passwordHash(owner, ownerSalts[:8], u), ownerSalts...)
+	oe := wrapKey(passwordHash(owner, ownerSalts[8:], u), c.key)
+
+	// /Perms repeats the permissions, encrypted with the file key so they
+	// cannot be changed without it
+	p := uint32(requiredPermissions | allow)
+	perms := make([]byte, 16)
+	binary.LittleEndian.PutUint32(perms, p)
+	copy(perms[4:], []byte{0xFF, 0xFF, 0xFF, 0xFF, 'T', 'a', 'd', 'b'})
+	copy(perms[12:], salts[32:])
+	block, _ := aes.NewCipher(c.key)
+	block.Encrypt(perms, perms)
+
+	return fmt.Sprintf("<</Filter /Standard /V 5 /R 6 /Length 256 "+
+		"/CF <</StdCF <</AuthEvent /DocOpen /CFM /AESV3 /Length 32>>>> /StmF /StdCF /StrF /StdCF "+
+		"/O <%x> /U <%x> /OE <%x> /UE <%x> /P %d /Perms <%x> /EncryptMetadata true>>",
+		o, u, oe, ue, int32(p), perms), nil
+}
+
+// encryptObject returns the body of the object at offset with its strings
+// and stream encrypted, without the obj and endobj keywords
+func (c *Cipher) encryptObject(document []byte, offset, number int) ([]byte, error) {
+	header := objectPattern.FindSubmatchIndex(document[offset:])
+	if header == nil {
+		return nil, fmt.Errorf("PDF object %d not found", number)
+	}
+
+	var out bytes.Buffer
+	data := document[offset+header[1]:]
+	for i := 0; i < len(data); {
+		switch ch := data[i]; {
+		case ch == '(':
+			value, end, err := literalString(data, i)
+			if err != nil {
+				return nil, fmt.Errorf("PDF object %d: %w", number, err)
+			}
+			encrypted, err := c.EncryptString(value)
+			if err != nil {
+				return nil, err
+			}
+			out.WriteString(encrypted)
+			i = end
+		case ch == '<' && i+1 < len(data) && data[i+1] == '<':
+			out.WriteString("<<")
+			i += 2
+		case ch == '<':
+			end := bytes.IndexByte(data[i:], '>')
+			if end < 0 {
+				return nil, fmt.Errorf("PDF object %d has an unterminated string", number)
+			}
+			value, err := hexString(data[i+1 : i+end])
+			if err != nil {
+				return nil, fmt.Errorf("PDF object %d: %w", number, err)
+			}
+			encrypted, err := c.EncryptString(value)
+			if err != nil {
+				return nil, err
+			}
+			out.WriteString(encrypted)
+			i += end + 1
+		case keywordAt(data, i, "endobj"):
+			return bytes.TrimSpace(out.Bytes()), nil
+		case keywordAt(data, i, "stream"):
+			dict, end, err := c.encryptStream(out.Bytes(), data, i)
+			if err != nil {
+				return nil, fmt.Errorf("PDF object %d: %w", number, err)
+			}
+			out.Reset()
+			out.Write(dict)
+			i = end
+		default:
+			out.WriteByte(ch)
+			i++
+		}
+	}
+	return nil, fmt.Errorf("PDF object %d is not terminated", number)
+}
+
+// encryptStream encrypts the stream starting at data[start], whose dictionary
+// has been written to dict, and returns the dictionary with the stream's new
+// length followed by the encrypted stream, along with the offset the stream
+// ends at in data
+func (c *Cipher) encryptStream(dict, data []byte, start int) ([]byte, int, error) {
+	if objectStreamPattern.Match(dict) {
+		return nil, 0, errors.New("object streams are not supported")
+	}
+	length := lengthPattern.FindSubmatchIndex(dict)
+	if length == nil {
+		return nil, 0, errors.New("stream has no length")
+	}
+	if length[4] >= 0 {
+		return nil, 0, errors.New("indirect stream lengths are not supported")
+	}
+	size, _ := strconv.Atoi(string(dict[length[2]:length[3]]))
+
+	begin := start + bytes.IndexByte(data[start:], '\n') + 1
+	if begin+size > len(data) {
+		return nil, 0, errors.New("stream is longer than the document")
+	}
+	rest := bytes.TrimLeft(data[begin+size:], "\r\n")
+	if !bytes.HasPrefix(rest, []byte("endstream")) {
+		return nil, 0, errors.New("stream length does not match its data")
+	}
+	end := len(data) - len(rest) + len("endstream")
+
+	encrypted, err := c.encrypt(data[begin : begin+size])
+	if err != nil {
+		return nil, 0, err
+	}
+	var out bytes.Buffer
+	out.Write(dict[:length[2]])
+	out.WriteString(strconv.Itoa(len(encrypted)))
+	out.Write(dict[length[3]:])
+	out.WriteString("stream\n")
+	out.Write(encrypted)
+	out.WriteString("\nendstream")
+	return out.Bytes(), end, nil
+}
+
+// passwordHash computes the hash of a password with a salt and, for the
+// owner password, the /U entry, as algorithm 2.B of PDF 2.0 defines it
+func passwordHash(password, salt, user []byte) []byte {
+	input := append(append(append([]byte(nil), password...), salt...), user...)
+	sum := sha256.Sum256(input)
+	k := sum[:]
+
+	var e []byte
+	for round := 0; round < 64 || int(e[len(e)-1]) > round-32; round++ {
+		var k1 []byte
+		for i := 0; i < 64; i++ {
+			k1 = append(append(append(k1, password...), k...), user...)
+		}
+
+		block, _ := aes.NewCipher(k[:16])
+		e = make([]byte, len(k1))
+		cipher.NewCBCEncrypter(block, k[16:32]).CryptBlocks(e, k1)
+
+		// The first 16 bytes of E as a number modulo 3 select the next hash;
+		// 256 is 1 modulo 3, so that is the sum of the bytes modulo 3
+		sum := 0
+		for _, b := range e[:16] {
+			sum += int(b)
+		}
+		var h hash.Hash
+		switch sum % 3 {
+		case 0:
+			h = sha256.New()
+		case 1:
+			h = sha512.New384()
+		default:
+			h = sha512.New()
+		}
+		h.Write(e)
+		k = h.Sum(nil)
+	}
+	return k[:32]
+}
+
+// wrapKey encrypts the file key with an intermediate key, with AES-256 in
+// CBC mode without an initialization vector or padding
+func wrapKey(intermediate, key []byte) []byte {
+	block, _ := aes.NewCipher(intermediate)
+	out := make([]byte, len(key))
+	cipher.NewCBCEncrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(out, key)
+	return out
+}
+
+// randomBytes returns n bytes from the system's secure random source
+func randomBytes(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate encryption key: %w", err)
+	}
+	return buf, nil
+}
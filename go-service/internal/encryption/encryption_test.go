@@ -0,0 +1,262 @@
+package encryption
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// samplePDF returns a one page PDF written by gofpdf with a title and a
+// compressed content stream
+func samplePDF(t *testing.T) []byte {
+	t.Helper()
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle("Report (Term 1)", false)
+	pdf.SetFont("Arial", "", 12)
+	pdf.AddPage()
+	pdf.Cell(40, 10, "Lisa Simpson 555-0113")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatalf("Failed to generate PDF: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// classicPDF returns a document made of objects, numbered from 1, with a
+// classic cross-reference table and trailer as the generator writes them
+func classicPDF(trailer string, objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<<\n/Size %d\n/Root 1 0 R\n%s>>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, trailer, xref)
+	return buf.Bytes()
+}
+
+// encryptionDictionary returns the encryption dictionary of a document
+func encryptionDictionary(t *testing.T, document []byte) []byte {
+	t.Helper()
+	match := regexp.MustCompile(`(?s)\d+ 0 obj\n(<</Filter /Standard.*?)\nendobj`).FindSubmatch(document)
+	if match == nil {
+		t.Fatal("Expected an encryption dictionary")
+	}
+	return match[1]
+}
+
+func TestEncryptAndOpen(t *testing.T) {
+	document, err := Encrypt(samplePDF(t), "01022010", "owner-secret", AllowPrint|AllowAnnotate)
+	if err != nil {
+		t.Fatalf("Failed to encrypt PDF: %v", err)
+	}
+	dict := encryptionDictionary(t, document)
+
+	for _, entry := range []string{"/V 5 /R 6", "/CFM /AESV3", "/Encrypt ", "/ID [<", "/ExtensionLevel 8"} {
+		if !bytes.Contains(document, []byte(entry)) {
+			t.Errorf("Expected the encrypted PDF to contain %q", entry)
+		}
+	}
+	if bytes.Contains(document, []byte("Report")) {
+		t.Error("Expected the title to be encrypted")
+	}
+
+	match := regexp.MustCompile(`/P (-?\d+)`).FindSubmatch(dict)
+	p, _ := strconv.ParseInt(string(match[1]), 10, 32)
+	if Permissions(p)&AllowPrint != AllowPrint || Permissions(p)&AllowAnnotate != AllowAnnotate {
+		t.Errorf("Expected printing and annotations to be allowed, got permissions %d", p)
+	}
+	if Permissions(p)&AllowCopy != 0 || Permissions(p)&AllowModify != 0 {
+		t.Errorf("Expected copying and modifying to be denied, got permissions %d", p)
+	}
+
+	if _, err := Open(dict, "wrong"); err == nil {
+		t.Error("Expected the wrong password to be rejected")
+	}
+	for _, password := range []string{"01022010", "owner-secret"} {
+		cipher, err := Open(dict, password)
+		if err != nil {
+			t.Fatalf("Expected %q to open the document, got error: %v", password, err)
+		}
+
+		// The page content decrypts to the text drawn on the page
+		stream := regexp.MustCompile(`(?s)/Length (\d+)>>\nstream\n`).FindSubmatchIndex(document)
+		length, _ := strconv.Atoi(string(document[stream[2]:stream[3]]))
+		content, err := cipher.Decrypt(document[stream[1] : stream[1]+length])
+		if err != nil {
+			t.Fatalf("Failed to decrypt content stream: %v", err)
+		}
+		reader, err := zlib.NewReader(bytes.NewReader(content))
+		if err != nil {
+			t.Fatalf("Expected the decrypted stream to be compressed: %v", err)
+		}
+		text, _ := io.ReadAll(reader)
+		if !bytes.Contains(text, []byte("(Lisa Simpson 555-0113)")) {
+			t.Errorf("Expected the page content to decrypt, got %q", text)
+		}
+
+		// So does the title
+		title := regexp.MustCompile(`/Title <([0-9a-f]+)>`).FindSubmatch(document)
+		encrypted, _ := hex.DecodeString(string(title[1]))
+		if decrypted, err := cipher.Decrypt(encrypted); err != nil || string(decrypted) != "Report (Term 1)" {
+			t.Errorf("Expected the title to decrypt, got %q (%v)", decrypted, err)
+		}
+	}
+}
+
+func TestEncryptedOffsets(t *testing.T) {
+	document, err := Encrypt(samplePDF(t), "01022010", "owner-secret", AllowPrint)
+	if err != nil {
+		t.Fatalf("Failed to encrypt PDF: %v", err)
+	}
+
+	// Every object of the rewritten document is where the table says
+	xref, _ := strconv.Atoi(string(startXrefPattern.FindSubmatch(document)[1]))
+	offsets, err := objectOffsets(document, xref)
+	if err != nil {
+		t.Fatalf("Failed to read cross-reference table: %v", err)
+	}
+	for number, offset := range offsets {
+		if !bytes.HasPrefix(document[offset:], []byte(strconv.Itoa(number)+" 0 obj")) {
+			t.Errorf("Expected object %d at offset %d", number, offset)
+		}
+	}
+
+	if _, err := Encrypt(document, "01022010", "owner-secret", AllowPrint); err == nil {
+		t.Error("Expected an encrypted document to be rejected")
+	}
+}
+
+func TestEncryptKeepsDocumentID(t *testing.T) {
+	const permanent = "00112233445566778899aabbccddeeff"
+	original := bytes.Replace(samplePDF(t), []byte("trailer\n<<"),
+		[]byte("trailer\n<<\n/ID [<"+permanent+"> <ffeeddccbbaa99887766554433221100>]"), 1)
+	document, err := Encrypt(original, "01022010", "owner-secret", AllowPrint)
+	if err != nil {
+		t.Fatalf("Failed to encrypt PDF: %v", err)
+	}
+
+	ids := regexp.MustCompile(`/ID \[<([0-9a-f]+)><([0-9a-f]+)>\]`).FindAllSubmatch(document, -1)
+	if len(ids) != 1 {
+		t.Fatalf("Expected one /ID in the encrypted PDF, got %d", len(ids))
+	}
+	if string(ids[0][1]) != permanent {
+		t.Errorf("Expected the first ID to stay %s, got %s", permanent, ids[0][1])
+	}
+	if string(ids[0][2]) == permanent || string(ids[0][2]) == "ffeeddccbbaa99887766554433221100" {
+		t.Errorf("Expected a new second ID, got %s", ids[0][2])
+	}
+
+	// A literal string ID is kept as well
+	original = classicPDF("/ID [(Term 1) (Term 1)]\n", "<</Type /Catalog>>")
+	document, err = Encrypt(original, "01022010", "owner-secret", AllowPrint)
+	if err != nil {
+		t.Fatalf("Failed to encrypt PDF: %v", err)
+	}
+	if !bytes.Contains(document, []byte(fmt.Sprintf("/ID [<%x><", "Term 1"))) {
+		t.Errorf("Expected the literal string ID to be kept, got trailer %q", document[bytes.LastIndex(document, []byte("trailer")):])
+	}
+}
+
+func TestEncryptRejectsObjectStreams(t *testing.T) {
+	objectStream := "<</Type /ObjStm /N 1 /First 4 /Length 20>>\nstream\n2 0 <</Type /Pages>>\nendstream"
+	xrefStream := "<</Type /XRef /Size 2 /W [1 2 1] /Root 1 0 R /Length 8>>\nstream\n\x00\x00\x00\xff\x01\x00\x09\x00\nendstream"
+
+	tests := []struct {
+		name     string
+		document []byte
+		err      string
+	}{
+		{"object stream", classicPDF("", "<</Type /Catalog /Pages 2 0 R>>", objectStream), "object streams are not supported"},
+		{"hybrid reference", classicPDF("/XRefStm 123\n", "<</Type /Catalog>>"), "object streams, which are not supported"},
+		{"cross-reference stream", []byte("%PDF-1.5\n1 0 obj\n<</Type /Catalog>>\nendobj\n2 0 obj\n" + xrefStream + "\nendobj\nstartxref\n36\n%%EOF\n"), "not a classic table"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Encrypt(test.document, "01022010", "owner-secret", AllowPrint)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+
+	// The same document without its object stream encrypts
+	if _, err := Encrypt(classicPDF("", "<</Type /Catalog /Pages 2 0 R>>", "<</Type /Pages /Kids [] /Count 0>>"), "01022010", "owner-secret", AllowPrint); err != nil {
+		t.Errorf("Expected a document without object streams to encrypt, got error: %v", err)
+	}
+}
+
+// TestEncryptQpdfInterop checks that qpdf, an independent implementation,
+// opens an encrypted document with either password and finds its content.
+// It is skipped when qpdf is not installed.
+func TestEncryptQpdfInterop(t *testing.T) {
+	qpdf, err := exec.LookPath("qpdf")
+	if err != nil {
+		t.Skip("qpdf is not installed")
+	}
+	document, err := Encrypt(samplePDF(t), "01022010", "owner-secret", AllowPrint)
+	if err != nil {
+		t.Fatalf("Failed to encrypt PDF: %v", err)
+	}
+	dir := t.TempDir()
+	encrypted := filepath.Join(dir, "encrypted.pdf")
+	if err := os.WriteFile(encrypted, document, 0o600); err != nil {
+		t.Fatalf("Failed to write PDF: %v", err)
+	}
+
+	if output, err := exec.Command(qpdf, "--check", encrypted).CombinedOutput(); err == nil {
+		t.Errorf("Expected qpdf to need a password, got %s", output)
+	}
+	for _, password := range []string{"01022010", "owner-secret"} {
+		if output, err := exec.Command(qpdf, "--password="+password, "--check", encrypted).CombinedOutput(); err != nil {
+			t.Fatalf("Expected qpdf to check the PDF with %q, got %v: %s", password, err, output)
+		}
+
+		// Decrypted without compression, the title and the page content are
+		// readable
+		decrypted := filepath.Join(dir, "decrypted.pdf")
+		if output, err := exec.Command(qpdf, "--password="+password, "--decrypt", "--qdf", "--object-streams=disable", encrypted, decrypted).CombinedOutput(); err != nil {
+			t.Fatalf("Failed to decrypt PDF with qpdf: %v: %s", err, output)
+		}
+		plain, err := os.ReadFile(decrypted)
+		if err != nil {
+			t.Fatalf("Failed to read decrypted PDF: %v", err)
+		}
+		for _, text := range []string{"(Lisa Simpson 555-0113)", "Report (Term 1)"} {
+			if !bytes.Contains(plain, []byte(text)) {
+				t.Errorf("Expected the PDF decrypted by qpdf to contain %q", text)
+			}
+		}
+	}
+}
+
+// passwordHashVector is the hash of the password 01022010 with the salt
+// saltsalt, computed with a port of the PDF.js implementation
+const passwordHashVector = "80b247f2e9f4546e5ef545ce2cb76d3eeeb514794b1175c1dba9ddb301199459"
+
+// TestPasswordHash checks the password hash of revision 6 against a value
+// computed with an independent implementation of algorithm 2.B
+func TestPasswordHash(t *testing.T) {
+	got := hex.EncodeToString(passwordHash([]byte("01022010"), []byte("saltsalt"), nil))
+	if got != passwordHashVector {
+		t.Errorf("Expected hash %s, got %s", passwordHashVector, got)
+	}
+}
@@ -0,0 +1,185 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// objectOffsets reads the offsets of the objects in the cross-reference
+// table at xref
+func objectOffsets(document []byte, xref int) (map[int]int, error) {
+	lines := strings.Split(string(document[xref:]), "\n")
+	if len(lines) < 2 || strings.TrimSpace(lines[0]) != "xref" {
+		return nil, errors.New("PDF cross-reference table is not a classic table")
+	}
+
+	offsets := make(map[int]int)
+	for i := 1; i < len(lines); {
+		var first, count int
+		if _, err := fmt.Sscanf(lines[i], "%d %d", &first, &count); err != nil {
+			break
+		}
+		for j := 0; j < count && i+1+j < len(lines); j++ {
+			var offset, generation int
+			var kind string
+			if _, err := fmt.Sscanf(lines[i+1+j], "%d %d %s", &offset, &generation, &kind); err == nil && kind == "n" {
+				if offset >= xref {
+					return nil, fmt.Errorf("PDF object %d is out of range", first+j)
+				}
+				offsets[first+j] = offset
+			}
+		}
+		i += count + 1
+	}
+	return offsets, nil
+}
+
+// appendEntry adds an entry at the end of a dictionary
+func appendEntry(dict []byte, key, value string) []byte {
+	end := bytes.LastIndex(dict, []byte(">>"))
+	return []byte(string(dict[:end]) + key + " " + value + "\n>>")
+}
+
+// keywordAt reports whether keyword starts at data[i] as a token of its own
+func keywordAt(data []byte, i int, keyword string) bool {
+	if !bytes.HasPrefix(data[i:], []byte(keyword)) {
+		return false
+	}
+	if i > 0 && !isDelimiter(data[i-1]) {
+		return false
+	}
+	end := i + len(keyword)
+	return end == len(data) || isDelimiter(data[end])
+}
+
+// isDelimiter reports whether c ends a token
+func isDelimiter(c byte) bool {
+	return strings.IndexByte(" \t\r\n\f\x00()<>[]{}/%", c) >= 0
+}
+
+// stringEntry returns the value of a literal or hex string entry of a
+// dictionary
+func stringEntry(dict []byte, key string) ([]byte, bool) {
+	for offset := 0; ; {
+		start := bytes.Index(dict[offset:], []byte(key))
+		if start < 0 {
+			return nil, false
+		}
+		at := offset + start + len(key)
+		offset = at
+
+		// Skip longer keys that start with key, such as /OE for /O
+		if at < len(dict) && !isDelimiter(dict[at]) {
+			continue
+		}
+		value := bytes.TrimLeft(dict[at:], " \t\r\n")
+		switch {
+		case bytes.HasPrefix(value, []byte("(")):
+			text, _, err := literalString(value, 0)
+			return text, err == nil
+		case bytes.HasPrefix(value, []byte("<")) && !bytes.HasPrefix(value, []byte("<<")):
+			end := bytes.IndexByte(value, '>')
+			if end < 0 {
+				return nil, false
+			}
+			text, err := hexString(value[1:end])
+			return text, err == nil
+		default:
+			return nil, false
+		}
+	}
+}
+
+// documentID returns the first element of the /ID array of a trailer, which
+// identifies a document across its revisions
+func documentID(trailer []byte) ([]byte, bool) {
+	start := bytes.Index(trailer, []byte("/ID"))
+	if start < 0 {
+		return nil, false
+	}
+	ids := bytes.TrimLeft(trailer[start+len("/ID"):], " \t\r\n")
+	if !bytes.HasPrefix(ids, []byte("[")) {
+		return nil, false
+	}
+	return stringEntry(ids, "[")
+}
+
+// literalString decodes the literal string starting at data[start] and
+// returns its value and the offset just past it
+func literalString(data []byte, start int) ([]byte, int, error) {
+	var value []byte
+	depth := 0
+	for i := start + 1; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '\\' && i+1 < len(data):
+			i++
+			switch next := data[i]; next {
+			case 'n':
+				value = append(value, '\n')
+			case 'r':
+				value = append(value, '\r')
+			case 't':
+				value = append(value, '\t')
+			case 'b':
+				value = append(value, '\b')
+			case 'f':
+				value = append(value, '\f')
+			case '\r':
+				// A line continuation, which may be followed by a line feed
+				if i+1 < len(data) && data[i+1] == '\n' {
+					i++
+				}
+			case '\n':
+				// A line continuation
+			default:
+				if next >= '0' && next <= '7' {
+					end := i
+					for end < len(data) && end < i+3 && data[end] >= '0' && data[end] <= '7' {
+						end++
+					}
+					code, _ := strconv.ParseUint(string(data[i:end]), 8, 16)
+					value = append(value, byte(code))
+					i = end - 1
+				} else {
+					value = append(value, next)
+				}
+			}
+		case c == '(':
+			depth++
+			value = append(value, c)
+		case c == ')':
+			if depth == 0 {
+				return value, i + 1, nil
+			}
+			depth--
+			value = append(value, c)
+		default:
+			value = append(value, c)
+		}
+	}
+	return nil, 0, errors.New("unterminated string")
+}
+
+// hexString decodes the digits of a hex string, ignoring white space; a
+// missing final digit is taken to be 0
+func hexString(digits []byte) ([]byte, error) {
+	clean := make([]byte, 0, len(digits)+1)
+	for _, c := range digits {
+		if strings.IndexByte(" \t\r\n\f\x00", c) < 0 {
+			clean = append(clean, c)
+		}
+	}
+	if len(clean)%2 == 1 {
+		clean = append(clean, '0')
+	}
+	value := make([]byte, len(clean)/2)
+	if _, err := hex.Decode(value, clean); err != nil {
+		return nil, fmt.Errorf("invalid hex string: %w", err)
+	}
+	return value, nil
+}
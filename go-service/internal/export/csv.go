@@ -0,0 +1,77 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// csvContentType is the MIME type of CSV reports
+const csvContentType = "text/csv; charset=utf-8"
+
+// renderCSV writes a report's tables as CSV. A single table is written as
+// is; several tables are each introduced by a row holding the title and
+// separated by an empty row.
+func renderCSV(ctx context.Context, report *Report) (io.Reader, string, error) {
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, report); err != nil {
+		return nil, "", err
+	}
+	return &buf, csvContentType, nil
+}
+
+// formulaPrefixes are the characters a spreadsheet reads a cell starting with
+// as a formula
+const formulaPrefixes = "=+-@\t\r"
+
+// writeCSV writes a report's tables to w as CSV. The cells hold names and
+// other text users entered, so cells a spreadsheet would evaluate are escaped.
+func writeCSV(w io.Writer, report *Report) error {
+	writer := csv.NewWriter(w)
+
+	for i, table := range report.Tables {
+		if len(report.Tables) > 1 {
+			if i > 0 {
+				if err := writer.Write([]string{""}); err != nil {
+					return err
+				}
+			}
+			if err := writer.Write(csvRow([]string{table.Title})); err != nil {
+				return err
+			}
+		}
+
+		if err := writer.Write(csvRow(table.Columns)); err != nil {
+			return err
+		}
+		for _, row := range table.Rows {
+			if err := writer.Write(csvRow(row)); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvRow returns the cells of a row with every cell a spreadsheet would read
+// as a formula prefixed with a quote, which makes it text. Numbers such as -3
+// are kept as they are.
+func csvRow(row []string) []string {
+	cells := make([]string, len(row))
+	for i, cell := range row {
+		cells[i] = cell
+		if cell == "" || !strings.ContainsRune(formulaPrefixes, rune(cell[0])) {
+			continue
+		}
+		if _, err := strconv.ParseFloat(cell, 64); err == nil {
+			continue
+		}
+		cells[i] = "'" + cell
+	}
+	return cells
+}
@@ -0,0 +1,257 @@
+// Package export renders a report in the format a caller asks for. A report
+// is defined once, as its data and the tables spreadsheets show, and the
+// Renderer registered for each format works from that definition.
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Report formats
+const (
+	FormatPDF  = "pdf"
+	FormatHTML = "html"
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatJSON = "json"
+)
+
+// Document modes, which only apply to the document formats
+const (
+	// ModeDraft marks a document as a draft, which is not signed or issued
+	ModeDraft = "draft"
+	// ModeOfficial issues a document as official, with a serial number
+	ModeOfficial = "official"
+)
+
+// Permissions a protected document can withhold from readers who open it with
+// the user password
+const (
+	PermissionPrint    = "print"
+	PermissionCopy     = "copy"
+	PermissionModify   = "modify"
+	PermissionAnnotate = "annotate"
+)
+
+// Permissions are the permissions of protected documents, which readers are
+// granted unless a protection denies them
+var Permissions = []string{PermissionPrint, PermissionCopy, PermissionModify, PermissionAnnotate}
+
+// ReportFormats are the formats every report can be written in; the first is
+// the default
+var ReportFormats = []string{FormatPDF, FormatCSV, FormatXLSX, FormatJSON, FormatHTML}
+
+// Errors returned by Negotiate and Render
+var (
+	// ErrUnsupportedFormat means the ?format= parameter names a format the
+	// report is not available in
+	ErrUnsupportedFormat = errors.New("unsupported report format")
+	// ErrNotAcceptable means none of the formats is in the Accept header
+	ErrNotAcceptable = errors.New("no acceptable report format")
+	// ErrUnsupportedReport means a renderer does not know how to lay out the
+	// report's data
+	ErrUnsupportedReport = errors.New("unsupported report")
+)
+
+// Protection encrypts a document so it can only be opened with a password.
+// It only applies to the document formats that support it.
+type Protection struct {
+	// Password is the user password the document is opened with
+	Password string
+	// Deny lists the Permissions withheld from readers
+	Deny []string
+}
+
+// Table is a titled table of report data, laid out as a spreadsheet shows it
+type Table struct {
+	Title   string
+	Columns []string
+	Rows    [][]string
+}
+
+// Report is a report definition shared by every output format
+type Report struct {
+	// Name is the download file name without an extension
+	Name string
+	// Data is the report's model. Document renderers lay the report out by
+	// its type; the JSON renderer writes it as is.
+	Data interface{}
+	// Tables lay the report out for the spreadsheet renderers
+	Tables []Table
+	// GeneratedAt is when the report's data was fetched, for reports that
+	// show it
+	GeneratedAt time.Time
+	// Mode is ModeDraft or ModeOfficial, or empty for a regular document
+	Mode string
+	// Protection encrypts the document when it is set
+	Protection *Protection
+}
+
+// Renderer renders reports in one format. Renderers are shared by concurrent
+// requests, so Render must not keep state between calls.
+type Renderer interface {
+	// Render renders report and returns the document and its MIME type. It
+	// returns ErrUnsupportedReport for report data it cannot lay out. A
+	// renderer may stream the document while it is still being drawn, in
+	// which case a later error is returned by the reader and the reader is
+	// an io.ReadCloser the caller must close; see Close.
+	Render(ctx context.Context, report *Report) (io.Reader, string, error)
+}
+
+// RendererFunc adapts a function to a Renderer
+type RendererFunc func(ctx context.Context, report *Report) (io.Reader, string, error)
+
+// Render implements Renderer
+func (f RendererFunc) Render(ctx context.Context, report *Report) (io.Reader, string, error) {
+	return f(ctx, report)
+}
+
+// registration is a Renderer registered for a format
+type registration struct {
+	contentType string
+	renderer    Renderer
+}
+
+// Registry holds the Renderer of each format by format name
+type Registry struct {
+	mu      sync.RWMutex
+	formats map[string]registration
+}
+
+// NewRegistry creates a registry holding the renderers of the table formats,
+// CSV, XLSX and JSON. Document formats are registered by their packages.
+func NewRegistry() *Registry {
+	registry := &Registry{formats: make(map[string]registration)}
+	registry.Register(FormatCSV, csvContentType, RendererFunc(renderCSV))
+	registry.Register(FormatXLSX, xlsxContentType, RendererFunc(renderXLSX))
+	registry.Register(FormatJSON, jsonContentType, RendererFunc(renderJSON))
+	return registry
+}
+
+// Register makes renderer the renderer of format, replacing any registered
+// before. contentType is the MIME type matched against Accept headers.
+func (r *Registry) Register(format, contentType string, renderer Renderer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.formats[format] = registration{contentType: contentType, renderer: renderer}
+}
+
+// Lookup returns the Renderer of a format
+func (r *Registry) Lookup(format string) (Renderer, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	registered, ok := r.formats[format]
+	return registered.renderer, ok
+}
+
+// ContentType returns the MIME type of a format, or an empty string when no
+// renderer is registered for it
+func (r *Registry) ContentType(format string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.formats[format].contentType
+}
+
+// Render renders report in format. It returns ErrUnsupportedFormat when no
+// renderer is registered for format.
+func (r *Registry) Render(ctx context.Context, format string, report *Report) (io.Reader, string, error) {
+	renderer, ok := r.Lookup(format)
+	if !ok {
+		return nil, "", ErrUnsupportedFormat
+	}
+	return renderer.Render(ctx, report)
+}
+
+// Close closes a rendered document if its reader is an io.Closer, which
+// releases a renderer that is still streaming it
+func Close(content io.Reader) error {
+	if closer, ok := content.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Negotiate selects one of formats for a request. The ?format= parameter
+// takes precedence; otherwise the most preferred type of the Accept header
+// that formats include is used, with wildcards and a missing header selecting
+// the first format. Browsers accept HTML with every page they load, so HTML
+// is only selected by the header when it accepts none of the other formats.
+func (r *Registry) Negotiate(req *http.Request, formats []string) (string, error) {
+	if format := req.URL.Query().Get("format"); format != "" {
+		for _, f := range formats {
+			if f == format && r.ContentType(f) != "" {
+				return format, nil
+			}
+		}
+		return "", ErrUnsupportedFormat
+	}
+
+	accept := req.Header.Get("Accept")
+	if accept == "" {
+		return formats[0], nil
+	}
+
+	type acceptedType struct {
+		mediaType string
+		quality   float64
+	}
+	var accepted []acceptedType
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality > 0 {
+			accepted = append(accepted, acceptedType{mediaType, quality})
+		}
+	}
+	sort.SliceStable(accepted, func(i, j int) bool { return accepted[i].quality > accepted[j].quality })
+
+	for _, html := range []bool{false, true} {
+		for _, a := range accepted {
+			for _, format := range formats {
+				if (format == FormatHTML) != html {
+					continue
+				}
+				contentType, _, _ := mime.ParseMediaType(r.ContentType(format))
+				if contentType == "" {
+					continue
+				}
+				if a.mediaType == "*/*" || a.mediaType == contentType ||
+					(strings.HasSuffix(a.mediaType, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(a.mediaType, "*"))) {
+					return format, nil
+				}
+			}
+		}
+	}
+	return "", ErrNotAcceptable
+}
+
+// jsonContentType is the MIME type of JSON reports
+const jsonContentType = "application/json"
+
+// renderJSON writes a report's data as JSON
+func renderJSON(ctx context.Context, report *Report) (io.Reader, string, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(report.Data); err != nil {
+		return nil, "", err
+	}
+	return &buf, jsonContentType, nil
+}
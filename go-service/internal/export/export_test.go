@@ -0,0 +1,217 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testReport returns a report with two tables
+func testReport() *Report {
+	return &Report{
+		Name: "roster",
+		Data: map[string]int{"students": 2},
+		Tables: []Table{
+			{Title: "Students", Columns: []string{"Roll", "Name"}, Rows: [][]string{{"1", "Bart Simpson"}, {"007", "Lisa <Simpson> & co"}}},
+			{Title: "Class: Grade 9/B", Columns: []string{"Class"}, Rows: [][]string{{"Grade 9"}}},
+		},
+	}
+}
+
+// testRegistry returns a registry with stand-ins for the document renderers
+func testRegistry() *Registry {
+	registry := NewRegistry()
+	registry.Register(FormatPDF, "application/pdf", RendererFunc(func(ctx context.Context, report *Report) (io.Reader, string, error) {
+		return strings.NewReader("%PDF-1.3"), "application/pdf", nil
+	}))
+	registry.Register(FormatHTML, "text/html; charset=utf-8", RendererFunc(func(ctx context.Context, report *Report) (io.Reader, string, error) {
+		return nil, "", ErrUnsupportedReport
+	}))
+	return registry
+}
+
+// render renders the test report with the renderer registered for format
+func render(t *testing.T, format string) []byte {
+	reader, _, err := testRegistry().Render(context.Background(), format, testReport())
+	if err != nil {
+		t.Fatalf("Expected %s report to render, got error: %v", format, err)
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to read %s report: %v", format, err)
+	}
+	return content
+}
+
+// TestNegotiate tests format selection from the format parameter and the
+// Accept header
+func TestNegotiate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		query  string
+		accept string
+		format string
+		err    error
+	}{
+		{"default", "", "", FormatPDF, nil},
+		{"parameter", "?format=xlsx", "application/pdf", FormatXLSX, nil},
+		{"unsupported_parameter", "?format=docx", "", "", ErrUnsupportedFormat},
+		{"accept", "", "text/csv", FormatCSV, nil},
+		{"quality", "", "application/pdf;q=0.5, application/json", FormatJSON, nil},
+		{"wildcard", "", "*/*", FormatPDF, nil},
+		{"type_wildcard", "", "text/*", FormatCSV, nil},
+		{"browser", "", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", FormatPDF, nil},
+		{"html_parameter", "?format=html", "", FormatHTML, nil},
+		{"html_only", "", "text/html", FormatHTML, nil},
+		{"not_acceptable", "", "image/png", "", ErrNotAcceptable},
+		{"refused", "", "application/pdf;q=0", "", ErrNotAcceptable},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/report"+tc.query, nil)
+			if tc.accept != "" {
+				r.Header.Set("Accept", tc.accept)
+			}
+
+			format, err := testRegistry().Negotiate(r, ReportFormats)
+			if !errors.Is(err, tc.err) || format != tc.format {
+				t.Errorf("Expected %q and error %v, got %q and %v", tc.format, tc.err, format, err)
+			}
+		})
+	}
+}
+
+// TestWriteCSV tests that several tables are written one after another
+func TestWriteCSV(t *testing.T) {
+	content := render(t, FormatCSV)
+
+	want := "Students\nRoll,Name\n1,Bart Simpson\n007,Lisa <Simpson> & co\n\nClass: Grade 9/B\nClass\nGrade 9\n"
+	if string(content) != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, content)
+	}
+}
+
+// TestWriteCSVEscapesFormulas tests that cells a spreadsheet would evaluate
+// are written as text, while negative numbers are kept
+func TestWriteCSVEscapesFormulas(t *testing.T) {
+	report := &Report{Tables: []Table{{
+		Columns: []string{"=Name", "Reason"},
+		Rows: [][]string{
+			{"=HYPERLINK(\"http://example.com\")", "+1 day"},
+			{"-2+3", "@SUM(A1:A2)"},
+			{"\tTab", "\rReturn"},
+			{"-3", "Sick leave"},
+		},
+	}}}
+
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, report); err != nil {
+		t.Fatalf("Expected CSV to be written, got error: %v", err)
+	}
+
+	want := "'=Name,Reason\n" +
+		"\"'=HYPERLINK(\"\"http://example.com\"\")\",'+1 day\n" +
+		"'-2+3,'@SUM(A1:A2)\n" +
+		"'\tTab,\"'\rReturn\"\n" +
+		"-3,Sick leave\n"
+	if buf.String() != want {
+		t.Errorf("Expected:\n%q\ngot:\n%q", want, buf.String())
+	}
+}
+
+// TestWriteXLSX tests the workbook parts, sheet names and cell types
+func TestWriteXLSX(t *testing.T) {
+	content := render(t, FormatXLSX)
+
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("Expected a ZIP package, got error: %v", err)
+	}
+	parts := make(map[string]string)
+	for _, file := range archive.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", file.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[file.Name] = string(content)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("Expected workbook to contain %s", name)
+		}
+	}
+
+	if !strings.Contains(parts["xl/workbook.xml"], `<sheet name="Class  Grade 9 B"`) {
+		t.Errorf("Expected invalid sheet name characters to be replaced, got %s", parts["xl/workbook.xml"])
+	}
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<c r="A1" t="inlineStr" s="1"><is><t xml:space="preserve">Roll</t></is></c>`,
+		`<c r="A2"><v>1</v></c>`,
+		`<c r="A3" t="inlineStr"><is><t xml:space="preserve">007</t></is></c>`,
+		`Lisa &lt;Simpson&gt; &amp; co`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("Expected worksheet to contain %q", want)
+		}
+	}
+}
+
+// TestXLSXColumnName tests column letters past Z
+func TestXLSXColumnName(t *testing.T) {
+	for col, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA"} {
+		if name := xlsxColumnName(col); name != want {
+			t.Errorf("Expected column %d to be %s, got %s", col, want, name)
+		}
+	}
+}
+
+// TestWriteJSON tests that the report data is written as JSON
+func TestWriteJSON(t *testing.T) {
+	content := render(t, FormatJSON)
+
+	var data map[string]int
+	if err := json.Unmarshal(content, &data); err != nil || data["students"] != 2 {
+		t.Errorf("Unexpected JSON %s", content)
+	}
+}
+
+// TestRegistry tests rendering with registered, replaced and missing
+// renderers
+func TestRegistry(t *testing.T) {
+	registry := testRegistry()
+
+	if _, _, err := registry.Render(context.Background(), "docx", testReport()); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Expected an unregistered format to be unsupported, got %v", err)
+	}
+	if _, _, err := registry.Render(context.Background(), FormatHTML, testReport()); !errors.Is(err, ErrUnsupportedReport) {
+		t.Errorf("Expected the renderer's error, got %v", err)
+	}
+
+	registry.Register(FormatHTML, "text/html", RendererFunc(func(ctx context.Context, report *Report) (io.Reader, string, error) {
+		return strings.NewReader("<h1>" + report.Name + "</h1>"), "text/html", nil
+	}))
+	reader, contentType, err := registry.Render(context.Background(), FormatHTML, testReport())
+	if err != nil {
+		t.Fatalf("Expected the replacement renderer to render, got error: %v", err)
+	}
+	if content, _ := io.ReadAll(reader); string(content) != "<h1>roster</h1>" || contentType != "text/html" {
+		t.Errorf("Unexpected %s document %s", contentType, content)
+	}
+
+	// Formats without a renderer cannot be negotiated
+	r := httptest.NewRequest("GET", "/report?format=html", nil)
+	if _, err := NewRegistry().Negotiate(r, ReportFormats); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Expected a format without a renderer to be unsupported, got %v", err)
+	}
+}
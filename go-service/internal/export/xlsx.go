@@ -0,0 +1,198 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// xlsxSheetNameLength is the longest sheet name spreadsheet applications accept
+const xlsxSheetNameLength = 31
+
+// xlsxPart is a file of a workbook package
+type xlsxPart struct {
+	name    string
+	content string
+}
+
+// xlsxContentType is the MIME type of XLSX reports
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// renderXLSX writes a report's tables as an Office Open XML workbook with one
+// worksheet per table and a bold header row
+func renderXLSX(ctx context.Context, report *Report) (io.Reader, string, error) {
+	var buf bytes.Buffer
+	if err := writeXLSX(&buf, report); err != nil {
+		return nil, "", err
+	}
+	return &buf, xlsxContentType, nil
+}
+
+// writeXLSX writes a report's tables to w as a workbook
+func writeXLSX(w io.Writer, report *Report) error {
+	tables := report.Tables
+	if len(tables) == 0 {
+		// A workbook needs at least one worksheet
+		tables = []Table{{Title: report.Name}}
+	}
+
+	archive := zip.NewWriter(w)
+
+	names := make([]string, len(tables))
+	used := make(map[string]bool)
+	for i, table := range tables {
+		names[i] = xlsxSheetName(table.Title, i+1, used)
+	}
+
+	parts := []xlsxPart{
+		{"[Content_Types].xml", xlsxContentTypes(len(tables))},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook(names)},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels(len(tables))},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for i, table := range tables {
+		parts = append(parts, xlsxPart{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), xlsxWorksheet(table)})
+	}
+
+	for _, part := range parts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// xlsxSheetName makes a table title a valid, unique sheet name
+func xlsxSheetName(title string, index int, used map[string]bool) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return ' '
+		}
+		return r
+	}, strings.TrimSpace(title))
+	if name == "" {
+		name = fmt.Sprintf("Sheet%d", index)
+	}
+	if runes := []rune(name); len(runes) > xlsxSheetNameLength {
+		name = string(runes[:xlsxSheetNameLength])
+	}
+	for base, n := name, 2; used[strings.ToLower(name)]; n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		runes := []rune(base)
+		if len(runes)+len(suffix) > xlsxSheetNameLength {
+			runes = runes[:xlsxSheetNameLength-len(suffix)]
+		}
+		name = string(runes) + suffix
+	}
+	used[strings.ToLower(name)] = true
+	return name
+}
+
+// xlsxWorksheet writes a table as worksheet XML. Values that read back as the
+// same number are stored as numbers; everything else, including numbers with
+// leading zeros, is stored as text.
+func xlsxWorksheet(table Table) string {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	writeRow := func(row int, values []string, style string) {
+		fmt.Fprintf(&buf, `<row r="%d">`, row)
+		for col, value := range values {
+			ref := xlsxColumnName(col) + strconv.Itoa(row)
+			if number, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(number) && !math.IsInf(number, 0) && strconv.FormatFloat(number, 'f', -1, 64) == value {
+				fmt.Fprintf(&buf, `<c r="%s"%s><v>%s</v></c>`, ref, style, value)
+				continue
+			}
+			fmt.Fprintf(&buf, `<c r="%s" t="inlineStr"%s><is><t xml:space="preserve">`, ref, style)
+			xml.EscapeText(&buf, []byte(value))
+			buf.WriteString(`</t></is></c>`)
+		}
+		buf.WriteString(`</row>`)
+	}
+
+	writeRow(1, table.Columns, ` s="1"`)
+	for i, values := range table.Rows {
+		writeRow(i+2, values, "")
+	}
+
+	buf.WriteString(`</sheetData></worksheet>`)
+	return buf.String()
+}
+
+// xlsxColumnName returns the letters naming a zero-based column, e.g. AA for 26
+func xlsxColumnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
+
+// xlsxContentTypes lists the content type of every part of a workbook
+func xlsxContentTypes(sheets int) string {
+	var buf strings.Builder
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	buf.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	buf.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	buf.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	buf.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&buf, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	buf.WriteString(`</Types>`)
+	return buf.String()
+}
+
+// xlsxWorkbook lists the worksheets of a workbook
+func xlsxWorkbook(names []string) string {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, name := range names {
+		buf.WriteString(`<sheet name="`)
+		xml.EscapeText(&buf, []byte(name))
+		fmt.Fprintf(&buf, `" sheetId="%d" r:id="rId%d"/>`, i+1, i+1)
+	}
+	buf.WriteString(`</sheets></workbook>`)
+	return buf.String()
+}
+
+// xlsxWorkbookRels links a workbook to its worksheets and styles
+func xlsxWorkbookRels(sheets int) string {
+	var buf strings.Builder
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&buf, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	fmt.Fprintf(&buf, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, sheets+1)
+	buf.WriteString(`</Relationships>`)
+	return buf.String()
+}
+
+// xlsxRootRels points the package at its workbook
+const xlsxRootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// xlsxStyles defines the default cell style and, as style 1, the bold header
+const xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`</styleSheet>`
@@ -0,0 +1,132 @@
+package html
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"go-service/internal/pdf"
+	"go-service/pkg/models"
+)
+
+// dashboardListRows and dashboardChartBars cap the rows of each table and the
+// bars of each chart as the one-page PDF summary does
+const (
+	dashboardListRows  = 4
+	dashboardChartBars = 6
+)
+
+// dashboard is the dashboard summary laid out as the PDF shows it
+type dashboard struct {
+	GeneratedAt  time.Time
+	Counts       []dashboardCount
+	Charts       []chart
+	Requests     int
+	Approved     int
+	OnReview     int
+	OutNextMonth int
+	Upcoming     []models.UpcomingLeave
+	Notices      []models.DashboardNotice
+}
+
+// dashboardCount is a head count of the dashboard
+type dashboardCount struct {
+	Label string
+	Count models.DashboardCount
+}
+
+// chart is a titled horizontal bar chart
+type chart struct {
+	Title string
+	Bars  []chartBar
+}
+
+// chartBar is a bar of a chart; Width is its length as a percentage of the
+// longest bar
+type chartBar struct {
+	Label string
+	Value string
+	Width float64
+}
+
+// RenderDashboardSummary writes the dashboard summary as an HTML document
+// with the head counts, charts and tables of the PDF summary
+func RenderDashboardSummary(w io.Writer, branding *pdf.Branding, summary *models.DashboardSummary, generatedAt time.Time) error {
+	report := dashboard{
+		GeneratedAt: generatedAt,
+		Counts: []dashboardCount{
+			{"Students", summary.Students},
+			{"Teachers", summary.Teachers},
+			{"Parents", summary.Parents},
+		},
+		Requests:     len(summary.LeaveHistory),
+		OutNextMonth: len(summary.OneMonthLeave),
+		Upcoming:     summary.OneMonthLeave,
+		Notices:      summary.Notices,
+	}
+
+	var labels []string
+	var values []float64
+	for _, c := range report.Counts {
+		labels = append(labels, c.Label+" (this year)", c.Label+" (last year)")
+		values = append(values, float64(c.Count.CurrentYear), float64(c.Count.PreviousYear()))
+	}
+	joined := newChart("Joined This Year vs Last Year", labels, values)
+
+	policies := append([]models.DashboardLeavePolicy(nil), summary.LeavePolicies...)
+	days := func(policy models.DashboardLeavePolicy) float64 {
+		value, _ := policy.TotalDaysUsed.Float64()
+		return value
+	}
+	sort.SliceStable(policies, func(i, j int) bool { return days(policies[i]) > days(policies[j]) })
+	if len(policies) > dashboardChartBars {
+		policies = policies[:dashboardChartBars]
+	}
+	labels, values = nil, nil
+	for _, policy := range policies {
+		labels = append(labels, policy.Name)
+		values = append(values, days(policy))
+	}
+	report.Charts = []chart{joined, newChart("Leave Days Used per Policy", labels, values)}
+
+	for _, leave := range summary.LeaveHistory {
+		switch leave.Status {
+		case "Approved":
+			report.Approved++
+		case "On Review":
+			report.OnReview++
+		}
+	}
+	if len(report.Upcoming) > dashboardListRows {
+		report.Upcoming = report.Upcoming[:dashboardListRows]
+	}
+	if len(report.Notices) > dashboardListRows {
+		report.Notices = report.Notices[:dashboardListRows]
+	}
+
+	return render(w, "dashboard.html", branding, report)
+}
+
+// newChart creates a bar chart with bars scaled to the largest value
+func newChart(title string, labels []string, values []float64) chart {
+	max := 0.0
+	for _, value := range values {
+		if value > max {
+			max = value
+		}
+	}
+
+	c := chart{Title: title}
+	for i, value := range values {
+		bar := chartBar{Label: labels[i], Value: fmt.Sprintf("%.1f", value)}
+		if value == float64(int64(value)) {
+			bar.Value = fmt.Sprintf("%d", int64(value))
+		}
+		if max > 0 && value > 0 {
+			bar.Width = 100 * value / max
+		}
+		c.Bars = append(c.Bars, bar)
+	}
+	return c
+}
@@ -0,0 +1,137 @@
+// Package html renders reports as standalone HTML documents for viewing in a
+// browser or printing
+package html
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"go-service/internal/pdf"
+	"go-service/pkg/models"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// templates holds the parsed HTML report templates
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"date": func(t time.Time) string {
+		if t.IsZero() {
+			return "Not specified"
+		}
+		return t.Format("Jan 2, 2006")
+	},
+	"longDate": func(t time.Time) string {
+		if t.IsZero() {
+			return "Not specified"
+		}
+		return t.Format("January 2, 2006")
+	},
+	"join": strings.Join,
+	"notRecorded": func(value string) string {
+		if value == "" {
+			return "Not recorded"
+		}
+		return value
+	},
+}).ParseFS(templateFS, "templates/*.html"))
+
+// page is the data passed to every HTML report template
+type page struct {
+	Branding *pdf.Branding
+	Report   interface{}
+}
+
+// profile is a single-record report laid out by a report template
+type profile struct {
+	Title    string
+	Subtitle string
+	Sections []profileSection
+}
+
+// profileSection is a titled section of a profile report
+type profileSection struct {
+	Title  string
+	Fields []pdf.FieldValue
+}
+
+// classRoster is a class section's roster table
+type classRoster struct {
+	Class   string
+	Section string
+	Columns []string
+	Rows    [][]string
+}
+
+// leaveReport is a user's leave in an academic year
+type leaveReport struct {
+	User     string
+	Year     models.AcademicYear
+	Requests []models.LeaveRequest
+	Policies []models.LeavePolicySummary
+}
+
+// classTeacherMatrix is the class teacher matrix with the number of columns
+// of its assignments table
+type classTeacherMatrix struct {
+	models.ClassTeacherMatrix
+	Columns int
+}
+
+// RenderProfile writes a student or staff report as an HTML document with
+// the sections and fields of its report template. values are the template's
+// fields resolved by pdf.Template.Values.
+func RenderProfile(w io.Writer, branding *pdf.Branding, tmpl *pdf.Template, values []pdf.FieldValue) error {
+	report := profile{Title: tmpl.Title, Subtitle: tmpl.Subtitle}
+	for _, section := range tmpl.Sections {
+		fields := values
+		if len(fields) > len(section.Fields) {
+			fields = fields[:len(section.Fields)]
+		}
+		values = values[len(fields):]
+		report.Sections = append(report.Sections, profileSection{Title: section.Title, Fields: fields})
+	}
+	return render(w, "profile.html", branding, report)
+}
+
+// RenderClassRoster writes a class section's roster as an HTML document with
+// the columns of the PDF roster
+func RenderClassRoster(w io.Writer, branding *pdf.Branding, className, section string, students []models.Student) error {
+	columns, rows := pdf.RosterTable(students)
+	return render(w, "class_roster.html", branding, classRoster{Class: className, Section: section, Columns: columns, Rows: rows})
+}
+
+// RenderLeaveReport writes a user's leave requests in an academic year and
+// the days taken per policy as an HTML document
+func RenderLeaveReport(w io.Writer, branding *pdf.Branding, userName string, year models.AcademicYear, requests []models.LeaveRequest, summaries []models.LeavePolicySummary) error {
+	return render(w, "leave_report.html", branding, leaveReport{User: userName, Year: year, Requests: requests, Policies: summaries})
+}
+
+// RenderNoticeBulletin writes a notice board bulletin as an HTML document
+// with one block per notice
+func RenderNoticeBulletin(w io.Writer, branding *pdf.Branding, bulletin models.NoticeBulletin) error {
+	return render(w, "notice_bulletin.html", branding, bulletin)
+}
+
+// RenderClassTeacherMatrix writes the class teacher matrix and the sections
+// and teachers it flags as an HTML document
+func RenderClassTeacherMatrix(w io.Writer, branding *pdf.Branding, matrix models.ClassTeacherMatrix) error {
+	return render(w, "class_teachers.html", branding, classTeacherMatrix{ClassTeacherMatrix: matrix, Columns: len(matrix.Sections) + 1})
+}
+
+// RenderLeaveDigest writes a pending leave digest as an HTML document
+// carrying the given branding
+func RenderLeaveDigest(w io.Writer, branding *pdf.Branding, digest models.LeaveDigest) error {
+	return render(w, "leave_digest.html", branding, digest)
+}
+
+// render executes the named template with the report and branding
+func render(w io.Writer, name string, branding *pdf.Branding, report interface{}) error {
+	if branding == nil {
+		branding = pdf.DefaultBranding()
+	}
+	return templates.ExecuteTemplate(w, name, page{Branding: branding, Report: report})
+}
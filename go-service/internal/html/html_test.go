@@ -0,0 +1,139 @@
+package html
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"go-service/internal/export"
+	"go-service/internal/pdf"
+	"go-service/pkg/models"
+)
+
+// TestRenderLeaveDigest tests that the digest lists every request, highlights
+// overlapping absences and escapes backend data
+func TestRenderLeaveDigest(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, time.May, d, 0, 0, 0, 0, time.UTC) }
+	requests := []models.LeaveRequest{
+		{ID: 1, User: "Edna Krabappel", UserID: 21, Department: "Primary", ReporterID: 1, Policy: "Sick Leave", From: day(6), To: day(8), Note: "<b>Flu</b>"},
+		{ID: 2, User: "Elizabeth Hoover", UserID: 22, Department: "Primary", ReporterID: 1, Policy: "Annual Leave", From: day(8), To: day(10)},
+	}
+	branding := &pdf.Branding{SchoolName: "Springfield Elementary", AccentColor: "#1F4E79"}
+
+	var buf bytes.Buffer
+	if err := RenderLeaveDigest(&buf, branding, models.BuildLeaveDigest(requests, 1, day(3))); err != nil {
+		t.Fatalf("Expected digest to render, got error: %v", err)
+	}
+	document := buf.String()
+
+	for _, want := range []string{
+		"Springfield Elementary",
+		"--accent: #1F4E79",
+		"<h2>Edna Krabappel - Primary</h2>",
+		"<h2>Elizabeth Hoover - Primary</h2>",
+		`<tr class="highlight"><td>Primary</td><td>May 8, 2024</td><td>May 8, 2024</td>`,
+		"&lt;b&gt;Flu&lt;/b&gt;",
+		"@media print",
+	} {
+		if !strings.Contains(document, want) {
+			t.Errorf("Expected document to contain %q", want)
+		}
+	}
+
+	buf.Reset()
+	if err := RenderLeaveDigest(&buf, nil, models.BuildLeaveDigest(nil, 1, day(3))); err != nil {
+		t.Fatalf("Expected empty digest to render, got error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "No leave requests are waiting for approval") {
+		t.Error("Expected empty digest to say no requests are waiting")
+	}
+}
+
+// TestRenderReports tests that every report renders the sections and fields
+// of its PDF and escapes backend data
+func TestRenderReports(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, time.May, d, 0, 0, 0, 0, time.UTC) }
+	branding := &pdf.Branding{SchoolName: "Springfield Elementary"}
+	student := models.Student{ID: 2, Name: "Bart <Simpson>", Class: "Grade 4", Section: "B", Roll: 7, DOB: day(1)}
+
+	matrix := models.BuildClassTeacherMatrix(
+		[]models.Class{{ID: 1, Name: "Grade 8", Sections: "A,B"}},
+		[]models.Section{{ID: 1, Name: "A"}, {ID: 2, Name: "B"}, {ID: 3, Name: "C"}},
+		[]models.ClassTeacher{{ID: 1, Class: "Grade 8", Section: "A", Teacher: "Edna Krabappel"}},
+	)
+
+	testCases := []struct {
+		name   string
+		render func(w *bytes.Buffer) error
+		want   []string
+	}{
+		{"profile", func(w *bytes.Buffer) error {
+			template, _ := pdf.BuiltinTemplates().Get(pdf.TemplateKindStudent, "")
+			values, err := template.Values(student)
+			if err != nil {
+				return err
+			}
+			return RenderProfile(w, branding, template, values)
+		}, []string{"<h1>STUDENT REPORT</h1>", "<h2>STUDENT INFORMATION</h2>", "<dt>Full Name</dt><dd>Bart &lt;Simpson&gt;</dd>", "<dt>Date of Birth</dt><dd>May 1, 2024</dd>", "<h2>ACADEMIC INFORMATION</h2>"}},
+		{"roster", func(w *bytes.Buffer) error {
+			return RenderClassRoster(w, branding, "Grade 4", "B", []models.Student{student})
+		}, []string{"<dt>Total Students</dt><dd>1</dd>", "<th>Guardian Contact</th>", "<td>7</td><td>Bart &lt;Simpson&gt;</td>"}},
+		{"leave", func(w *bytes.Buffer) error {
+			requests := []models.LeaveRequest{{Policy: "Sick Leave", From: day(6), To: day(8), Status: "Approved"}}
+			return RenderLeaveReport(w, branding, "Edna Krabappel", models.NewAcademicYear(2024, time.April), requests, nil)
+		}, []string{"<dd>2024-25</dd>", "<dd>April 1, 2024 to March 31, 2025</dd>", "<td>Sick Leave</td><td>3</td><td>Approved</td><td>-</td>", "No leave policies assigned"}},
+		{"notices", func(w *bytes.Buffer) error {
+			notice := models.BulletinNotice{Notice: models.Notice{Title: "Field Trip", Author: "Seymour Skinner", CreatedDate: day(6), ReviewerName: "Gary Chalmers"}, Recipients: "Everyone"}
+			return RenderNoticeBulletin(w, branding, models.NoticeBulletin{From: day(6), To: day(12), Notices: []models.BulletinNotice{notice}})
+		}, []string{"<h2>Field Trip</h2>", "Posted by Seymour Skinner on May 6, 2024 for Everyone", "Approved by Gary Chalmers</p>"}},
+		{"dashboard", func(w *bytes.Buffer) error {
+			summary := &models.DashboardSummary{
+				Students:      models.DashboardCount{CurrentYear: 12, Change: 2, PercentChange: 20},
+				LeavePolicies: []models.DashboardLeavePolicy{{Name: "Sick Leave", TotalDaysUsed: "4"}, {Name: "Annual Leave", TotalDaysUsed: "8"}},
+			}
+			return RenderDashboardSummary(w, branding, summary, day(6))
+		}, []string{"Week of May 6, 2024", "&#43;2 (&#43;20.0%) vs last year", `<span>Annual Leave</span><span><span class="fill" style="width: 100%"></span></span><span class="value">8</span>`, `style="width: 50%"`, "Nobody is on leave in the next 30 days"}},
+		{"class_teachers", func(w *bytes.Buffer) error {
+			return RenderClassTeacherMatrix(w, branding, matrix)
+		}, []string{"<td>Grade 8</td><td>Edna Krabappel</td><td class=\"unassigned\">UNASSIGNED</td><td>-</td>", "<td>Grade 8</td><td>B</td>", "No teacher is assigned to more than one section"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tc.render(&buf); err != nil {
+				t.Fatalf("Expected report to render, got error: %v", err)
+			}
+			document := buf.String()
+
+			for _, want := range append(tc.want, "Springfield Elementary", "@media print") {
+				if !strings.Contains(document, want) {
+					t.Errorf("Expected document to contain %q", want)
+				}
+			}
+		})
+	}
+}
+
+// TestRenderer tests that the renderer lays reports out by their data and
+// reports data without an HTML layout as unsupported
+func TestRenderer(t *testing.T) {
+	renderer := NewRenderer(func() *pdf.Branding { return &pdf.Branding{SchoolName: "Springfield Elementary"} })
+
+	reader, contentType, err := renderer.Render(context.Background(), &export.Report{Data: models.ClassRoster{Class: "Grade 4", Section: "B"}})
+	if err != nil {
+		t.Fatalf("Expected roster to render, got error: %v", err)
+	}
+	content, _ := io.ReadAll(reader)
+	if contentType != ContentType || !strings.Contains(string(content), "<h1>Class Roster</h1>") || !strings.Contains(string(content), "Springfield Elementary") {
+		t.Errorf("Unexpected %s document %s", contentType, content)
+	}
+
+	if _, _, err := renderer.Render(context.Background(), &export.Report{Data: "roster"}); !errors.Is(err, export.ErrUnsupportedReport) {
+		t.Errorf("Expected ErrUnsupportedReport, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package html
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"go-service/internal/export"
+	"go-service/internal/pdf"
+	"go-service/pkg/models"
+)
+
+// ContentType is the MIME type of HTML reports
+const ContentType = "text/html; charset=utf-8"
+
+// Renderer renders reports as HTML documents, laid out by the type of the
+// report's data with the same sections as the PDF. It keeps no state between
+// reports and can be shared by concurrent requests.
+type Renderer struct {
+	branding func() *pdf.Branding
+}
+
+// NewRenderer creates an HTML renderer. branding is called for every report
+// and returns the branding it carries; it may be nil to use the default.
+func NewRenderer(branding func() *pdf.Branding) *Renderer {
+	return &Renderer{branding: branding}
+}
+
+// Render implements export.Renderer
+func (r *Renderer) Render(ctx context.Context, report *export.Report) (io.Reader, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var branding *pdf.Branding
+	if r.branding != nil {
+		branding = r.branding()
+	}
+
+	var buf bytes.Buffer
+	if err := r.render(&buf, branding, report); err != nil {
+		return nil, "", err
+	}
+	return &buf, ContentType, nil
+}
+
+// render writes a report with the template of its data
+func (r *Renderer) render(w io.Writer, branding *pdf.Branding, report *export.Report) error {
+	switch data := report.Data.(type) {
+	case pdf.Profile:
+		values, err := data.Template.Values(data.Record)
+		if err != nil {
+			return err
+		}
+		return RenderProfile(w, branding, data.Template, values)
+	case models.ClassRoster:
+		return RenderClassRoster(w, branding, data.Class, data.Section, data.Students)
+	case models.LeaveReport:
+		return RenderLeaveReport(w, branding, data.User, data.Period, data.Requests, data.Policies)
+	case models.LeaveDigest:
+		return RenderLeaveDigest(w, branding, data)
+	case models.NoticeBulletin:
+		return RenderNoticeBulletin(w, branding, data)
+	case *models.DashboardSummary:
+		return RenderDashboardSummary(w, branding, data, report.GeneratedAt)
+	case models.ClassTeacherMatrix:
+		return RenderClassTeacherMatrix(w, branding, data)
+	}
+	return fmt.Errorf("%w: no HTML layout for %T", export.ErrUnsupportedReport, report.Data)
+}
@@ -0,0 +1,440 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Status is the lifecycle state of a job
+type Status string
+
+const (
+	StatusQueued  Status = "queued"
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed"
+)
+
+const (
+	// queueSize bounds the number of jobs waiting for a worker
+	queueSize = 100
+	// progressSaveInterval throttles how often progress updates are persisted
+	progressSaveInterval = 500 * time.Millisecond
+	// interruptedError is recorded for jobs that were unfinished when the service stopped
+	interruptedError = "job interrupted by service restart"
+	// cleanupInterval is how often finished jobs are checked for expiry
+	cleanupInterval = 10 * time.Minute
+)
+
+var (
+	// ErrNotFound is returned for unknown job IDs
+	ErrNotFound = errors.New("job not found")
+	// ErrNotReady is returned when the result of an unfinished job is requested
+	ErrNotReady = errors.New("job result not ready")
+	// ErrQueueFull is returned when too many jobs are waiting to run
+	ErrQueueFull = errors.New("job queue is full")
+	// ErrUnavailable is returned when the job directory cannot be used
+	ErrUnavailable = errors.New("job store unavailable")
+)
+
+// Progress reports how much of a job's work is complete
+type Progress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// Job is the persisted state of an asynchronous report job
+type Job struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+	// Owner is the ID of the user who submitted the job, the only user who
+	// may see it
+	Owner       int        `json:"owner"`
+	Status      Status     `json:"status"`
+	Progress    Progress   `json:"progress"`
+	Error       string     `json:"error,omitempty"`
+	ContentType string     `json:"contentType"`
+	Filename    string     `json:"filename"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+}
+
+// Task produces the artifact of a job by writing it to w. It should call
+// progress, from a single goroutine, as work completes.
+type Task func(ctx context.Context, w io.Writer, progress func(done, total int)) error
+
+// queuedJob pairs a job with the task that produces its artifact
+type queuedJob struct {
+	id   string
+	kind string
+	task Task
+}
+
+// Manager runs report jobs on a fixed pool of workers and persists their
+// state and artifacts to a local directory, so finished jobs survive a
+// process restart. Finished jobs and their artifacts are deleted once they
+// are older than the retention period.
+type Manager struct {
+	dir       string
+	workers   int
+	retention time.Duration
+
+	startOnce sync.Once
+	startErr  error
+
+	mu     sync.Mutex
+	jobs   map[string]*Job
+	queue  chan queuedJob
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewManager creates a job manager storing its state in dir, which runs jobs
+// on the given number of workers and keeps finished jobs for retention. The
+// directory is only opened and the workers only started on first use.
+func NewManager(dir string, workers int, retention time.Duration) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		dir:       dir,
+		workers:   workers,
+		retention: retention,
+		jobs:      make(map[string]*Job),
+		queue:     make(chan queuedJob, queueSize),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// start opens the job directory and starts the workers, once. Jobs found in
+// the directory are reloaded; jobs that were still queued or running when the
+// previous process stopped are marked failed.
+func (m *Manager) start() error {
+	m.startOnce.Do(func() {
+		if err := m.open(); err != nil {
+			fmt.Printf("Report jobs disabled: %v\n", err)
+			m.startErr = fmt.Errorf("%w: %v", ErrUnavailable, err)
+			return
+		}
+
+		for i := 0; i < m.workers; i++ {
+			m.wg.Add(1)
+			go m.worker()
+		}
+		m.wg.Add(1)
+		go m.cleaner()
+	})
+	return m.startErr
+}
+
+// open creates the job directory, readable by the service only, and reloads
+// the jobs persisted in it
+func (m *Manager) open() error {
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create job directory: %w", err)
+	}
+
+	// Temporary files are left behind by jobs interrupted mid-write
+	leftovers, _ := filepath.Glob(filepath.Join(m.dir, "*.tmp"))
+	for _, path := range leftovers {
+		os.Remove(path)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.load(); err != nil {
+		return err
+	}
+	m.expire(time.Now())
+	return nil
+}
+
+// Close stops the workers, cancelling any running job
+func (m *Manager) Close() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// Submit queues a task on behalf of the user with the given ID and returns
+// the new job
+func (m *Manager) Submit(owner int, kind, contentType, filename string, task Task) (Job, error) {
+	if err := m.start(); err != nil {
+		return Job{}, err
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return Job{}, err
+	}
+
+	job := &Job{
+		ID:          id,
+		Kind:        kind,
+		Owner:       owner,
+		Status:      StatusQueued,
+		ContentType: contentType,
+		Filename:    filename,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	select {
+	case m.queue <- queuedJob{id: id, kind: kind, task: task}:
+	default:
+		return Job{}, ErrQueueFull
+	}
+
+	m.jobs[id] = job
+	if err := m.save(job); err != nil {
+		fmt.Printf("Error persisting %s job: %v\n", kind, err)
+	}
+
+	return *job, nil
+}
+
+// Get returns a snapshot of the job with the given ID. Jobs submitted by
+// another user than owner are reported as not found.
+func (m *Manager) Get(id string, owner int) (Job, error) {
+	if err := m.start(); err != nil {
+		return Job{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok || job.Owner != owner {
+		return Job{}, ErrNotFound
+	}
+	return *job, nil
+}
+
+// OpenResult opens the artifact of a finished job submitted by owner. The
+// caller must close the file.
+func (m *Manager) OpenResult(id string, owner int) (*os.File, Job, error) {
+	job, err := m.Get(id, owner)
+	if err != nil {
+		return nil, Job{}, err
+	}
+	if job.Status != StatusDone {
+		return nil, job, ErrNotReady
+	}
+
+	file, err := os.Open(m.resultPath(id))
+	if err != nil {
+		return nil, job, fmt.Errorf("failed to open job result: %w", err)
+	}
+	return file, job, nil
+}
+
+// worker runs queued jobs until the manager is closed
+func (m *Manager) worker() {
+	defer m.wg.Done()
+	for {
+		select {
+		case queued := <-m.queue:
+			m.run(queued)
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// run executes a single job, writing its artifact to a temporary file that
+// is only moved into place once the task succeeds
+func (m *Manager) run(queued queuedJob) {
+	m.update(queued.id, true, func(job *Job) {
+		now := time.Now().UTC()
+		job.Status = StatusRunning
+		job.StartedAt = &now
+	})
+
+	err := m.produce(queued)
+
+	m.update(queued.id, true, func(job *Job) {
+		now := time.Now().UTC()
+		job.FinishedAt = &now
+		if err != nil {
+			job.Status = StatusFailed
+			job.Error = err.Error()
+			return
+		}
+		job.Status = StatusDone
+		job.Progress.Done = job.Progress.Total
+	})
+
+	if err != nil {
+		fmt.Printf("%s job failed: %v\n", queued.kind, err)
+	}
+}
+
+// produce runs the task of a job and stores its artifact. CreateTemp creates
+// the artifact with mode 0600, so only the service can read it.
+func (m *Manager) produce(queued queuedJob) error {
+	tmp, err := os.CreateTemp(m.dir, queued.id+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create result file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	var lastSave time.Time
+	progress := func(done, total int) {
+		persist := time.Since(lastSave) >= progressSaveInterval || done == total
+		if persist {
+			lastSave = time.Now()
+		}
+		m.update(queued.id, persist, func(job *Job) {
+			job.Progress = Progress{Done: done, Total: total}
+		})
+	}
+
+	if err := queued.task(m.ctx, tmp, progress); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write result file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), m.resultPath(queued.id)); err != nil {
+		return fmt.Errorf("failed to store result file: %w", err)
+	}
+	return nil
+}
+
+// update applies fn to a job under the lock and optionally persists it
+func (m *Manager) update(id string, persist bool, fn func(job *Job)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return
+	}
+	fn(job)
+
+	if persist {
+		if err := m.save(job); err != nil {
+			fmt.Printf("Error persisting %s job: %v\n", job.Kind, err)
+		}
+	}
+}
+
+// cleaner deletes expired jobs until the manager is closed
+func (m *Manager) cleaner() {
+	defer m.wg.Done()
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			m.mu.Lock()
+			m.expire(now)
+			m.mu.Unlock()
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// expire deletes the jobs that finished more than the retention period
+// before now, along with their artifacts. The caller must hold m.mu.
+func (m *Manager) expire(now time.Time) {
+	for id, job := range m.jobs {
+		if job.FinishedAt == nil || now.Sub(*job.FinishedAt) < m.retention {
+			continue
+		}
+
+		if err := os.Remove(m.resultPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("Error deleting expired %s job result: %v\n", job.Kind, err)
+			continue
+		}
+		if err := os.Remove(m.statePath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("Error deleting expired %s job: %v\n", job.Kind, err)
+			continue
+		}
+		delete(m.jobs, id)
+	}
+}
+
+// load reads persisted jobs from the job directory. The caller must hold m.mu.
+func (m *Manager) load() error {
+	paths, err := filepath.Glob(filepath.Join(m.dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("failed to list persisted jobs: %w", err)
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read persisted job: %w", err)
+		}
+
+		var job Job
+		if err := json.Unmarshal(data, &job); err != nil {
+			fmt.Printf("Skipping unreadable job file: %v\n", err)
+			continue
+		}
+
+		// Tasks cannot be resumed, so unfinished jobs are failed
+		if job.Status == StatusQueued || job.Status == StatusRunning {
+			now := time.Now().UTC()
+			job.Status = StatusFailed
+			job.Error = interruptedError
+			job.FinishedAt = &now
+			if err := m.save(&job); err != nil {
+				return fmt.Errorf("failed to persist interrupted job: %w", err)
+			}
+		}
+
+		m.jobs[job.ID] = &job
+	}
+
+	return nil
+}
+
+// save atomically writes the state of a job to disk
+func (m *Manager) save(job *Job) error {
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := m.statePath(job.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.statePath(job.ID))
+}
+
+// statePath returns the path of the persisted state of a job
+func (m *Manager) statePath(id string) string {
+	return filepath.Join(m.dir, id+".json")
+}
+
+// resultPath returns the path of the artifact of a job
+func (m *Manager) resultPath(id string) string {
+	return filepath.Join(m.dir, id+".result")
+}
+
+// newJobID returns a random, unguessable job ID
+func newJobID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
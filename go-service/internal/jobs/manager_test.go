@@ -0,0 +1,295 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testOwner is the ID of the user submitting the test jobs
+const testOwner = 7
+
+// waitForJob polls the manager until the job is done or failed
+func waitForJob(t *testing.T, m *Manager, id string) Job {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := m.Get(id, testOwner)
+		if err != nil {
+			t.Fatalf("Failed to get job: %v", err)
+		}
+		if job.Status == StatusDone || job.Status == StatusFailed {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("Job %s did not finish in time", id)
+	return Job{}
+}
+
+func TestSubmitAndDownloadResult(t *testing.T) {
+	m := NewManager(t.TempDir(), 1, time.Hour)
+	defer m.Close()
+
+	job, err := m.Submit(testOwner, "test", "text/plain", "result.txt", func(ctx context.Context, w io.Writer, progress func(done, total int)) error {
+		for i := 1; i <= 3; i++ {
+			progress(i, 3)
+		}
+		_, err := io.WriteString(w, "report")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to submit job: %v", err)
+	}
+	if job.Status != StatusQueued {
+		t.Errorf("Expected new job to be queued, got %s", job.Status)
+	}
+
+	job = waitForJob(t, m, job.ID)
+	if job.Status != StatusDone {
+		t.Fatalf("Expected job to be done, got %s (%s)", job.Status, job.Error)
+	}
+	if job.Progress != (Progress{Done: 3, Total: 3}) {
+		t.Errorf("Expected progress 3/3, got %+v", job.Progress)
+	}
+	if job.StartedAt == nil || job.FinishedAt == nil {
+		t.Error("Expected start and finish times to be recorded")
+	}
+
+	file, job, err := m.OpenResult(job.ID, testOwner)
+	if err != nil {
+		t.Fatalf("Failed to open result: %v", err)
+	}
+	defer file.Close()
+
+	data, _ := io.ReadAll(file)
+	if string(data) != "report" {
+		t.Errorf("Expected result %q, got %q", "report", string(data))
+	}
+	if job.Filename != "result.txt" || job.ContentType != "text/plain" {
+		t.Errorf("Unexpected result metadata: %+v", job)
+	}
+}
+
+func TestFailedJob(t *testing.T) {
+	m := NewManager(t.TempDir(), 1, time.Hour)
+	defer m.Close()
+
+	job, err := m.Submit(testOwner, "test", "text/plain", "result.txt", func(ctx context.Context, w io.Writer, progress func(done, total int)) error {
+		io.WriteString(w, "partial")
+		return errors.New("backend unavailable")
+	})
+	if err != nil {
+		t.Fatalf("Failed to submit job: %v", err)
+	}
+
+	job = waitForJob(t, m, job.ID)
+	if job.Status != StatusFailed || job.Error != "backend unavailable" {
+		t.Errorf("Expected failed job with error, got %s (%s)", job.Status, job.Error)
+	}
+
+	if _, _, err := m.OpenResult(job.ID, testOwner); !errors.Is(err, ErrNotReady) {
+		t.Errorf("Expected ErrNotReady for a failed job, got %v", err)
+	}
+
+	// The partial artifact must not be left behind
+	if matches, _ := filepath.Glob(filepath.Join(m.dir, "*.tmp")); len(matches) != 0 {
+		t.Errorf("Expected temporary files to be removed, found %v", matches)
+	}
+}
+
+func TestUnknownJob(t *testing.T) {
+	m := NewManager(t.TempDir(), 1, time.Hour)
+	defer m.Close()
+
+	if _, err := m.Get("missing", testOwner); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if _, _, err := m.OpenResult("missing", testOwner); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestJobsSurviveRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	m := NewManager(dir, 1, time.Hour)
+
+	done, err := m.Submit(testOwner, "test", "text/plain", "done.txt", func(ctx context.Context, w io.Writer, progress func(done, total int)) error {
+		_, err := io.WriteString(w, "finished")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to submit job: %v", err)
+	}
+	waitForJob(t, m, done.ID)
+
+	// Block the only worker so the next job is still running at shutdown
+	started := make(chan struct{})
+	running, err := m.Submit(testOwner, "test", "text/plain", "running.txt", func(ctx context.Context, w io.Writer, progress func(done, total int)) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("Failed to submit job: %v", err)
+	}
+	<-started
+
+	// Simulate a crash by reloading from disk while the state still says running
+	state, err := os.ReadFile(m.statePath(running.ID))
+	if err != nil {
+		t.Fatalf("Failed to read job state: %v", err)
+	}
+	m.Close()
+	if err := os.WriteFile(m.statePath(running.ID), state, 0o644); err != nil {
+		t.Fatalf("Failed to restore job state: %v", err)
+	}
+
+	restarted := NewManager(dir, 1, time.Hour)
+	defer restarted.Close()
+
+	file, job, err := restarted.OpenResult(done.ID, testOwner)
+	if err != nil {
+		t.Fatalf("Expected finished job to survive restart: %v", err)
+	}
+	data, _ := io.ReadAll(file)
+	file.Close()
+	if string(data) != "finished" || job.Status != StatusDone {
+		t.Errorf("Unexpected reloaded job %+v with result %q", job, string(data))
+	}
+
+	job, err = restarted.Get(running.ID, testOwner)
+	if err != nil {
+		t.Fatalf("Expected interrupted job to be reloaded: %v", err)
+	}
+	if job.Status != StatusFailed || job.Error != interruptedError {
+		t.Errorf("Expected interrupted job to be failed, got %s (%s)", job.Status, job.Error)
+	}
+}
+
+// submitDone submits a job that writes content and waits for it to finish
+func submitDone(t *testing.T, m *Manager, content string) Job {
+	t.Helper()
+
+	job, err := m.Submit(testOwner, "test", "text/plain", "result.txt", func(ctx context.Context, w io.Writer, progress func(done, total int)) error {
+		_, err := io.WriteString(w, content)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to submit job: %v", err)
+	}
+	return waitForJob(t, m, job.ID)
+}
+
+func TestJobsAreVisibleToOwnerOnly(t *testing.T) {
+	m := NewManager(t.TempDir(), 1, time.Hour)
+	defer m.Close()
+
+	job := submitDone(t, m, "report")
+	if job.Owner != testOwner {
+		t.Errorf("Expected job to be owned by %d, got %d", testOwner, job.Owner)
+	}
+
+	if _, err := m.Get(job.ID, testOwner+1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for another user, got %v", err)
+	}
+	if _, _, err := m.OpenResult(job.ID, testOwner+1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for another user's result, got %v", err)
+	}
+}
+
+func TestJobFilesArePrivate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "jobs")
+	m := NewManager(dir, 1, time.Hour)
+	defer m.Close()
+
+	// Nothing is created before the first job
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("Expected the job directory to be created on first use, got %v", err)
+	}
+
+	job := submitDone(t, m, "report")
+	for _, path := range []string{dir, m.statePath(job.ID), m.resultPath(job.ID)} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Failed to stat %s: %v", path, err)
+		}
+		if perm := info.Mode().Perm(); perm&0o077 != 0 {
+			t.Errorf("Expected %s to be readable by the service only, got %v", filepath.Base(path), perm)
+		}
+	}
+}
+
+func TestExpiredJobsAreDeleted(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(dir, 1, time.Hour)
+
+	old := submitDone(t, m, "old")
+	recent := submitDone(t, m, "recent")
+
+	m.mu.Lock()
+	m.expire(old.FinishedAt.Add(30 * time.Minute))
+	m.mu.Unlock()
+	if _, err := m.Get(old.ID, testOwner); err != nil {
+		t.Fatalf("Expected a job within the retention period to be kept: %v", err)
+	}
+
+	// Backdate the first job past the retention period
+	m.update(old.ID, true, func(job *Job) {
+		finished := job.FinishedAt.Add(-2 * time.Hour)
+		job.FinishedAt = &finished
+	})
+	m.mu.Lock()
+	m.expire(time.Now())
+	m.mu.Unlock()
+
+	if _, err := m.Get(old.ID, testOwner); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the expired job to be deleted, got %v", err)
+	}
+	for _, path := range []string{m.statePath(old.ID), m.resultPath(old.ID)} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be deleted, got %v", filepath.Base(path), err)
+		}
+	}
+	if _, err := m.Get(recent.ID, testOwner); err != nil {
+		t.Errorf("Expected the recent job to be kept: %v", err)
+	}
+
+	// Expired jobs persisted by a previous process are deleted on start
+	m.update(recent.ID, true, func(job *Job) {
+		finished := job.FinishedAt.Add(-2 * time.Hour)
+		job.FinishedAt = &finished
+	})
+	m.Close()
+
+	restarted := NewManager(dir, 1, time.Hour)
+	defer restarted.Close()
+	if _, err := restarted.Get(recent.ID, testOwner); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the expired job to be deleted on start, got %v", err)
+	}
+}
+
+func TestUnavailableJobStore(t *testing.T) {
+	// A file where the directory should be makes the store unusable
+	path := filepath.Join(t.TempDir(), "jobs")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	m := NewManager(path, 1, time.Hour)
+	defer m.Close()
+
+	if _, err := m.Submit(testOwner, "test", "text/plain", "result.txt", nil); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected ErrUnavailable, got %v", err)
+	}
+	if _, err := m.Get("missing", testOwner); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected ErrUnavailable, got %v", err)
+	}
+}
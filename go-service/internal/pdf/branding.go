@@ -0,0 +1,302 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	// defaultSchoolName is shown when no branding is configured
+	defaultSchoolName = "School Management System"
+	// logoImageName is the name the logo is registered under in each PDF
+	logoImageName = "branding-logo"
+	// logoHeight is the height of the letterhead logo in mm
+	logoHeight = 18
+)
+
+// Color is an RGB color
+type Color struct {
+	R, G, B int
+}
+
+// Branding is the school identity applied to every report
+type Branding struct {
+	SchoolName string `json:"schoolName"`
+	// Address lines printed in the letterhead
+	Address []string `json:"address"`
+	// Logo is a PNG or JPEG file, relative to the branding config file or absolute
+	Logo string `json:"logo"`
+	// AccentColor fills section and table headers, e.g. "#1F4E79"
+	AccentColor string `json:"accentColor"`
+	// AccentTextColor is the text color drawn on AccentColor
+	AccentTextColor string `json:"accentTextColor"`
+	// FooterDisclaimer is printed at the end of every report
+	FooterDisclaimer string `json:"footerDisclaimer"`
+
+	accent     Color
+	accentText Color
+	logo       []byte
+	logoType   string
+}
+
+// DefaultBranding returns the neutral branding used when none is configured
+func DefaultBranding() *Branding {
+	return &Branding{
+		SchoolName: defaultSchoolName,
+		accent:     Color{230, 230, 230},
+		accentText: Color{0, 0, 0},
+	}
+}
+
+// hasLetterhead reports whether the branding has a logo or address to print
+// at the top of every page
+func (b *Branding) hasLetterhead() bool {
+	return len(b.logo) > 0 || len(b.Address) > 0
+}
+
+// LoadBranding reads a branding config file and the logo it references
+func LoadBranding(path string) (*Branding, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read branding config: %w", err)
+	}
+
+	branding := DefaultBranding()
+	if err := json.Unmarshal(data, branding); err != nil {
+		return nil, fmt.Errorf("invalid branding config: %w", err)
+	}
+
+	if branding.SchoolName == "" {
+		branding.SchoolName = defaultSchoolName
+	}
+	if branding.AccentColor != "" {
+		if branding.accent, err = parseColor(branding.AccentColor); err != nil {
+			return nil, fmt.Errorf("invalid accentColor: %w", err)
+		}
+	}
+	if branding.AccentTextColor != "" {
+		if branding.accentText, err = parseColor(branding.AccentTextColor); err != nil {
+			return nil, fmt.Errorf("invalid accentTextColor: %w", err)
+		}
+	}
+
+	if branding.Logo != "" {
+		logoPath := branding.logoPath(path)
+		if branding.logo, err = os.ReadFile(logoPath); err != nil {
+			return nil, fmt.Errorf("failed to read logo: %w", err)
+		}
+
+		// gofpdf needs to be told the image format of in-memory images
+		switch http.DetectContentType(branding.logo) {
+		case "image/png":
+			branding.logoType = "PNG"
+		case "image/jpeg":
+			branding.logoType = "JPG"
+		default:
+			return nil, fmt.Errorf("logo %s must be a PNG or JPEG image", branding.Logo)
+		}
+	}
+
+	return branding, nil
+}
+
+// logoPath resolves the logo file relative to the branding config file
+func (b *Branding) logoPath(configPath string) string {
+	if filepath.IsAbs(b.Logo) {
+		return b.Logo
+	}
+	return filepath.Join(filepath.Dir(configPath), b.Logo)
+}
+
+// parseColor parses a "#RRGGBB" hex color
+func parseColor(value string) (Color, error) {
+	hex := strings.TrimPrefix(value, "#")
+	if len(hex) != 6 {
+		return Color{}, fmt.Errorf("%q is not a #RRGGBB color", value)
+	}
+
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return Color{}, fmt.Errorf("%q is not a #RRGGBB color", value)
+	}
+	return Color{int(rgb >> 16 & 0xff), int(rgb >> 8 & 0xff), int(rgb & 0xff)}, nil
+}
+
+// BrandingStore serves the branding config from a file, reloading it when
+// the file or its logo changes so branding can be updated without a restart
+type BrandingStore struct {
+	path string
+
+	mu       sync.Mutex
+	current  *Branding
+	modTimes [2]time.Time
+}
+
+// NewBrandingStore creates a store for the branding config at path. A missing
+// file yields the default branding.
+func NewBrandingStore(path string) *BrandingStore {
+	return &BrandingStore{path: path, current: DefaultBranding()}
+}
+
+// Current returns the branding to apply to a new report. A config that fails
+// to load is logged and the previously loaded branding is kept.
+func (s *BrandingStore) Current() *Branding {
+	if s == nil {
+		return DefaultBranding()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	configTime, err := modTime(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.current = DefaultBranding()
+		s.modTimes = [2]time.Time{}
+		return s.current
+	}
+	if err != nil {
+		fmt.Printf("Error checking branding config: %v\n", err)
+		return s.current
+	}
+
+	var logoTime time.Time
+	if s.current.Logo != "" {
+		logoTime, _ = modTime(s.current.logoPath(s.path))
+	}
+
+	if s.modTimes == [2]time.Time{configTime, logoTime} {
+		return s.current
+	}
+
+	branding, err := LoadBranding(s.path)
+	if err != nil {
+		// Remember the broken version so it is not reloaded for every report
+		s.modTimes = [2]time.Time{configTime, logoTime}
+		fmt.Printf("Error reloading branding config, keeping previous branding: %v\n", err)
+		return s.current
+	}
+
+	if branding.Logo != "" {
+		logoTime, _ = modTime(branding.logoPath(s.path))
+	}
+	s.current = branding
+	s.modTimes = [2]time.Time{configTime, logoTime}
+	fmt.Printf("Loaded branding for %s\n", branding.SchoolName)
+
+	return s.current
+}
+
+// modTime returns the modification time of a file
+func modTime(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// applyBranding registers the logo and letterhead of the generator's branding
+func (g *Generator) applyBranding() {
+	if len(g.branding.logo) > 0 {
+		g.pdf.RegisterImageOptionsReader(logoImageName, gofpdf.ImageOptions{ImageType: g.branding.logoType}, bytes.NewReader(g.branding.logo))
+	}
+
+	if g.branding.hasLetterhead() {
+		g.pdf.SetHeaderFunc(g.addLetterhead)
+	}
+}
+
+// addLetterhead draws the logo, school name and address at the top of a page
+// followed by a rule in the accent color
+func (g *Generator) addLetterhead() {
+	// Restore the font of the interrupted content, if any was selected yet
+	if current := g.font; current.Family != "" {
+		defer g.setFont(current)
+	}
+
+	leftMargin, topMargin, rightMargin, _ := g.pdf.GetMargins()
+	pageWidth, _ := g.pdf.GetPageSize()
+	textX := leftMargin
+
+	if len(g.branding.logo) > 0 {
+		g.pdf.ImageOptions(logoImageName, leftMargin, topMargin, 0, logoHeight, false, gofpdf.ImageOptions{ImageType: g.branding.logoType}, 0, "")
+		info := g.pdf.GetImageInfo(logoImageName)
+		if info != nil && info.Height() > 0 {
+			textX += logoHeight*info.Width()/info.Height() + 4
+		}
+	}
+
+	g.pdf.SetXY(textX, topMargin)
+	g.setFont(FontSpec{Family: "Arial", Style: "B", Size: 14})
+	g.cell(0, 7, g.branding.SchoolName)
+	g.pdf.Ln(7)
+
+	g.setFont(FontSpec{Family: "Arial", Size: 9})
+	for _, line := range g.branding.Address {
+		g.pdf.SetX(textX)
+		g.cell(0, 4.5, line)
+		g.pdf.Ln(4.5)
+	}
+
+	// Rule below the taller of the logo and the text block
+	y := g.pdf.GetY() + 2
+	if len(g.branding.logo) > 0 && y < topMargin+logoHeight+2 {
+		y = topMargin + logoHeight + 2
+	}
+	accent := g.branding.accent
+	g.pdf.SetDrawColor(accent.R, accent.G, accent.B)
+	g.pdf.SetLineWidth(0.8)
+	g.pdf.Line(leftMargin, y, pageWidth-rightMargin, y)
+	g.pdf.SetLineWidth(0.2)
+	g.pdf.SetDrawColor(0, 0, 0)
+
+	g.pdf.SetXY(leftMargin, y+6)
+}
+
+// schoolHeading returns the heading printed below a report title, which is
+// left out when the letterhead already names the school
+func (g *Generator) schoolHeading() string {
+	if g.branding.hasLetterhead() {
+		return ""
+	}
+	return g.branding.SchoolName
+}
+
+// setAccentFill selects the accent color for filled header cells
+func (g *Generator) setAccentFill() {
+	g.pdf.SetFillColor(g.branding.accent.R, g.branding.accent.G, g.branding.accent.B)
+	g.pdf.SetTextColor(g.branding.accentText.R, g.branding.accentText.G, g.branding.accentText.B)
+}
+
+// resetColors restores black text after drawing accent cells
+func (g *Generator) resetColors() {
+	g.pdf.SetTextColor(0, 0, 0)
+}
+
+// addDisclaimer prints the branding footer disclaimer wrapped to the page width
+func (g *Generator) addDisclaimer() {
+	if g.branding.FooterDisclaimer == "" {
+		return
+	}
+
+	pageWidth, _ := g.pdf.GetPageSize()
+	leftMargin, _, rightMargin, _ := g.pdf.GetMargins()
+	width := pageWidth - leftMargin - rightMargin - 2*g.pdf.GetCellMargin()
+
+	g.pdf.Ln(5)
+	for _, line := range g.wrapText(g.branding.FooterDisclaimer, width) {
+		g.cell(0, 4, line)
+		g.pdf.Ln(4)
+	}
+}
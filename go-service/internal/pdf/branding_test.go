@@ -0,0 +1,201 @@
+package pdf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-service/pkg/models"
+)
+
+// writeLogo writes a small PNG logo to path
+func writeLogo(t *testing.T, path string) {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for x := 0; x < 40; x++ {
+		for y := 0; y < 20; y++ {
+			img.Set(x, y, color.RGBA{31, 78, 121, 255})
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create logo: %v", err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		t.Fatalf("Failed to encode logo: %v", err)
+	}
+}
+
+// writeBranding writes a branding config and moves its modification time
+// forward so a reload is detected even on coarse filesystem clocks
+func writeBranding(t *testing.T, path, config string, age time.Duration) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatalf("Failed to write branding config: %v", err)
+	}
+	modified := time.Now().Add(-age)
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatalf("Failed to set branding config time: %v", err)
+	}
+}
+
+// TestLoadBranding tests parsing a branding config with a logo
+func TestLoadBranding(t *testing.T) {
+	dir := t.TempDir()
+	writeLogo(t, filepath.Join(dir, "logo.png"))
+	config := filepath.Join(dir, "branding.json")
+	writeBranding(t, config, `{
+		"schoolName": "Springfield High",
+		"address": ["742 Evergreen Terrace", "Springfield"],
+		"logo": "logo.png",
+		"accentColor": "#1F4E79",
+		"accentTextColor": "#FFFFFF",
+		"footerDisclaimer": "This report is issued by Springfield High."
+	}`, 0)
+
+	branding, err := LoadBranding(config)
+	if err != nil {
+		t.Fatalf("Failed to load branding: %v", err)
+	}
+
+	if branding.accent != (Color{31, 78, 121}) || branding.accentText != (Color{255, 255, 255}) {
+		t.Errorf("Unexpected colors: %+v %+v", branding.accent, branding.accentText)
+	}
+	if branding.logoType != "PNG" || len(branding.logo) == 0 {
+		t.Errorf("Expected the PNG logo to be loaded, got type %q", branding.logoType)
+	}
+	if !branding.hasLetterhead() {
+		t.Error("Expected a letterhead for a branding with a logo and address")
+	}
+	if DefaultBranding().hasLetterhead() {
+		t.Error("Expected no letterhead for the default branding")
+	}
+}
+
+// TestLoadBrandingValidation tests that invalid branding configs are rejected
+func TestLoadBrandingValidation(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "logo.gif"), []byte("GIF89a"), 0o644); err != nil {
+		t.Fatalf("Failed to write logo: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		config   string
+		errorMsg string
+	}{
+		{"invalid_json", `{`, "invalid branding config"},
+		{"invalid_color", `{"accentColor":"blue"}`, "invalid accentColor"},
+		{"missing_logo", `{"logo":"missing.png"}`, "failed to read logo"},
+		{"unsupported_logo", `{"logo":"logo.gif"}`, "PNG or JPEG"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := filepath.Join(dir, tc.name+".json")
+			writeBranding(t, config, tc.config, 0)
+
+			_, err := LoadBranding(config)
+			if err == nil || !strings.Contains(err.Error(), tc.errorMsg) {
+				t.Errorf("Expected error containing %q, got %v", tc.errorMsg, err)
+			}
+		})
+	}
+}
+
+// TestBrandingStoreReload tests that config changes are picked up without a
+// restart and that a broken config keeps the previous branding
+func TestBrandingStoreReload(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "branding.json")
+	store := NewBrandingStore(config)
+
+	if branding := store.Current(); branding.SchoolName != defaultSchoolName {
+		t.Errorf("Expected default branding without a config, got %q", branding.SchoolName)
+	}
+
+	writeBranding(t, config, `{"schoolName":"Springfield High"}`, time.Hour)
+	if branding := store.Current(); branding.SchoolName != "Springfield High" {
+		t.Errorf("Expected branding to be loaded, got %q", branding.SchoolName)
+	}
+
+	writeBranding(t, config, `{"schoolName":"Shelbyville High"}`, 0)
+	if branding := store.Current(); branding.SchoolName != "Shelbyville High" {
+		t.Errorf("Expected branding to be reloaded, got %q", branding.SchoolName)
+	}
+
+	writeBranding(t, config, `{"accentColor":"nope"}`, -time.Hour)
+	if branding := store.Current(); branding.SchoolName != "Shelbyville High" {
+		t.Errorf("Expected previous branding to be kept, got %q", branding.SchoolName)
+	}
+
+	os.Remove(config)
+	if branding := store.Current(); branding.SchoolName != defaultSchoolName {
+		t.Errorf("Expected default branding after the config is removed, got %q", branding.SchoolName)
+	}
+}
+
+// TestBrandedReports tests that branding is applied to every report type
+func TestBrandedReports(t *testing.T) {
+	dir := t.TempDir()
+	writeLogo(t, filepath.Join(dir, "logo.png"))
+	config := filepath.Join(dir, "branding.json")
+	writeBranding(t, config, `{
+		"schoolName": "Springfield High",
+		"address": ["742 Evergreen Terrace"],
+		"logo": "logo.png",
+		"accentColor": "#1F4E79",
+		"footerDisclaimer": "Issued by Springfield High. Contact the office to verify this report."
+	}`, 0)
+
+	branding, err := LoadBranding(config)
+	if err != nil {
+		t.Fatalf("Failed to load branding: %v", err)
+	}
+
+	reports := map[string]func(g *Generator, w io.Writer) error{
+		"student_report": func(g *Generator, w io.Writer) error {
+			return g.GenerateStudentReport(w, &models.Student{Name: "Bart Simpson"})
+		},
+		"class_roster": func(g *Generator, w io.Writer) error {
+			return g.GenerateClassRoster(w, "Grade 4", "B", []models.Student{{Name: "Bart Simpson"}})
+		},
+	}
+
+	for name, generate := range reports {
+		t.Run(name, func(t *testing.T) {
+			generator := NewGenerator(WithBranding(branding))
+			generator.pdf.SetCompression(false)
+
+			var buf bytes.Buffer
+			if err := generate(generator, &buf); err != nil {
+				t.Fatalf("Expected PDF generation to succeed, got error: %v", err)
+			}
+			pdfBytes := buf.Bytes()
+
+			for _, text := range []string{"Springfield High", "742 Evergreen Terrace", "/Subtype /Image", "Contact the office"} {
+				if !bytes.Contains(pdfBytes, []byte(text)) {
+					t.Errorf("Expected %q in the branded report", text)
+				}
+			}
+			// The accent color is used as a fill color (rg operator)
+			if !bytes.Contains(pdfBytes, []byte("0.122 0.306 0.475 rg")) {
+				t.Error("Expected the accent color to be used for header fills")
+			}
+			if bytes.Contains(pdfBytes, []byte(defaultSchoolName)) {
+				t.Error("Expected the default school name to be replaced")
+			}
+		})
+	}
+}
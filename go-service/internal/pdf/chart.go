@@ -0,0 +1,68 @@
+package pdf
+
+import "fmt"
+
+// chartBar is a single labelled bar of a bar chart
+type chartBar struct {
+	label string
+	value float64
+}
+
+const (
+	chartTitleHeight = 6
+	chartBarHeight   = 4
+	chartBarGap      = 1.5
+	chartLabelWidth  = 30
+	chartValueWidth  = 12
+)
+
+// addBarChart draws a horizontal bar chart of width at the current position,
+// with bars scaled to the largest value. Bars are filled in the accent color.
+func (g *Generator) addBarChart(title string, bars []chartBar, width float64) {
+	x, y := g.pdf.GetXY()
+
+	g.setFont(FontSpec{Family: "Arial", Style: "B", Size: 9})
+	g.pdf.SetXY(x, y)
+	g.cellFormat(width, chartTitleHeight, title, "", 0, "L", false)
+	y += chartTitleHeight
+
+	max := 0.0
+	for _, bar := range bars {
+		if bar.value > max {
+			max = bar.value
+		}
+	}
+
+	g.setFont(FontSpec{Family: "Arial", Style: "", Size: 8})
+	if len(bars) == 0 {
+		g.pdf.SetXY(x, y)
+		g.cellFormat(width, chartBarHeight, "No data", "", 0, "L", false)
+		g.pdf.SetXY(x, y+chartBarHeight)
+		return
+	}
+
+	barSpace := width - chartLabelWidth - chartValueWidth
+	for _, bar := range bars {
+		g.pdf.SetXY(x, y)
+		g.cellFormat(chartLabelWidth, chartBarHeight, g.fitText(bar.label, chartLabelWidth-2), "", 0, "L", false)
+
+		if max > 0 && bar.value > 0 {
+			g.setAccentFill()
+			g.pdf.Rect(x+chartLabelWidth, y+0.5, barSpace*bar.value/max, chartBarHeight-1, "F")
+			g.resetColors()
+		}
+
+		g.pdf.SetXY(x+width-chartValueWidth, y)
+		g.cellFormat(chartValueWidth, chartBarHeight, formatChartValue(bar.value), "", 0, "R", false)
+		y += chartBarHeight + chartBarGap
+	}
+	g.pdf.SetXY(x, y)
+}
+
+// formatChartValue prints whole values without decimals
+func formatChartValue(value float64) string {
+	if value == float64(int64(value)) {
+		return fmt.Sprintf("%d", int64(value))
+	}
+	return fmt.Sprintf("%.1f", value)
+}
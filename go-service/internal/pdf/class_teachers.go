@@ -0,0 +1,146 @@
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"go-service/pkg/models"
+)
+
+// classTeacherClassWidth is the width of the class column of the matrix; the
+// section columns share the rest of the printable width
+const classTeacherClassWidth = 30
+
+// classSectionColumns and teacherSectionsColumns define the flag table
+// layouts; widths add up to the printable width of an A4 page with the
+// default 10mm margins
+var (
+	classSectionColumns = []tableColumn{
+		{"Class", 95}, {"Section", 95},
+	}
+	teacherSectionsColumns = []tableColumn{
+		{"Teacher", 60}, {"Sections", 130},
+	}
+	unplacedColumns = []tableColumn{
+		{"Teacher", 60}, {"Class", 65}, {"Section", 65},
+	}
+)
+
+// GenerateClassTeacherMatrix writes a PDF with the class teacher of every
+// section laid out as a class by section matrix, followed by the sections
+// without a class teacher and the teachers assigned to several sections
+func (g *Generator) GenerateClassTeacherMatrix(w io.Writer, matrix models.ClassTeacherMatrix) error {
+	g.addPageNumbers()
+
+	// Title
+	g.pdf.AddPage()
+	g.setFont(FontSpec{Family: "Arial", Style: "B", Size: 16})
+	g.cell(0, 10, "CLASS TEACHER ASSIGNMENTS")
+	g.pdf.Ln(12)
+
+	// School Header
+	if heading := g.schoolHeading(); heading != "" {
+		g.setFont(FontSpec{Family: "Arial", Style: "B", Size: 14})
+		g.cell(0, 8, heading)
+		g.pdf.Ln(10)
+	}
+
+	g.addField("Classes", fmt.Sprintf("%d", len(matrix.Rows)))
+	g.addField("Unassigned Sections", fmt.Sprintf("%d", len(matrix.Unassigned)))
+	g.addField("Multiple Sections", fmt.Sprintf("%d teachers", len(matrix.MultiAssigned)))
+	g.pdf.Ln(4)
+
+	g.addSectionHeader("ASSIGNMENTS")
+	columns := []tableColumn{{"Class", 190}}
+	if len(matrix.Sections) > 0 {
+		columns[0].width = classTeacherClassWidth
+		width := (190 - classTeacherClassWidth) / float64(len(matrix.Sections))
+		for _, section := range matrix.Sections {
+			columns = append(columns, tableColumn{section, width})
+		}
+	}
+	rows := make([][]string, len(matrix.Rows))
+	for i, row := range matrix.Rows {
+		rows[i] = append([]string{row.Class}, classTeacherCells(matrix, row)...)
+	}
+	g.addTable(columns, rows, "No classes found")
+
+	g.setFont(FontSpec{Family: "Arial", Style: "I", Size: 8})
+	g.cell(0, 6, "* assigned to more than one section; - the class does not run the section")
+	g.pdf.Ln(6)
+	g.pdf.Ln(g.template.Spacing.BetweenSections)
+
+	g.addSectionHeader("SECTIONS WITHOUT A CLASS TEACHER")
+	rows = make([][]string, len(matrix.Unassigned))
+	for i, section := range matrix.Unassigned {
+		rows[i] = []string{section.Class, section.Section}
+	}
+	g.addTable(classSectionColumns, rows, "Every section has a class teacher")
+	g.pdf.Ln(g.template.Spacing.BetweenSections)
+
+	g.addSectionHeader("TEACHERS WITH MULTIPLE SECTIONS")
+	rows = make([][]string, len(matrix.MultiAssigned))
+	for i, assignments := range matrix.MultiAssigned {
+		sections := make([]string, len(assignments.Sections))
+		for j, section := range assignments.Sections {
+			sections[j] = section.Class + " " + section.Section
+		}
+		rows[i] = []string{assignments.Teacher, strings.Join(sections, ", ")}
+	}
+	g.addTable(teacherSectionsColumns, rows, "No teacher is assigned to more than one section")
+
+	// Assignments to deleted classes or sections only need attention when
+	// there are any
+	if len(matrix.Unplaced) > 0 {
+		g.pdf.Ln(g.template.Spacing.BetweenSections)
+		g.addSectionHeader("ASSIGNMENTS TO REMOVED CLASSES OR SECTIONS")
+		rows = make([][]string, len(matrix.Unplaced))
+		for i, assignment := range matrix.Unplaced {
+			rows[i] = []string{notRecorded(assignment.Teacher), notRecorded(assignment.Class), notRecorded(assignment.Section)}
+		}
+		g.addTable(unplacedColumns, rows, "")
+	}
+
+	// Footer
+	g.addFooter()
+
+	if err := g.output(w); err != nil {
+		return fmt.Errorf("failed to generate PDF: %w", err)
+	}
+
+	return nil
+}
+
+// classTeacherCells returns the text of a matrix row's cells: the teachers,
+// marked when they teach several sections, UNASSIGNED for a section without a
+// class teacher and - for a section the class does not run
+func classTeacherCells(matrix models.ClassTeacherMatrix, row models.ClassTeacherRow) []string {
+	cells := make([]string, len(row.Cells))
+	for i, cell := range row.Cells {
+		switch {
+		case cell.Unassigned():
+			cells[i] = "UNASSIGNED"
+		case len(cell.Teachers) == 0:
+			cells[i] = "-"
+		default:
+			teachers := make([]string, len(cell.Teachers))
+			for j, teacher := range cell.Teachers {
+				teachers[j] = teacher
+				if matrix.TeachesSeveral(teacher) {
+					teachers[j] += " *"
+				}
+			}
+			cells[i] = strings.Join(teachers, ", ")
+		}
+	}
+	return cells
+}
+
+// notRecorded returns value, or "Not recorded" when it is empty
+func notRecorded(value string) string {
+	if value == "" {
+		return "Not recorded"
+	}
+	return value
+}
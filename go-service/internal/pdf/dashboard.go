@@ -0,0 +1,163 @@
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"go-service/pkg/models"
+)
+
+const (
+	// dashboardListRows and dashboardChartBars cap the rows of each table and
+	// the bars of each chart so the summary stays on one page
+	dashboardListRows  = 4
+	dashboardChartBars = 6
+	dashboardBoxHeight = 18
+	dashboardGutter    = 6
+)
+
+// dashboardUpcomingColumns and dashboardNoticeColumns define the dashboard
+// table layouts; widths add up to the printable width of an A4 page with the
+// default 10mm margins
+var (
+	dashboardUpcomingColumns = []tableColumn{
+		{"Name", 70}, {"Leave Type", 50}, {"From", 35}, {"To", 35},
+	}
+	dashboardNoticeColumns = []tableColumn{
+		{"Title", 90}, {"Author", 50}, {"Date", 25}, {"Status", 25},
+	}
+)
+
+// GenerateDashboardSummary writes a one-page executive summary of the
+// dashboard: head counts compared with the previous year, leave statistics
+// and the latest notices
+func (g *Generator) GenerateDashboardSummary(w io.Writer, summary *models.DashboardSummary, generatedAt time.Time) error {
+	pageWidth, _ := g.pdf.GetPageSize()
+	leftMargin, _, rightMargin, _ := g.pdf.GetMargins()
+	width := pageWidth - leftMargin - rightMargin
+	half := (width - dashboardGutter) / 2
+
+	// Title
+	g.pdf.AddPage()
+	g.setFont(FontSpec{Family: "Arial", Style: "B", Size: 16})
+	g.cell(0, 10, "SCHOOL DASHBOARD SUMMARY")
+	g.pdf.Ln(10)
+
+	// School Header
+	if heading := g.schoolHeading(); heading != "" {
+		g.setFont(FontSpec{Family: "Arial", Style: "B", Size: 14})
+		g.cell(0, 8, heading)
+		g.pdf.Ln(8)
+	}
+
+	g.setFont(FontSpec{Family: "Arial", Style: "I", Size: 9})
+	g.cell(0, 6, "Week of "+formatDate(generatedAt))
+	g.pdf.Ln(8)
+
+	// Head counts
+	counts := []struct {
+		label string
+		count models.DashboardCount
+	}{
+		{"Students", summary.Students},
+		{"Teachers", summary.Teachers},
+		{"Parents", summary.Parents},
+	}
+	boxWidth := (width - 2*dashboardGutter) / 3
+	y := g.pdf.GetY()
+	for i, c := range counts {
+		g.addCountBox(leftMargin+float64(i)*(boxWidth+dashboardGutter), y, boxWidth, c.label, c.count)
+	}
+	g.pdf.SetXY(leftMargin, y+dashboardBoxHeight+dashboardGutter)
+
+	// Charts side by side
+	joined := make([]chartBar, 0, 2*len(counts))
+	for _, c := range counts {
+		joined = append(joined,
+			chartBar{c.label + " (this year)", float64(c.count.CurrentYear)},
+			chartBar{c.label + " (last year)", float64(c.count.PreviousYear())})
+	}
+	var used []chartBar
+	for _, policy := range summary.LeavePolicies {
+		days, _ := policy.TotalDaysUsed.Float64()
+		used = append(used, chartBar{policy.Name, days})
+	}
+	sort.SliceStable(used, func(i, j int) bool { return used[i].value > used[j].value })
+	if len(used) > dashboardChartBars {
+		used = used[:dashboardChartBars]
+	}
+
+	y = g.pdf.GetY()
+	g.addBarChart("Joined This Year vs Last Year", joined, half)
+	bottom := g.pdf.GetY()
+	g.pdf.SetXY(leftMargin+half+dashboardGutter, y)
+	g.addBarChart("Leave Days Used per Policy", used, half)
+	if g.pdf.GetY() > bottom {
+		bottom = g.pdf.GetY()
+	}
+	g.pdf.SetXY(leftMargin, bottom+2)
+
+	// Leave statistics
+	g.addSectionHeader("LEAVE")
+	approved, onReview := 0, 0
+	for _, leave := range summary.LeaveHistory {
+		switch leave.Status {
+		case "Approved":
+			approved++
+		case "On Review":
+			onReview++
+		}
+	}
+	g.addField("Latest Requests", fmt.Sprintf("%d (%d approved, %d on review); %d out in the next 30 days",
+		len(summary.LeaveHistory), approved, onReview, len(summary.OneMonthLeave)))
+
+	var rows [][]string
+	for i, leave := range summary.OneMonthLeave {
+		if i == dashboardListRows {
+			break
+		}
+		rows = append(rows, []string{leave.User, leave.LeaveType, formatDate(leave.FromDate.Time), formatDate(leave.ToDate.Time)})
+	}
+	g.addTable(dashboardUpcomingColumns, rows, "Nobody is on leave in the next 30 days")
+	g.pdf.Ln(2)
+
+	// Recent notices
+	g.addSectionHeader("RECENT NOTICES")
+	rows = nil
+	for i, notice := range summary.Notices {
+		if i == dashboardListRows {
+			break
+		}
+		rows = append(rows, []string{notice.Title, notice.Author, notice.CreatedDate.Format("Jan 2, 2006"), notice.Status})
+	}
+	g.addTable(dashboardNoticeColumns, rows, "No notices")
+
+	// Footer
+	g.addFooter()
+
+	if err := g.output(w); err != nil {
+		return fmt.Errorf("failed to generate PDF: %w", err)
+	}
+
+	return nil
+}
+
+// addCountBox draws a bordered box with a head count and its change since
+// the previous year
+func (g *Generator) addCountBox(x, y, width float64, label string, count models.DashboardCount) {
+	g.pdf.Rect(x, y, width, dashboardBoxHeight, "D")
+
+	g.pdf.SetXY(x, y+1)
+	g.setFont(FontSpec{Family: "Arial", Style: "B", Size: 9})
+	g.cellFormat(width, 5, label+" joined this year", "", 0, "C", false)
+
+	g.pdf.SetXY(x, y+6)
+	g.setFont(FontSpec{Family: "Arial", Style: "B", Size: 16})
+	g.cellFormat(width, 8, fmt.Sprintf("%d", count.CurrentYear), "", 0, "C", false)
+
+	g.pdf.SetXY(x, y+14)
+	g.setFont(FontSpec{Family: "Arial", Style: "", Size: 8})
+	g.cellFormat(width, 5, fmt.Sprintf("%+d (%+.1f%%) vs last year", count.Change, count.PercentChange), "", 0, "C", false)
+}
@@ -0,0 +1,197 @@
+package pdf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// FontManifestName is the name of the manifest describing a fonts directory
+const FontManifestName = "fonts.json"
+
+// defaultFontFamily is the name under which the default UTF-8 font is registered
+const defaultFontFamily = "report"
+
+// fontStyles lists the gofpdf style strings registered for every font family
+var fontStyles = []string{"", "B", "I", "BI"}
+
+// fontFiles names the TrueType files of a font family by style, relative to
+// the fonts directory or absolute. Only Regular is required; missing styles
+// reuse the regular face.
+type fontFiles struct {
+	Regular    string `json:"regular"`
+	Bold       string `json:"bold"`
+	Italic     string `json:"italic"`
+	BoldItalic string `json:"boldItalic"`
+}
+
+// fallbackFontFiles is a font used for the listed Unicode scripts
+type fallbackFontFiles struct {
+	fontFiles
+	// Scripts are Unicode script names as in unicode.Scripts, e.g. "Devanagari" or "Han"
+	Scripts []string `json:"scripts"`
+}
+
+// fontManifest is the content of fonts.json
+type fontManifest struct {
+	Default   fontFiles           `json:"default"`
+	Fallbacks []fallbackFontFiles `json:"fallbacks"`
+}
+
+// fontFamily is a loaded TrueType family and the scripts it is used for
+type fontFamily struct {
+	name    string
+	scripts []*unicode.RangeTable
+	// faces holds the font file contents keyed by gofpdf style
+	faces map[string][]byte
+}
+
+// FontSet holds the UTF-8 fonts embedded in reports: a default family and
+// fallback families selected per Unicode script
+type FontSet struct {
+	families []*fontFamily
+}
+
+// textRun is a piece of text drawn with a single font family
+type textRun struct {
+	Text   string `json:"text"`
+	Family string `json:"family"`
+}
+
+// LoadFonts loads the fonts described by the fonts.json manifest in dir.
+// It returns nil without error when dir has no manifest, in which case
+// reports use the core PDF fonts.
+func LoadFonts(dir string) (*FontSet, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, FontManifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font manifest: %w", err)
+	}
+
+	var manifest fontManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid font manifest: %w", err)
+	}
+
+	defaultFamily, err := loadFontFamily(dir, defaultFontFamily, manifest.Default)
+	if err != nil {
+		return nil, fmt.Errorf("default font: %w", err)
+	}
+	set := &FontSet{families: []*fontFamily{defaultFamily}}
+
+	for _, fallback := range manifest.Fallbacks {
+		if len(fallback.Scripts) == 0 {
+			return nil, fmt.Errorf("fallback font %s: at least one script is required", fallback.Regular)
+		}
+
+		name := defaultFontFamily + "-" + strings.ToLower(fallback.Scripts[0])
+		family, err := loadFontFamily(dir, name, fallback.fontFiles)
+		if err != nil {
+			return nil, fmt.Errorf("fallback font for %s: %w", fallback.Scripts[0], err)
+		}
+
+		for _, script := range fallback.Scripts {
+			table, ok := unicode.Scripts[script]
+			if !ok {
+				return nil, fmt.Errorf("fallback font %s: unknown script %q", fallback.Regular, script)
+			}
+			family.scripts = append(family.scripts, table)
+		}
+		set.families = append(set.families, family)
+	}
+
+	return set, nil
+}
+
+// loadFontFamily reads the font files of a family
+func loadFontFamily(dir, name string, files fontFiles) (*fontFamily, error) {
+	if files.Regular == "" {
+		return nil, errors.New("a regular font file is required")
+	}
+
+	regular, err := os.ReadFile(fontPath(dir, files.Regular))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font: %w", err)
+	}
+
+	family := &fontFamily{name: name, faces: map[string][]byte{}}
+	for style, file := range map[string]string{"": files.Regular, "B": files.Bold, "I": files.Italic, "BI": files.BoldItalic} {
+		if file == "" {
+			family.faces[style] = regular
+			continue
+		}
+		face, err := os.ReadFile(fontPath(dir, file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read font: %w", err)
+		}
+		family.faces[style] = face
+	}
+
+	return family, nil
+}
+
+// fontPath resolves a font file named in the manifest; relative paths are
+// relative to the fonts directory
+func fontPath(dir, file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(dir, file)
+}
+
+// familyFor returns the family used to draw r, or nil for characters shared
+// by all scripts (spaces, digits, punctuation, combining marks) which are
+// drawn with the font of the surrounding text
+func (fs *FontSet) familyFor(r rune) *fontFamily {
+	for _, family := range fs.families[1:] {
+		for _, table := range family.scripts {
+			if unicode.Is(table, r) {
+				return family
+			}
+		}
+	}
+	if unicode.In(r, unicode.Common, unicode.Inherited) {
+		return nil
+	}
+	return fs.families[0]
+}
+
+// splitRuns splits text into runs that can each be drawn with a single font
+func (fs *FontSet) splitRuns(text string) []textRun {
+	var runs []textRun
+	var current strings.Builder
+	var family *fontFamily
+
+	for _, r := range text {
+		next := fs.familyFor(r)
+		if next == nil {
+			// Shared characters stay with the current run, or start one in the default font
+			next = family
+			if next == nil {
+				next = fs.families[0]
+			}
+		}
+
+		if family != nil && next != family {
+			runs = append(runs, textRun{Text: current.String(), Family: family.name})
+			current.Reset()
+		}
+		family = next
+		current.WriteRune(r)
+	}
+
+	if current.Len() > 0 {
+		runs = append(runs, textRun{Text: current.String(), Family: family.name})
+	}
+	return runs
+}
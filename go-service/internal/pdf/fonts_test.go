@@ -0,0 +1,319 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf16"
+
+	"go-service/pkg/models"
+)
+
+// updateGolden rewrites golden files instead of comparing against them
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+// multilingualFixtures is the student fixture file also served by the mock Node.js API
+const multilingualFixtures = "../../testdata/multilingual_students.json"
+
+// systemFontDir is where DejaVu fonts are commonly installed
+const systemFontDir = "/usr/share/fonts/truetype/dejavu"
+
+// loadMultilingualStudents reads the shared multilingual student fixtures
+func loadMultilingualStudents(t *testing.T) []models.Student {
+	t.Helper()
+
+	data, err := os.ReadFile(multilingualFixtures)
+	if err != nil {
+		t.Fatalf("Failed to read fixtures: %v", err)
+	}
+
+	var students []models.Student
+	if err := json.Unmarshal(data, &students); err != nil {
+		t.Fatalf("Failed to parse fixtures: %v", err)
+	}
+	return students
+}
+
+// scriptFontSet returns a font set without font data, enough to split text into runs
+func scriptFontSet() *FontSet {
+	family := func(name string, scripts ...*unicode.RangeTable) *fontFamily {
+		return &fontFamily{name: name, scripts: scripts}
+	}
+	return &FontSet{families: []*fontFamily{
+		family(defaultFontFamily),
+		family("report-devanagari", unicode.Devanagari),
+		family("report-arabic", unicode.Arabic),
+		family("report-han", unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul),
+	}}
+}
+
+// TestSplitRunsGolden tests script run segmentation of the multilingual fixtures
+func TestSplitRunsGolden(t *testing.T) {
+	fonts := scriptFontSet()
+
+	runs := map[string][]textRun{}
+	for _, student := range loadMultilingualStudents(t) {
+		for field, text := range map[string]string{
+			"name":             student.Name,
+			"fatherName":       student.FatherName,
+			"currentAddress":   student.CurrentAddress,
+			"permanentAddress": student.PermanentAddress,
+			"reporterName":     student.ReporterName,
+		} {
+			runs[student.Email+" "+field] = fonts.splitRuns(text)
+		}
+	}
+
+	got, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to encode runs: %v", err)
+	}
+
+	golden := filepath.Join("testdata", "script_runs.golden.json")
+	if *updateGolden {
+		if err := os.WriteFile(golden, append(got, '\n'), 0o644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(got), bytes.TrimSpace(want)) {
+		t.Errorf("Script runs differ from %s (run with -update to accept):\n%s", golden, got)
+	}
+}
+
+// TestSplitRunsKeepsSharedCharacters tests that spaces, digits and
+// punctuation stay with the surrounding script
+func TestSplitRunsKeepsSharedCharacters(t *testing.T) {
+	runs := scriptFontSet().splitRuns("12, गांधी मार्ग (Jaipur)")
+
+	want := []textRun{
+		{Text: "12, ", Family: "report"},
+		{Text: "गांधी मार्ग (", Family: "report-devanagari"},
+		{Text: "Jaipur)", Family: "report"},
+	}
+	if len(runs) != len(want) {
+		t.Fatalf("Expected %d runs, got %+v", len(want), runs)
+	}
+	for i := range want {
+		if runs[i] != want[i] {
+			t.Errorf("Run %d: expected %+v, got %+v", i, want[i], runs[i])
+		}
+	}
+
+	if runs := scriptFontSet().splitRuns(""); len(runs) != 0 {
+		t.Errorf("Expected no runs for empty text, got %+v", runs)
+	}
+}
+
+// TestLoadFonts tests loading a fonts directory manifest
+func TestLoadFonts(t *testing.T) {
+	dir := t.TempDir()
+
+	// Without a manifest the core fonts are used
+	fonts, err := LoadFonts(dir)
+	if err != nil || fonts != nil {
+		t.Fatalf("Expected no font set without a manifest, got %v, %v", fonts, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "regular.ttf"), []byte("font"), 0o644); err != nil {
+		t.Fatalf("Failed to write font: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		manifest string
+		errorMsg string
+	}{
+		{"missing_file", `{"default":{"regular":"missing.ttf"}}`, "failed to read font"},
+		{"missing_regular", `{"default":{"bold":"regular.ttf"}}`, "regular font file is required"},
+		{"unknown_script", `{"default":{"regular":"regular.ttf"},"fallbacks":[{"scripts":["Klingon"],"regular":"regular.ttf"}]}`, "unknown script"},
+		{"no_scripts", `{"default":{"regular":"regular.ttf"},"fallbacks":[{"regular":"regular.ttf"}]}`, "at least one script"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := os.WriteFile(filepath.Join(dir, FontManifestName), []byte(tc.manifest), 0o644); err != nil {
+				t.Fatalf("Failed to write manifest: %v", err)
+			}
+			_, err := LoadFonts(dir)
+			if err == nil || !strings.Contains(err.Error(), tc.errorMsg) {
+				t.Errorf("Expected error containing %q, got %v", tc.errorMsg, err)
+			}
+		})
+	}
+
+	manifest := `{"default":{"regular":"regular.ttf"},"fallbacks":[{"scripts":["Han","Hiragana"],"regular":"regular.ttf"}]}`
+	if err := os.WriteFile(filepath.Join(dir, FontManifestName), []byte(manifest), 0o644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	fonts, err = LoadFonts(dir)
+	if err != nil {
+		t.Fatalf("Failed to load fonts: %v", err)
+	}
+	if len(fonts.families) != 2 || fonts.families[1].name != "report-han" || len(fonts.families[1].scripts) != 2 {
+		t.Errorf("Unexpected font families: %+v", fonts.families)
+	}
+	if fonts.familyFor('さ') != fonts.families[1] {
+		t.Error("Expected Hiragana to use the Han fallback font")
+	}
+}
+
+// systemFonts loads the DejaVu fonts, which cover Latin and Arabic, skipping
+// the test when they are not installed
+func systemFonts(t *testing.T) *FontSet {
+	t.Helper()
+	if _, err := os.Stat(filepath.Join(systemFontDir, "DejaVuSans.ttf")); err != nil {
+		t.Skipf("DejaVu fonts not installed in %s", systemFontDir)
+	}
+
+	dir := t.TempDir()
+	manifest := `{
+		"default": {"regular": "` + systemFontDir + `/DejaVuSans.ttf", "bold": "` + systemFontDir + `/DejaVuSans-Bold.ttf"},
+		"fallbacks": [{"scripts": ["Arabic"], "regular": "` + systemFontDir + `/DejaVuSans.ttf"}]
+	}`
+	if err := os.WriteFile(filepath.Join(dir, FontManifestName), []byte(manifest), 0o644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+
+	fonts, err := LoadFonts(dir)
+	if err != nil {
+		t.Fatalf("Failed to load fonts: %v", err)
+	}
+	return fonts
+}
+
+// TestGenerateMultilingualReports tests rendering the multilingual fixtures
+// with embedded TrueType fonts
+func TestGenerateMultilingualReports(t *testing.T) {
+	fonts := systemFonts(t)
+
+	students := loadMultilingualStudents(t)
+	for i := range students {
+		student := &students[i]
+		t.Run(student.Email, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := NewGenerator(WithFonts(fonts)).GenerateStudentReport(&buf, student); err != nil {
+				t.Fatalf("Expected PDF generation to succeed, got error: %v", err)
+			}
+			pdfBytes := buf.Bytes()
+			if !bytes.HasPrefix(pdfBytes, []byte("%PDF")) {
+				t.Error("Generated content does not appear to be a valid PDF")
+			}
+			if !bytes.Contains(pdfBytes, []byte("/FontFile2")) {
+				t.Error("Expected the TrueType font to be embedded")
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := NewGenerator(WithFonts(fonts)).GenerateClassRoster(&buf, "Grade 8", "C", students); err != nil {
+		t.Fatalf("Expected roster generation to succeed, got error: %v", err)
+	}
+	pdfBytes := buf.Bytes()
+	if !bytes.HasPrefix(pdfBytes, []byte("%PDF")) {
+		t.Error("Generated roster does not appear to be a valid PDF")
+	}
+}
+
+// drawnTextPattern matches the text shown by a content stream, which the PDF
+// library writes as a literal string of UTF-16 characters for UTF-8 fonts
+var drawnTextPattern = regexp.MustCompile(`\(((?:\\.|[^\\)])*)\)\s*Tj`)
+
+// drawnText returns the non-ASCII strings shown by the content streams of an
+// uncompressed document, as the characters the glyphs are looked up by
+func drawnText(document []byte) []string {
+	var texts []string
+	for _, match := range drawnTextPattern.FindAllSubmatch(document, -1) {
+		var raw []byte
+		for i := 0; i < len(match[1]); i++ {
+			if match[1][i] == '\\' {
+				i++
+				if match[1][i] == 'r' {
+					raw = append(raw, '\r')
+					continue
+				}
+			}
+			raw = append(raw, match[1][i])
+		}
+
+		units := make([]uint16, len(raw)/2)
+		for i := range units {
+			units[i] = uint16(raw[2*i])<<8 | uint16(raw[2*i+1])
+		}
+		text := string(utf16.Decode(units))
+		for _, r := range text {
+			if r > unicode.MaxASCII {
+				texts = append(texts, text)
+				break
+			}
+		}
+	}
+	return texts
+}
+
+// TestDrawnTextGolden tests the text drawn for the multilingual fixtures: the
+// characters, after shaping, in the order they appear on the page
+func TestDrawnTextGolden(t *testing.T) {
+	fonts := systemFonts(t)
+
+	drawn := map[string][]string{}
+	students := loadMultilingualStudents(t)
+	for i := range students {
+		generator := NewGenerator(WithFonts(fonts))
+		generator.pdf.SetCompression(false)
+		var buf bytes.Buffer
+		if err := generator.GenerateStudentReport(&buf, &students[i]); err != nil {
+			t.Fatalf("Expected PDF generation to succeed, got error: %v", err)
+		}
+		drawn[students[i].Email] = drawnText(buf.Bytes())
+	}
+
+	got, err := json.MarshalIndent(drawn, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to encode drawn text: %v", err)
+	}
+
+	golden := filepath.Join("testdata", "drawn_text.golden.json")
+	if *updateGolden {
+		if err := os.WriteFile(golden, append(got, '\n'), 0o644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(got), bytes.TrimSpace(want)) {
+		t.Errorf("Drawn text differs from %s (run with -update to accept):\n%s", golden, got)
+	}
+}
+
+// TestCoreFontsTranslateLatin1 tests that accented text is encoded for the
+// core fonts instead of being written as raw UTF-8
+func TestCoreFontsTranslateLatin1(t *testing.T) {
+	generator := NewGenerator()
+	generator.pdf.SetCompression(false)
+
+	var buf bytes.Buffer
+	if err := generator.GenerateStudentReport(&buf, &models.Student{Name: "José Müller"}); err != nil {
+		t.Fatalf("Expected PDF generation to succeed, got error: %v", err)
+	}
+	pdfBytes := buf.Bytes()
+
+	if !bytes.Contains(pdfBytes, []byte("Jos\xe9 M\xfcller")) {
+		t.Error("Expected the name to be encoded as cp1252")
+	}
+	if bytes.Contains(pdfBytes, []byte("José")) {
+		t.Error("Expected no raw UTF-8 text in the content stream")
+	}
+}
@@ -1,131 +1,198 @@
 package pdf
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"time"
 
+	"go-service/internal/export"
 	"go-service/pkg/models"
 
 	"github.com/jung-kurt/gofpdf"
 )
 
-// Generator handles PDF generation for student reports
+// Generator handles PDF generation for student and staff reports. The
+// Generate methods write the finished document to w as it is output rather
+// than returning it, so it is not copied again on its way to the client.
 type Generator struct {
-	pdf *gofpdf.Fpdf
+	pdf      *gofpdf.Fpdf
+	template *Template
+	// fonts holds the embedded UTF-8 fonts; nil to use the core fonts
+	fonts *FontSet
+	// font is the font selected with setFont
+	font FontSpec
+	// translate encodes text for the core fonts
+	translate func(string) string
+	// branding is the school identity applied to the report
+	branding *Branding
+	// pageNumbers is set by reports that number their pages
+	pageNumbers bool
+	// verification is printed on every page of a verifiable report
+	verification *Verification
+	// draft overlays the draft watermark on every page
+	draft bool
+	// serial is the serial number of an official document
+	serial string
+	// firstPage is the number of pages drawn in earlier parts of a report
+	// drawn in parts, which continue the page numbers
+	firstPage int
+	// protection encrypts the document when it is set
+	protection *export.Protection
 }
 
-// NewGenerator creates a new PDF generator
-func NewGenerator() *Generator {
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	return &Generator{
-		pdf: pdf,
+// Option configures a Generator
+type Option func(*Generator)
+
+// WithTemplate selects the layout used for student or staff reports
+func WithTemplate(tmpl *Template) Option {
+	return func(g *Generator) {
+		if tmpl != nil {
+			g.template = tmpl
+		}
 	}
 }
 
-// GenerateStudentReport creates a PDF report for a student
-func (g *Generator) GenerateStudentReport(student *models.Student) ([]byte, error) {
-	// Initialize PDF
-	g.pdf.AddPage()
-	g.pdf.SetFont("Arial", "B", 16)
-
-	// Title
-	g.pdf.Cell(0, 10, "STUDENT REPORT")
-	g.pdf.Ln(20)
+// WithFonts embeds the UTF-8 fonts of fonts instead of using the core PDF
+// fonts, which can only render Latin-1 text
+func WithFonts(fonts *FontSet) Option {
+	return func(g *Generator) {
+		g.fonts = fonts
+	}
+}
 
-	// School Header
-	g.pdf.SetFont("Arial", "B", 14)
-	g.pdf.Cell(0, 8, "School Management System")
-	g.pdf.Ln(15)
-
-	// Student Basic Information
-	g.addSectionHeader("STUDENT INFORMATION")
-	g.addField("Student ID", fmt.Sprintf("%d", student.ID))
-	g.addField("Full Name", student.Name)
-	g.addField("Email", student.Email)
-	g.addField("Phone", student.Phone)
-	g.addField("Gender", student.Gender)
-	g.addField("Date of Birth", g.formatDate(student.DOB))
-	
-	g.pdf.Ln(10)
+// WithBranding applies a school's name, letterhead, colors and disclaimer
+func WithBranding(branding *Branding) Option {
+	return func(g *Generator) {
+		if branding != nil {
+			g.branding = branding
+		}
+	}
+}
 
-	// Academic Information
-	g.addSectionHeader("ACADEMIC INFORMATION")
-	g.addField("Class", student.Class)
-	g.addField("Section", student.Section)
-	g.addField("Roll Number", fmt.Sprintf("%d", student.Roll))
-	g.addField("Admission Date", g.formatDate(student.AdmissionDate))
-	g.addField("System Access", g.formatBool(student.SystemAccess))
+// NewGenerator creates a new PDF generator
+func NewGenerator(opts ...Option) *Generator {
+	g := &Generator{
+		template: defaultTemplate,
+		branding: DefaultBranding(),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	g.newDocument()
+	return g
+}
 
-	g.pdf.Ln(10)
+// newDocument starts a new document set up with the generator's options.
+// Reports drawn in parts start one for every part.
+func (g *Generator) newDocument() {
+	g.pdf = gofpdf.New("P", "mm", "A4", "")
+	if g.fonts != nil {
+		g.registerFonts()
+	} else {
+		g.translate = g.pdf.UnicodeTranslatorFromDescriptor("")
+	}
+	g.applyBranding()
+	g.applyVerification()
+	g.pdf.SetFooterFunc(g.addPageFooter)
+}
 
-	// Family Information
-	g.addSectionHeader("FAMILY INFORMATION")
-	g.addField("Father's Name", student.FatherName)
-	g.addField("Father's Phone", student.FatherPhone)
-	g.addField("Mother's Name", student.MotherName)
-	g.addField("Mother's Phone", student.MotherPhone)
-	g.addField("Guardian's Name", student.GuardianName)
-	g.addField("Guardian's Phone", student.GuardianPhone)
-	g.addField("Relation to Guardian", student.RelationOfGuardian)
+// GenerateStudentReport writes a PDF report for a student laid out by the
+// generator's template
+func (g *Generator) GenerateStudentReport(w io.Writer, student *models.Student) error {
+	return g.generateProfile(w, TemplateKindStudent, defaultTemplate, student)
+}
 
-	g.pdf.Ln(10)
+// GenerateStaffReport writes an HR profile PDF for a staff member laid out by
+// the generator's template, or the built-in staff layout if the generator's
+// template is not a staff template
+func (g *Generator) GenerateStaffReport(w io.Writer, staff *models.Staff) error {
+	return g.generateProfile(w, TemplateKindStaff, defaultStaffTemplate, staff)
+}
 
-	// Address Information
-	g.addSectionHeader("ADDRESS INFORMATION")
-	g.addField("Current Address", student.CurrentAddress)
-	g.addField("Permanent Address", student.PermanentAddress)
+// generateProfile renders a single-record report of the given template kind,
+// falling back to fallback when the generator's template is of another kind
+func (g *Generator) generateProfile(w io.Writer, kind string, fallback *Template, record interface{}) error {
+	if g.template.Kind != kind {
+		g.template = fallback
+	}
 
-	g.pdf.Ln(10)
+	values, err := g.template.Values(record)
+	if err != nil {
+		return err
+	}
 
-	// Additional Information
-	g.addSectionHeader("ADDITIONAL INFORMATION")
-	g.addField("Class Teacher/Reporter", student.ReporterName)
+	// Initialize PDF
+	g.pdf.AddPage()
+	g.renderTemplate(values)
 
 	// Footer
 	g.addFooter()
 
-	// Get PDF as bytes
-	var buf []byte
-	var err error
-	
-	// Use OutputFileAndClose with a temporary approach
-	buf, err = g.getPDFBytes()
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate PDF: %w", err)
+	if err := g.output(w); err != nil {
+		return fmt.Errorf("failed to generate PDF: %w", err)
+	}
+
+	return nil
+}
+
+// output writes the finished document to w, encrypted when it is protected
+func (g *Generator) output(w io.Writer) error {
+	return g.protected(w, g.pdf.Output)
+}
+
+// protected calls write to write the document to w. A protected document is
+// encrypted as a whole, so it is written to a buffer first.
+func (g *Generator) protected(w io.Writer, write func(io.Writer) error) error {
+	if g.protection == nil {
+		return write(w)
 	}
 
-	return buf, nil
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return err
+	}
+	encrypted, err := g.protect(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(encrypted)
+	return err
 }
 
 // addSectionHeader adds a section header to the PDF
 func (g *Generator) addSectionHeader(title string) {
-	g.pdf.SetFont("Arial", "B", 12)
-	g.pdf.SetFillColor(230, 230, 230)
-	g.pdf.CellFormat(0, 8, title, "1", 1, "L", true, 0, "")
-	g.pdf.Ln(5)
+	g.setFont(g.template.Fonts.SectionHeader)
+	g.setAccentFill()
+	g.cellFormat(0, g.template.Spacing.SectionHeaderHeight, title, "1", 1, "L", true)
+	g.resetColors()
+	g.pdf.Ln(g.template.Spacing.AfterSectionHeader)
 }
 
 // addField adds a field with label and value to the PDF
 func (g *Generator) addField(label, value string) {
-	g.pdf.SetFont("Arial", "B", 10)
-	g.pdf.Cell(50, 6, label+":")
+	spacing := g.template.Spacing
+
+	g.setFont(g.template.Fonts.Label)
+	g.cell(spacing.LabelWidth, spacing.LineHeight, label+":")
 	
-	g.pdf.SetFont("Arial", "", 10)
-	g.pdf.Cell(0, 6, value)
-	g.pdf.Ln(8)
+	g.setFont(g.template.Fonts.Value)
+	g.cell(0, spacing.LineHeight, value)
+	g.pdf.Ln(spacing.FieldAdvance)
 }
 
 // addFooter adds a footer to the PDF
 func (g *Generator) addFooter() {
 	g.pdf.Ln(20)
-	g.pdf.SetFont("Arial", "I", 8)
-	g.pdf.Cell(0, 5, "Generated on: "+time.Now().Format("January 2, 2006 at 3:04 PM"))
+	g.setFont(FontSpec{Family: "Arial", Style: "I", Size: 8})
+	g.cell(0, 5, "Generated on: "+time.Now().Format("January 2, 2006 at 3:04 PM"))
 	g.pdf.Ln(5)
-	g.pdf.Cell(0, 5, "Report generated by Go PDF Report Service")
+	g.cell(0, 5, "Report generated by Go PDF Report Service")
+	g.addDisclaimer()
 }
 
 // formatDate formats a time.Time to a readable string
-func (g *Generator) formatDate(t time.Time) string {
+func formatDate(t time.Time) string {
 	if t.IsZero() {
 		return "Not specified"
 	}
@@ -133,35 +200,9 @@ func (g *Generator) formatDate(t time.Time) string {
 }
 
 // formatBool formats a boolean to a readable string
-func (g *Generator) formatBool(b bool) string {
+func formatBool(b bool) string {
 	if b {
 		return "Yes"
 	}
 	return "No"
 }
-
-// getPDFBytes returns the PDF as a byte slice
-func (g *Generator) getPDFBytes() ([]byte, error) {
-	// Create a buffer to capture the PDF output
-	var buf []byte
-	
-	// This is a simplified approach - in a real implementation,
-	// you might want to use a proper buffer or temporary file
-	err := g.pdf.Output(&PDFBuffer{&buf})
-	if err != nil {
-		return nil, err
-	}
-	
-	return buf, nil
-}
-
-// PDFBuffer implements io.Writer for capturing PDF output
-type PDFBuffer struct {
-	data *[]byte
-}
-
-// Write implements io.Writer interface
-func (pb *PDFBuffer) Write(p []byte) (n int, err error) {
-	*pb.data = append(*pb.data, p...)
-	return len(p), nil
-} 
\ No newline at end of file
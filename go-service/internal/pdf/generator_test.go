@@ -1,6 +1,9 @@
 package pdf
 
 import (
+	"bytes"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -49,10 +52,11 @@ func TestGenerateStudentReport(t *testing.T) {
 		SystemAccess:     true,
 	}
 	
-	pdfBytes, err := generator.GenerateStudentReport(student)
-	if err != nil {
+	var buf bytes.Buffer
+	if err := generator.GenerateStudentReport(&buf, student); err != nil {
 		t.Errorf("Expected PDF generation to succeed, got error: %v", err)
 	}
+	pdfBytes := buf.Bytes()
 	
 	if len(pdfBytes) == 0 {
 		t.Error("Expected PDF bytes to be generated, got empty slice")
@@ -62,4 +66,236 @@ func TestGenerateStudentReport(t *testing.T) {
 	if len(pdfBytes) < 4 || string(pdfBytes[:4]) != "%PDF" {
 		t.Error("Generated content does not appear to be a valid PDF")
 	}
-} 
\ No newline at end of file
+} 
+
+// TestGenerateClassRoster tests that a large roster spans several pages
+func TestGenerateClassRoster(t *testing.T) {
+	generator := NewGenerator()
+
+	students := make([]models.Student, 120)
+	for i := range students {
+		students[i] = models.Student{
+			ID:                 i + 1,
+			Name:               fmt.Sprintf("Student %03d", i+1),
+			Gender:             "Female",
+			Phone:              "555-0000",
+			Roll:               i + 1,
+			GuardianName:       "A Guardian With A Remarkably Long Name That Needs Truncating",
+			GuardianPhone:      "555-9999",
+			RelationOfGuardian: "Mother",
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := generator.GenerateClassRoster(&buf, "Grade 10", "A", students); err != nil {
+		t.Fatalf("Expected roster generation to succeed, got error: %v", err)
+	}
+	pdfBytes := buf.Bytes()
+
+	if len(pdfBytes) < 4 || string(pdfBytes[:4]) != "%PDF" {
+		t.Error("Generated content does not appear to be a valid PDF")
+	}
+
+	if pages := generator.pdf.PageNo(); pages < 3 {
+		t.Errorf("Expected roster of 120 students to span at least 3 pages, got %d", pages)
+	}
+}
+
+// TestGenerateEmptyClassRoster tests that an empty roster still renders
+func TestGenerateEmptyClassRoster(t *testing.T) {
+	generator := NewGenerator()
+
+	var buf bytes.Buffer
+	if err := generator.GenerateClassRoster(&buf, "Grade 1", "Z", nil); err != nil {
+		t.Fatalf("Expected roster generation to succeed, got error: %v", err)
+	}
+	pdfBytes := buf.Bytes()
+
+	if len(pdfBytes) < 4 || string(pdfBytes[:4]) != "%PDF" {
+		t.Error("Generated content does not appear to be a valid PDF")
+	}
+}
+
+// TestGuardianContact tests formatting of the roster guardian column
+func TestGuardianContact(t *testing.T) {
+	testCases := []struct {
+		student  models.Student
+		expected string
+	}{
+		{models.Student{GuardianName: "Jane", RelationOfGuardian: "Mother", GuardianPhone: "555-1"}, "Jane (Mother) - 555-1"},
+		{models.Student{GuardianName: "Jane", GuardianPhone: "555-1"}, "Jane - 555-1"},
+		{models.Student{GuardianPhone: "555-1"}, "555-1"},
+		{models.Student{}, ""},
+	}
+
+	for _, tc := range testCases {
+		if got := guardianContact(&tc.student); got != tc.expected {
+			t.Errorf("Expected %q, got %q", tc.expected, got)
+		}
+	}
+}
+
+// TestGenerateLeaveReport tests that a leave report with a long history
+// renders both tables
+func TestGenerateLeaveReport(t *testing.T) {
+	generator := NewGenerator()
+	year := models.NewAcademicYear(2024, time.April)
+
+	requests := make([]models.LeaveRequest, 60)
+	for i := range requests {
+		from := year.Start.AddDate(0, 0, i*5)
+		requests[i] = models.LeaveRequest{
+			ID:       i + 1,
+			Policy:   "Sick Leave",
+			PolicyID: 1,
+			From:     from,
+			To:       from.AddDate(0, 0, 1),
+			StatusID: models.LeaveStatusApproved,
+			Status:   "Approved",
+			Approver: "Seymour Skinner",
+		}
+	}
+	summaries := models.SummarizeLeave(requests, []models.LeavePolicy{{ID: 1, Name: "Sick Leave"}}, year)
+
+	var buf bytes.Buffer
+	if err := generator.GenerateLeaveReport(&buf, "Edna Krabappel", year, requests, summaries); err != nil {
+		t.Fatalf("Expected leave report generation to succeed, got error: %v", err)
+	}
+	pdfBytes := buf.Bytes()
+
+	if len(pdfBytes) < 4 || string(pdfBytes[:4]) != "%PDF" {
+		t.Error("Generated content does not appear to be a valid PDF")
+	}
+
+	if pages := generator.pdf.PageNo(); pages < 2 {
+		t.Errorf("Expected leave report of 60 requests to span at least 2 pages, got %d", pages)
+	}
+}
+
+// TestGenerateLeaveDigest tests that pending leave digests render with and
+// without requests
+func TestGenerateLeaveDigest(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, time.May, d, 0, 0, 0, 0, time.UTC) }
+	requests := []models.LeaveRequest{
+		{ID: 1, User: "Edna Krabappel", UserID: 21, Department: "Primary", ReporterID: 1, Policy: "Sick Leave", From: day(6), To: day(8), Submitted: day(1)},
+		{ID: 2, User: "Elizabeth Hoover", UserID: 22, Department: "Primary", ReporterID: 1, Policy: "Annual Leave", From: day(8), To: day(10), Submitted: day(2)},
+	}
+
+	for name, digest := range map[string]models.LeaveDigest{
+		"with_requests": models.BuildLeaveDigest(requests, 1, day(3)),
+		"empty":         models.BuildLeaveDigest(nil, 1, day(3)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := NewGenerator().GenerateLeaveDigest(&buf, digest); err != nil {
+				t.Fatalf("Expected digest generation to succeed, got error: %v", err)
+			}
+			pdfBytes := buf.Bytes()
+
+			if len(pdfBytes) < 4 || string(pdfBytes[:4]) != "%PDF" {
+				t.Error("Generated content does not appear to be a valid PDF")
+			}
+		})
+	}
+}
+
+// TestGenerateNoticeBulletin tests that notice blocks flow onto further pages
+func TestGenerateNoticeBulletin(t *testing.T) {
+	generator := NewGenerator()
+	week := time.Date(2024, time.May, 6, 0, 0, 0, 0, time.UTC)
+
+	bulletin := models.NoticeBulletin{From: week, To: week.AddDate(0, 0, 6), Audience: "Teacher"}
+	for i := 0; i < 20; i++ {
+		bulletin.Notices = append(bulletin.Notices, models.BulletinNotice{
+			Notice: models.Notice{
+				ID:           i + 1,
+				Title:        fmt.Sprintf("Notice %d", i+1),
+				Description:  strings.Repeat("The school will be closed for the staff development day. ", 6),
+				Author:       "Seymour Skinner",
+				CreatedDate:  week,
+				ReviewerName: "Gary Chalmers",
+				ReviewedDate: week.AddDate(0, 0, 1),
+			},
+			Recipients: "Everyone",
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := generator.GenerateNoticeBulletin(&buf, bulletin); err != nil {
+		t.Fatalf("Expected bulletin generation to succeed, got error: %v", err)
+	}
+	pdfBytes := buf.Bytes()
+
+	if len(pdfBytes) < 4 || string(pdfBytes[:4]) != "%PDF" {
+		t.Error("Generated content does not appear to be a valid PDF")
+	}
+
+	if pages := generator.pdf.PageNo(); pages < 3 {
+		t.Errorf("Expected 20 notices to span at least 3 pages, got %d", pages)
+	}
+}
+
+// TestGenerateDashboardSummary tests that a full dashboard with a letterhead
+// and disclaimer still fits on one page
+func TestGenerateDashboardSummary(t *testing.T) {
+	branding := DefaultBranding()
+	branding.Address = []string{"742 Evergreen Terrace", "Springfield"}
+	branding.FooterDisclaimer = strings.Repeat("This summary is generated from live data and may change. ", 3)
+	generator := NewGenerator(WithBranding(branding))
+
+	day := func(d int) models.Date { return models.Date{Time: time.Date(2024, time.May, d, 0, 0, 0, 0, time.UTC)} }
+	summary := &models.DashboardSummary{
+		Students: models.DashboardCount{CurrentYear: 48, Change: 8, PercentChange: 20},
+		Teachers: models.DashboardCount{CurrentYear: 3, Change: -1, PercentChange: -25},
+	}
+	for i := 0; i < 8; i++ {
+		summary.LeavePolicies = append(summary.LeavePolicies, models.DashboardLeavePolicy{ID: i + 1, Name: fmt.Sprintf("Policy %d", i+1), TotalDaysUsed: "12"})
+		summary.OneMonthLeave = append(summary.OneMonthLeave, models.UpcomingLeave{User: "Edna Krabappel", LeaveType: "Annual Leave", FromDate: day(i + 1), ToDate: day(i + 3)})
+		summary.Notices = append(summary.Notices, models.DashboardNotice{Title: strings.Repeat("Very long notice title ", 5), Author: "Seymour Skinner", CreatedDate: day(i + 1), Status: "Approved"})
+		summary.LeaveHistory = append(summary.LeaveHistory, models.DashboardLeave{Status: "Approved"})
+	}
+
+	var buf bytes.Buffer
+	if err := generator.GenerateDashboardSummary(&buf, summary, time.Date(2024, time.May, 10, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Expected dashboard generation to succeed, got error: %v", err)
+	}
+	pdfBytes := buf.Bytes()
+
+	if len(pdfBytes) < 4 || string(pdfBytes[:4]) != "%PDF" {
+		t.Error("Generated content does not appear to be a valid PDF")
+	}
+
+	if pages := generator.pdf.PageNo(); pages != 1 {
+		t.Errorf("Expected the dashboard summary to fit on one page, got %d", pages)
+	}
+}
+
+// TestGenerateClassTeacherMatrix tests class teacher matrices with and
+// without flagged sections
+func TestGenerateClassTeacherMatrix(t *testing.T) {
+	classes := []models.Class{{Name: "Grade 8", Sections: "A,B,C"}, {Name: "Grade 9", Sections: "A"}}
+	sections := []models.Section{{Name: "A"}, {Name: "B"}, {Name: "C"}}
+	assignments := []models.ClassTeacher{
+		{ID: 1, Class: "Grade 8", Section: "A", Teacher: "Edna Krabappel"},
+		{ID: 2, Class: "Grade 9", Section: "A", Teacher: "Edna Krabappel"},
+		{ID: 3, Class: "Grade 8", Section: "C", Teacher: "Elizabeth Hoover"},
+		{ID: 4, Section: "B", Teacher: "Dewey Largo"},
+	}
+
+	for name, matrix := range map[string]models.ClassTeacherMatrix{
+		"flagged": models.BuildClassTeacherMatrix(classes, sections, assignments),
+		"empty":   models.BuildClassTeacherMatrix(nil, nil, nil),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := NewGenerator().GenerateClassTeacherMatrix(&buf, matrix); err != nil {
+				t.Fatalf("Expected matrix generation to succeed, got error: %v", err)
+			}
+			pdfBytes := buf.Bytes()
+
+			if len(pdfBytes) < 4 || string(pdfBytes[:4]) != "%PDF" {
+				t.Error("Generated content does not appear to be a valid PDF")
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package pdf
+
+import "math"
+
+const (
+	// watermarkText is drawn across every page of a draft
+	watermarkText = "DRAFT"
+	// watermarkSize is the font size of the draft watermark in points
+	watermarkSize = 110
+	// watermarkAlpha is the opacity of the draft watermark, which is drawn
+	// over the content and must leave it readable
+	watermarkAlpha = 0.15
+)
+
+// WithDraftWatermark overlays a diagonal DRAFT watermark on every page
+func WithDraftWatermark() Option {
+	return func(g *Generator) {
+		g.draft = true
+	}
+}
+
+// WithSerialNumber prints the serial number of an official document at the
+// bottom of every page
+func WithSerialNumber(serial string) Option {
+	return func(g *Generator) {
+		g.serial = serial
+	}
+}
+
+// addWatermark draws the draft watermark across the page from the bottom left
+// to the top right corner
+func (g *Generator) addWatermark() {
+	pageWidth, pageHeight := g.pdf.GetPageSize()
+	centerX, centerY := pageWidth/2, pageHeight/2
+	angle := math.Atan2(pageHeight, pageWidth) * 180 / math.Pi
+
+	g.setFont(FontSpec{Family: "Arial", Style: "B", Size: watermarkSize})
+	width := g.textWidth(watermarkText)
+	_, fontHeight := g.pdf.GetFontSize()
+
+	g.pdf.TransformBegin()
+	g.pdf.TransformRotate(angle, centerX, centerY)
+	g.pdf.SetAlpha(watermarkAlpha, "Normal")
+	g.pdf.SetTextColor(128, 128, 128)
+	g.pdf.Text(centerX-width/2, centerY+fontHeight*0.35, watermarkText)
+	g.pdf.SetAlpha(1, "Normal")
+	g.pdf.TransformEnd()
+	g.resetColors()
+}
+
+// addSerialNumber prints the serial number of an official document in the
+// bottom left corner of the page
+func (g *Generator) addSerialNumber() {
+	g.pdf.SetY(-15)
+	g.setFont(FontSpec{Family: "Arial", Style: "B", Size: 8})
+	g.cellFormat(0, 10, "Official copy - Serial No. "+g.serial, "", 0, "L", false)
+}
@@ -0,0 +1,61 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"go-service/pkg/models"
+)
+
+// generateModeRoster writes a roster long enough to span several pages with
+// a generator configured by opts, uncompressed so its text can be searched
+func generateModeRoster(t *testing.T, opts ...Option) (*Generator, []byte) {
+	t.Helper()
+	generator := NewGenerator(opts...)
+	generator.pdf.SetCompression(false)
+
+	students := make([]models.Student, 120)
+	for i := range students {
+		students[i] = models.Student{ID: i + 1, Name: fmt.Sprintf("Student %03d", i+1), Roll: i + 1}
+	}
+
+	var buf bytes.Buffer
+	if err := generator.GenerateClassRoster(&buf, "Grade 10", "A", students); err != nil {
+		t.Fatalf("Expected roster generation to succeed, got error: %v", err)
+	}
+	return generator, buf.Bytes()
+}
+
+// TestDraftWatermarkOnEveryPage tests that a draft has the translucent DRAFT
+// watermark drawn over the content of every page
+func TestDraftWatermarkOnEveryPage(t *testing.T) {
+	generator, content := generateModeRoster(t, WithDraftWatermark())
+
+	pages := generator.pdf.PageNo()
+	if pages < 2 {
+		t.Fatalf("Expected the roster to span several pages, got %d", pages)
+	}
+	if count := bytes.Count(content, []byte("(DRAFT) Tj")); count != pages {
+		t.Errorf("Expected the watermark on all %d pages, got %d", pages, count)
+	}
+	if !bytes.Contains(content, []byte("/ca 0.15")) {
+		t.Error("Expected the watermark to be translucent")
+	}
+
+	_, plain := generateModeRoster(t)
+	if bytes.Contains(plain, []byte("(DRAFT) Tj")) {
+		t.Error("Expected no watermark without the draft option")
+	}
+}
+
+// TestSerialNumberOnEveryPage tests that an official document prints its
+// serial number on every page
+func TestSerialNumberOnEveryPage(t *testing.T) {
+	generator, content := generateModeRoster(t, WithSerialNumber("2024-000042"))
+
+	pages := generator.pdf.PageNo()
+	if count := bytes.Count(content, []byte("Official copy - Serial No. 2024-000042")); count != pages {
+		t.Errorf("Expected the serial number on all %d pages, got %d", pages, count)
+	}
+}
@@ -0,0 +1,105 @@
+package pdf
+
+import (
+	"fmt"
+	"io"
+
+	"go-service/pkg/models"
+)
+
+const (
+	noticeTitleHeight = 8
+	noticeLineHeight  = 5
+	noticeSpacing     = 6
+)
+
+// GenerateNoticeBulletin writes a printable bulletin board PDF with one block
+// per notice. A block is moved to the next page rather than split, unless it
+// is longer than a page.
+func (g *Generator) GenerateNoticeBulletin(w io.Writer, bulletin models.NoticeBulletin) error {
+	g.addPageNumbers()
+
+	// Title
+	g.pdf.AddPage()
+	g.setFont(FontSpec{Family: "Arial", Style: "B", Size: 16})
+	g.cell(0, 10, "NOTICE BOARD")
+	g.pdf.Ln(12)
+
+	// School Header
+	if heading := g.schoolHeading(); heading != "" {
+		g.setFont(FontSpec{Family: "Arial", Style: "B", Size: 14})
+		g.cell(0, 8, heading)
+		g.pdf.Ln(10)
+	}
+
+	g.addField("Period", fmt.Sprintf("%s to %s", formatDate(bulletin.From), formatDate(bulletin.To)))
+	if bulletin.Audience != "" {
+		g.addField("Audience", bulletin.Audience)
+	}
+	g.addField("Notices", fmt.Sprintf("%d", len(bulletin.Notices)))
+	g.pdf.Ln(4)
+
+	if len(bulletin.Notices) == 0 {
+		g.setFont(FontSpec{Family: "Arial", Style: "I", Size: 10})
+		g.cellFormat(0, tableRowHeight, "No approved notices in this period", "1", 1, "C", false)
+	}
+	for _, notice := range bulletin.Notices {
+		g.addNotice(notice)
+	}
+
+	// Footer
+	g.addFooter()
+
+	if err := g.output(w); err != nil {
+		return fmt.Errorf("failed to generate PDF: %w", err)
+	}
+
+	return nil
+}
+
+// addNotice draws a notice block: the title on an accent bar, the author and
+// review metadata and the wrapped description
+func (g *Generator) addNotice(notice models.BulletinNotice) {
+	pageWidth, pageHeight := g.pdf.GetPageSize()
+	leftMargin, topMargin, rightMargin, bottomMargin := g.pdf.GetMargins()
+	width := pageWidth - leftMargin - rightMargin - 2*g.pdf.GetCellMargin()
+
+	g.setFont(FontSpec{Family: "Arial", Style: "", Size: 10})
+	lines := g.wrapText(notice.Description, width)
+
+	// Keep the block on one page when it fits on an empty one
+	height := noticeTitleHeight + 2*noticeLineHeight + float64(len(lines))*noticeLineHeight
+	if available := pageHeight - bottomMargin - g.pdf.GetY(); height > available && height <= pageHeight-topMargin-bottomMargin {
+		g.pdf.AddPage()
+	}
+
+	g.setFont(FontSpec{Family: "Arial", Style: "B", Size: 12})
+	g.setAccentFill()
+	g.cellFormat(0, noticeTitleHeight, notice.Title, "1", 1, "L", true)
+	g.resetColors()
+
+	g.setFont(FontSpec{Family: "Arial", Style: "I", Size: 9})
+	posted := fmt.Sprintf("Posted by %s on %s", notice.Author, formatDate(notice.CreatedDate))
+	if notice.Recipients != "" {
+		posted += " for " + notice.Recipients
+	}
+	g.cell(0, noticeLineHeight, posted)
+	g.pdf.Ln(noticeLineHeight)
+
+	reviewed := "Approved"
+	if notice.ReviewerName != "" {
+		reviewed += " by " + notice.ReviewerName
+	}
+	if !notice.ReviewedDate.IsZero() {
+		reviewed += " on " + formatDate(notice.ReviewedDate)
+	}
+	g.cell(0, noticeLineHeight, reviewed)
+	g.pdf.Ln(noticeLineHeight)
+
+	g.setFont(FontSpec{Family: "Arial", Style: "", Size: 10})
+	for _, line := range lines {
+		g.cell(0, noticeLineHeight, line)
+		g.pdf.Ln(noticeLineHeight)
+	}
+	g.pdf.Ln(noticeSpacing)
+}
@@ -0,0 +1,708 @@
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// startXrefPattern matches the offset of the cross-reference table
+	startXrefPattern = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+	// trailerPattern matches an entry of the trailer dictionary
+	trailerPattern = regexp.MustCompile(`/(Root|Info) (\d+) 0 R`)
+	// referencePattern matches a reference to an indirect object
+	referencePattern = regexp.MustCompile(`(\d+) 0 R`)
+	// pageTreePattern matches the entries of a page tree that list its pages
+	pageTreePattern = regexp.MustCompile(`/Kids \[[^\]]*\]\s*/Count \d+`)
+	// pagesPattern matches the page tree entry of a catalog
+	pagesPattern = regexp.MustCompile(`/Pages (\d+) 0 R`)
+	// contentsPattern matches the content stream entry of a page
+	contentsPattern = regexp.MustCompile(`/Contents (\d+) 0 R`)
+	// baseFontPattern matches the name of a font
+	baseFontPattern = regexp.MustCompile(`/BaseFont /(\S+)`)
+	// descendantFontPattern matches the CID font of a Type 0 font
+	descendantFontPattern = regexp.MustCompile(`/DescendantFonts \[(\d+) 0 R\]`)
+	// cidToGIDMapPattern matches the glyph map of a CID font
+	cidToGIDMapPattern = regexp.MustCompile(`/CIDToGIDMap (\d+) 0 R`)
+)
+
+// documentWriter joins documents drawn in parts into one document. Parts are
+// complete documents as the PDF library writes them: their pages are moved into a single page tree and the objects
+// their pages use are renumbered to follow those of the parts before them.
+// An object identical to one of an earlier part, such as a core font or an
+// image every part embeds, is written once and shared. Embedded TrueType
+// fonts are subset to the characters of each part, so they are replaced by
+// subsets of the characters of every part, embedded once. The catalog and
+// document information are those of the first part.
+//
+// Parts are spooled to a temporary file as they are added, so only the part
+// being drawn is held in memory, and the document is written out once it is
+// closed, when its page count is known and replaces the page count alias.
+type documentWriter struct {
+	w io.Writer
+	// alias is the page count alias the parts leave in their page contents
+	alias string
+	// fonts returns a document embedding the fonts of the parts subset to
+	// runes. Without it the fonts of every part are kept.
+	fonts func(runes []rune) ([]byte, error)
+
+	// spool holds the objects, each after a spooledObject header, so the
+	// writer keeps nothing in memory for them until the document is written
+	spool   *os.File
+	spooler *bufio.Writer
+	// size is the number of objects numbered so far, object 1 being the page
+	// tree that is written last
+	size int
+	// shared holds the number of the objects that may be shared by the hash
+	// of their body
+	shared map[[sha256.Size]byte]int
+	// subsets holds the number of the subset each TrueType font is replaced
+	// with, by font name, and runes the characters of the parts' subsets
+	subsets map[string]int
+	runes   map[rune]bool
+	// kids holds the object numbers of the pages, in order
+	kids []int
+	// header is the first line of the first part, which gives the version
+	header string
+	// pageTree is the page tree dictionary of the first part
+	pageTree   []byte
+	root, info int
+	err        error
+}
+
+// spooledObject is the header of an object in the spool
+type spooledObject struct {
+	number, length int
+	// content is set for page contents, which are spooled as their data
+	// alone so the page count alias can be replaced, and compressed for
+	// those that are compressed
+	content, compressed bool
+}
+
+// pdfPart is a part read for its objects
+type pdfPart struct {
+	data    []byte
+	offsets map[int]int
+	// xref is the offset of the cross-reference table
+	xref int
+}
+
+// newDocumentWriter returns a documentWriter writing to w. alias is the page
+// count alias of the parts, and fonts returns the subsets of their TrueType
+// fonts; it is nil for documents drawn with the core fonts.
+func newDocumentWriter(w io.Writer, alias string, fonts func(runes []rune) ([]byte, error)) *documentWriter {
+	return &documentWriter{
+		w:       w,
+		alias:   alias,
+		fonts:   fonts,
+		size:    1,
+		shared:  make(map[[sha256.Size]byte]int),
+		subsets: make(map[string]int),
+		runes:   make(map[rune]bool),
+	}
+}
+
+// empty reports whether no part has been added
+func (d *documentWriter) empty() bool {
+	return d.root == 0
+}
+
+// add spools the objects of a part
+func (d *documentWriter) add(part []byte) error {
+	if d.err != nil {
+		return d.err
+	}
+
+	header := part[:bytes.IndexByte(part, '\n')+1]
+	if d.header == "" {
+		d.header = string(header)
+	} else if string(header) != d.header {
+		return fmt.Errorf("PDF part is %s but the document is %s", strings.TrimSpace(string(header)), strings.TrimSpace(d.header))
+	}
+
+	p, err := readPart(part)
+	if err != nil {
+		return err
+	}
+	trailer := make(map[string]int)
+	for _, entry := range trailerPattern.FindAllSubmatch(part[p.xref:], -1) {
+		trailer[string(entry[1])], _ = strconv.Atoi(string(entry[2]))
+	}
+	root := trailer["Root"]
+	match := pagesPattern.FindSubmatch(p.object(root))
+	if match == nil {
+		return errors.New("PDF part has no page tree")
+	}
+	pageTree, _ := strconv.Atoi(string(match[1]))
+	tree := p.object(pageTree)
+	if !pageTreePattern.Match(tree) {
+		return fmt.Errorf("PDF part has no pages in page tree %d", pageTree)
+	}
+
+	// Pages are numbered up front and never shared, and their contents are
+	// spooled apart so the page count alias can be replaced
+	numbers := map[int]int{pageTree: 1}
+	contents := make(map[int]bool)
+	var kids []int
+	for _, kid := range referencePattern.FindAllSubmatch(tree, -1) {
+		number, _ := strconv.Atoi(string(kid[1]))
+		numbers[number] = d.number()
+		kids = append(kids, number)
+		if match := contentsPattern.FindSubmatch(p.object(number)); match != nil {
+			content, _ := strconv.Atoi(string(match[1]))
+			contents[content] = true
+		}
+	}
+
+	// Only the catalog and information of the first part are kept
+	roots := kids
+	if d.root == 0 {
+		roots = append(roots, root)
+		if trailer["Info"] != 0 {
+			roots = append(roots, trailer["Info"])
+		}
+	}
+	if err := d.copyObjects(p, numbers, contents, roots, pageTree, d.fonts != nil); err != nil {
+		return err
+	}
+
+	if d.root == 0 {
+		d.root, d.info = numbers[root], numbers[trailer["Info"]]
+		d.pageTree = tree
+	}
+	for _, kid := range kids {
+		d.kids = append(d.kids, numbers[kid])
+	}
+	return d.err
+}
+
+// copyObjects spools the objects of a part reachable from roots, each after
+// the objects it refers to. numbers holds the numbers given to objects up
+// front, which are not shared, and receives those of the others. pageTree is
+// the page tree of the part, which is replaced by the document's. When
+// replaceFonts is set, TrueType fonts are replaced by the subsets written
+// once the document is closed.
+func (d *documentWriter) copyObjects(p *pdfPart, numbers map[int]int, contents map[int]bool, roots []int, pageTree int, replaceFonts bool) error {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := map[int]int{pageTree: visited}
+
+	var visit func(number int) error
+	visit = func(number int) error {
+		switch state[number] {
+		case visiting:
+			// An object that refers back to one being copied cannot wait
+			// for its number
+			if numbers[number] == 0 {
+				numbers[number] = d.number()
+			}
+			return nil
+		case visited:
+			return nil
+		}
+
+		body := p.object(number)
+		if body == nil {
+			return fmt.Errorf("PDF part refers to missing object %d", number)
+		}
+		state[number] = visiting
+		dict, stream := splitStream(body)
+
+		if replaceFonts && bytes.Contains(dict, []byte("/Subtype /Type0")) {
+			state[number] = visited
+			subset, err := d.subset(p, dict)
+			numbers[number] = subset
+			return err
+		}
+
+		for _, ref := range references(dict) {
+			if err := visit(ref); err != nil {
+				return err
+			}
+		}
+		state[number] = visited
+
+		dict, err := renumber(dict, numbers)
+		if err != nil {
+			return fmt.Errorf("PDF part object %d: %w", number, err)
+		}
+		switch {
+		case contents[number]:
+			numbers[number] = d.number()
+			data := bytes.TrimSuffix(bytes.TrimPrefix(stream, []byte(">>\nstream\n")), []byte("\nendstream"))
+			compressed := bytes.Contains(dict, []byte("/FlateDecode"))
+			d.spoolObject(spooledObject{number: numbers[number], content: true, compressed: compressed}, data)
+			return d.err
+		case numbers[number] == 0:
+			hash := sha256.New()
+			hash.Write(dict)
+			hash.Write(stream)
+			var sum [sha256.Size]byte
+			copy(sum[:], hash.Sum(nil))
+			if shared, ok := d.shared[sum]; ok {
+				numbers[number] = shared
+				return nil
+			}
+			numbers[number] = d.number()
+			d.shared[sum] = numbers[number]
+		}
+
+		var object bytes.Buffer
+		fmt.Fprintf(&object, "%d 0 obj\n", numbers[number])
+		object.Write(dict)
+		object.Write(stream)
+		object.WriteString("\nendobj\n")
+		d.spoolObject(spooledObject{number: numbers[number]}, object.Bytes())
+		return d.err
+	}
+
+	for _, root := range roots {
+		if err := visit(root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subset returns the number of the subset a TrueType font of a part is
+// replaced with, adding the characters of the part's subset to those of the
+// document's subsets
+func (d *documentWriter) subset(p *pdfPart, font []byte) (int, error) {
+	match := baseFontPattern.FindSubmatch(font)
+	if match == nil {
+		return 0, errors.New("PDF part has a font without a name")
+	}
+	name := string(match[1])
+
+	// The glyph map of the CID font maps every character of the subset to
+	// its glyph
+	var glyphs []byte
+	if match := descendantFontPattern.FindSubmatch(font); match != nil {
+		descendant, _ := strconv.Atoi(string(match[1]))
+		if match := cidToGIDMapPattern.FindSubmatch(p.object(descendant)); match != nil {
+			number, _ := strconv.Atoi(string(match[1]))
+			_, stream := splitStream(p.object(number))
+			data := bytes.TrimSuffix(bytes.TrimPrefix(stream, []byte(">>\nstream\n")), []byte("\nendstream"))
+			reader, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return 0, fmt.Errorf("PDF part font %s has an unreadable glyph map: %w", name, err)
+			}
+			if glyphs, err = io.ReadAll(reader); err != nil {
+				return 0, fmt.Errorf("PDF part font %s has an unreadable glyph map: %w", name, err)
+			}
+		}
+	}
+	if glyphs == nil {
+		return 0, fmt.Errorf("PDF part font %s has no glyph map", name)
+	}
+	for cid := 0; cid+1 < len(glyphs); cid += 2 {
+		if glyphs[cid] != 0 || glyphs[cid+1] != 0 {
+			d.runes[rune(cid/2)] = true
+		}
+	}
+
+	number, ok := d.subsets[name]
+	if !ok {
+		number = d.number()
+		d.subsets[name] = number
+	}
+	return number, nil
+}
+
+// close writes the document: the spooled objects, the subsets of the
+// TrueType fonts, the page tree and the cross-reference table and trailer
+func (d *documentWriter) close() error {
+	defer d.discard()
+	if d.err != nil {
+		return d.err
+	}
+	if d.root == 0 {
+		return errors.New("PDF document has no parts")
+	}
+	if err := d.addSubsets(); err != nil {
+		return err
+	}
+	if err := d.spooler.Flush(); err != nil {
+		return err
+	}
+
+	buffered := bufio.NewWriterSize(d.w, 32*1024)
+	w := &countingWriter{w: buffered}
+	offsets := make([]int64, d.size+1)
+	w.WriteString(d.header)
+
+	if _, err := d.spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	spool := bufio.NewReaderSize(d.spool, 32*1024)
+	count := []byte(strconv.Itoa(len(d.kids)))
+	for {
+		var object spooledObject
+		_, err := fmt.Fscanf(spool, "%d %d %t %t\n", &object.number, &object.length, &object.content, &object.compressed)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to read spooled PDF object: %w", err)
+		}
+
+		offsets[object.number] = w.written
+		data := io.LimitReader(spool, int64(object.length))
+		if !object.content {
+			if _, err := io.Copy(w, data); err != nil {
+				return err
+			}
+			continue
+		}
+
+		content, err := d.pageContent(object, data, count)
+		if err != nil {
+			return err
+		}
+		// Compressed data may end before the length it was spooled with
+		if _, err := io.Copy(io.Discard, data); err != nil {
+			return err
+		}
+		filter := ""
+		if object.compressed {
+			filter = "/Filter /FlateDecode "
+		}
+		fmt.Fprintf(w, "%d 0 obj\n<<%s/Length %d>>\nstream\n", object.number, filter, len(content))
+		w.Write(content)
+		w.WriteString("\nendstream\nendobj\n")
+	}
+
+	offsets[1] = w.written
+	kids := pageTreePattern.FindIndex(d.pageTree)
+	fmt.Fprintf(w, "1 0 obj\n%s/Kids [", d.pageTree[:kids[0]])
+	for _, kid := range d.kids {
+		fmt.Fprintf(w, "%d 0 R ", kid)
+	}
+	fmt.Fprintf(w, "]\n/Count %d%s\nendobj\n", len(d.kids), d.pageTree[kids[1]:])
+
+	xref := w.written
+	fmt.Fprintf(w, "xref\n0 %d\n0000000000 65535 f \n", d.size+1)
+	for number, offset := range offsets[1:] {
+		if offset == 0 {
+			return fmt.Errorf("PDF object %d was never written", number+1)
+		}
+		fmt.Fprintf(w, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(w, "trailer\n<<\n/Size %d\n/Root %d 0 R\n", d.size+1, d.root)
+	if d.info != 0 {
+		fmt.Fprintf(w, "/Info %d 0 R\n", d.info)
+	}
+	fmt.Fprintf(w, ">>\nstartxref\n%d\n%%%%EOF\n", xref)
+	if w.err != nil {
+		return w.err
+	}
+	return buffered.Flush()
+}
+
+// pageContent reads the data of a spooled page content with the page count
+// alias replaced by count
+func (d *documentWriter) pageContent(object spooledObject, data io.Reader, count []byte) ([]byte, error) {
+	if object.compressed {
+		reader, err := zlib.NewReader(data)
+		if err != nil {
+			return nil, fmt.Errorf("PDF object %d has unreadable content: %w", object.number, err)
+		}
+		data = reader
+	}
+	content, err := io.ReadAll(data)
+	if err != nil {
+		return nil, fmt.Errorf("PDF object %d has unreadable content: %w", object.number, err)
+	}
+
+	content = bytes.ReplaceAll(content, []byte(d.alias), count)
+	content = bytes.ReplaceAll(content, utf16Text(d.alias), utf16Text(string(count)))
+	if !object.compressed {
+		return content, nil
+	}
+
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	writer.Write(content)
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+// addSubsets spools the subsets of the TrueType fonts of the parts, which
+// hold the characters of every part
+func (d *documentWriter) addSubsets() error {
+	if len(d.subsets) == 0 {
+		return nil
+	}
+
+	runes := make([]rune, 0, len(d.runes))
+	for r := range d.runes {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	fonts, err := d.fonts(runes)
+	if err != nil {
+		return fmt.Errorf("failed to subset fonts: %w", err)
+	}
+	p, err := readPart(fonts)
+	if err != nil {
+		return err
+	}
+
+	numbers := make(map[int]int)
+	var roots []int
+	for _, number := range sortedObjects(p.offsets) {
+		font, _ := splitStream(p.object(number))
+		if !bytes.Contains(font, []byte("/Subtype /Type0")) {
+			continue
+		}
+		if match := baseFontPattern.FindSubmatch(font); match != nil {
+			if subset, ok := d.subsets[string(match[1])]; ok {
+				numbers[number] = subset
+				roots = append(roots, number)
+			}
+		}
+	}
+	if len(roots) != len(d.subsets) {
+		return errors.New("PDF font subsets are missing fonts of the parts")
+	}
+	return d.copyObjects(p, numbers, nil, roots, 0, false)
+}
+
+// discard removes the spool
+func (d *documentWriter) discard() {
+	if d.spool != nil {
+		d.spool.Close()
+		os.Remove(d.spool.Name())
+		d.spool = nil
+	}
+}
+
+// number returns the number of a new object
+func (d *documentWriter) number() int {
+	d.size++
+	return d.size
+}
+
+// spoolObject appends the data of an object to the spool, keeping the first
+// error
+func (d *documentWriter) spoolObject(object spooledObject, data []byte) {
+	if d.err != nil {
+		return
+	}
+	if d.spool == nil {
+		if d.spool, d.err = os.CreateTemp("", "pdf-parts-*"); d.err != nil {
+			return
+		}
+		d.spooler = bufio.NewWriterSize(d.spool, 32*1024)
+	}
+
+	fmt.Fprintf(d.spooler, "%d %d %t %t\n", object.number, len(data), object.content, object.compressed)
+	_, d.err = d.spooler.Write(data)
+}
+
+// countingWriter counts the bytes written to w, keeping the first error
+type countingWriter struct {
+	w       io.Writer
+	written int64
+	err     error
+}
+
+// Write implements io.Writer
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.written += int64(n)
+	c.err = err
+	return n, err
+}
+
+// WriteString writes s
+func (c *countingWriter) WriteString(s string) {
+	c.Write([]byte(s))
+}
+
+// readPart reads the cross-reference table of a part
+func readPart(part []byte) (*pdfPart, error) {
+	match := startXrefPattern.FindSubmatch(part)
+	if match == nil {
+		return nil, errors.New("PDF part has no cross-reference table")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	offsets, err := partOffsets(part, xref)
+	if err != nil {
+		return nil, err
+	}
+	return &pdfPart{data: part, offsets: offsets, xref: xref}, nil
+}
+
+// object returns the body of an object of the part, or nil if it has none
+func (p *pdfPart) object(number int) []byte {
+	return partObject(p.data, p.offsets, number)
+}
+
+// partOffsets reads the offsets of the objects in the cross-reference table
+// of a part at xref
+func partOffsets(part []byte, xref int) (map[int]int, error) {
+	if xref >= len(part) {
+		return nil, errors.New("PDF part cross-reference table is out of range")
+	}
+	lines := strings.Split(string(part[xref:]), "\n")
+	if len(lines) < 2 || strings.TrimSpace(lines[0]) != "xref" {
+		return nil, errors.New("PDF part cross-reference table is not a classic table")
+	}
+
+	offsets := make(map[int]int)
+	var first, count int
+	if _, err := fmt.Sscanf(lines[1], "%d %d", &first, &count); err != nil {
+		return nil, fmt.Errorf("invalid PDF part cross-reference table: %w", err)
+	}
+	for i := 0; i < count && i+2 < len(lines); i++ {
+		var offset, generation int
+		var kind string
+		if _, err := fmt.Sscanf(lines[i+2], "%d %d %s", &offset, &generation, &kind); err == nil && kind == "n" {
+			if offset >= xref {
+				return nil, fmt.Errorf("PDF part object %d is out of range", first+i)
+			}
+			offsets[first+i] = offset
+		}
+	}
+	return offsets, nil
+}
+
+// sortedObjects returns the numbers of the objects of a part in the order
+// they appear in it
+func sortedObjects(offsets map[int]int) []int {
+	numbers := make([]int, 0, len(offsets))
+	for number := range offsets {
+		numbers = append(numbers, number)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return offsets[numbers[i]] < offsets[numbers[j]] })
+	return numbers
+}
+
+// partObject returns the body of an object of a part, without the obj and
+// endobj keywords. Objects are read up to the next object or the
+// cross-reference table, since a stream may contain the endobj keyword.
+func partObject(part []byte, offsets map[int]int, number int) []byte {
+	offset, ok := offsets[number]
+	if !ok {
+		return nil
+	}
+	end := bytes.LastIndex(part, []byte("\nxref\n"))
+	for _, other := range offsets {
+		if other > offset && other < end {
+			end = other
+		}
+	}
+	body := part[offset:end]
+	if start := bytes.Index(body, []byte("obj\n")); start >= 0 {
+		body = body[start+len("obj\n"):]
+	}
+	body = bytes.TrimSuffix(bytes.TrimRight(body, "\n"), []byte("endobj"))
+	return bytes.TrimRight(body, "\n")
+}
+
+// splitStream splits the body of an object into its dictionary and its
+// stream, which starts with the end of the dictionary. Only the dictionary
+// refers to other objects.
+func splitStream(body []byte) (dict, stream []byte) {
+	if at := bytes.Index(body, []byte(">>\nstream\n")); at >= 0 {
+		return body[:at], body[at:]
+	}
+	return body, nil
+}
+
+// references returns the numbers of the objects a dictionary refers to, in
+// order
+func references(dict []byte) []int {
+	var numbers []int
+	replaceReferences(dict, func(number int) (int, error) {
+		numbers = append(numbers, number)
+		return number, nil
+	})
+	return numbers
+}
+
+// renumber replaces the references in a dictionary with the numbers the
+// objects they refer to are written as. A reference to an object without a
+// number is an error, since it would point at an unrelated object.
+func renumber(dict []byte, numbers map[int]int) ([]byte, error) {
+	return replaceReferences(dict, func(number int) (int, error) {
+		renumbered, ok := numbers[number]
+		if !ok {
+			return 0, fmt.Errorf("reference to unknown object %d", number)
+		}
+		return renumbered, nil
+	})
+}
+
+// replaceReferences replaces every reference in a dictionary by the number
+// replace returns for it. Literal strings are left as they are.
+func replaceReferences(dict []byte, replace func(number int) (int, error)) ([]byte, error) {
+	var out []byte
+	var err error
+	for len(dict) > 0 {
+		at := bytes.IndexByte(dict, '(')
+		if at < 0 {
+			at = len(dict)
+		}
+		out = append(out, referencePattern.ReplaceAllFunc(dict[:at], func(ref []byte) []byte {
+			number, _ := strconv.Atoi(string(ref[:bytes.IndexByte(ref, ' ')]))
+			replaced, replaceErr := replace(number)
+			if replaceErr != nil && err == nil {
+				err = replaceErr
+			}
+			return []byte(fmt.Sprintf("%d 0 R", replaced))
+		})...)
+		dict = dict[at:]
+
+		end := literalStringEnd(dict)
+		out = append(out, dict[:end]...)
+		dict = dict[end:]
+	}
+	return out, err
+}
+
+// literalStringEnd returns the offset just past the literal string data
+// starts with, or the length of data if the string is not terminated
+func literalStringEnd(data []byte) int {
+	depth := 0
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(data)
+}
+
+// utf16Text encodes text as the PDF library writes text drawn with a
+// TrueType font, in UTF-16 without a byte order mark
+func utf16Text(text string) []byte {
+	var out []byte
+	for _, r := range text {
+		out = append(out, byte(r>>8), byte(r))
+	}
+	return out
+}
@@ -0,0 +1,209 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"go-service/pkg/models"
+)
+
+// TestLongRosterIsWrittenInParts tests that a roster longer than a part is
+// joined into one document whose pages are numbered through
+func TestLongRosterIsWrittenInParts(t *testing.T) {
+	students := make([]models.Student, 1000)
+	for i := range students {
+		students[i] = models.Student{ID: i + 1, Name: fmt.Sprintf("Student %04d", i+1), Roll: i + 1}
+	}
+
+	var buf bytes.Buffer
+	if err := NewGenerator(WithDraftWatermark()).GenerateClassRoster(&buf, "Grade 10", "A", students); err != nil {
+		t.Fatalf("Expected roster generation to succeed, got error: %v", err)
+	}
+	document := buf.Bytes()
+
+	// Every object is where the cross-reference table says
+	xref, _ := strconv.Atoi(string(startXrefPattern.FindSubmatch(document)[1]))
+	offsets, err := partOffsets(document, xref)
+	if err != nil {
+		t.Fatalf("Failed to read cross-reference table: %v", err)
+	}
+	for number, offset := range offsets {
+		if !bytes.HasPrefix(document[offset:], []byte(strconv.Itoa(number)+" 0 obj")) {
+			t.Errorf("Expected object %d at offset %d", number, offset)
+		}
+	}
+
+	// Every reference resolves, and the pages of every part are in the one
+	// page tree
+	for number := range offsets {
+		body := partObject(document, offsets, number)
+		if at := bytes.Index(body, []byte("stream\n")); at >= 0 {
+			body = body[:at]
+		}
+		for _, ref := range referencePattern.FindAllSubmatch(body, -1) {
+			if target, _ := strconv.Atoi(string(ref[1])); offsets[target] == 0 {
+				t.Errorf("Object %d refers to missing object %d", number, target)
+			}
+		}
+	}
+	count := regexp.MustCompile(`/Type /Pages\n/Kids \[[^\]]*\]\n/Count (\d+)`).FindSubmatch(document)
+	if count == nil {
+		t.Fatal("Expected a page tree")
+	}
+	pages, _ := strconv.Atoi(string(count[1]))
+	if pages <= rosterPartPages {
+		t.Fatalf("Expected the roster to span more than one part, got %d pages", pages)
+	}
+	if trees := bytes.Count(document, []byte("/Type /Pages")); trees != 1 {
+		t.Errorf("Expected one page tree, got %d", trees)
+	}
+
+	// The pages are numbered through to the page count
+	var labels []string
+	for _, stream := range regexp.MustCompile(`(?s)<</Filter /FlateDecode /Length (\d+)>>\nstream\n`).FindAllSubmatchIndex(document, -1) {
+		length, _ := strconv.Atoi(string(document[stream[2]:stream[3]]))
+		reader, err := zlib.NewReader(bytes.NewReader(document[stream[1] : stream[1]+length]))
+		if err != nil {
+			t.Fatalf("Failed to decompress stream: %v", err)
+		}
+		content, _ := io.ReadAll(reader)
+		for _, label := range regexp.MustCompile(`\(Page \d+ of \d+\)`).FindAll(content, -1) {
+			labels = append(labels, string(label))
+		}
+	}
+	if len(labels) != pages {
+		t.Fatalf("Expected %d page numbers, got %d", pages, len(labels))
+	}
+	for i, label := range labels {
+		if expected := fmt.Sprintf("(Page %d of %d)", i+1, pages); label != expected {
+			t.Errorf("Expected page %d to be labelled %s, got %s", i+1, expected, label)
+		}
+	}
+	if count := bytes.Count(document, []byte("/Type /Page\n")); count != pages {
+		t.Errorf("Expected %d pages, got %d", pages, count)
+	}
+}
+
+// TestRosterPartsShareResources tests that the fonts and logo every part
+// carries are written once in the joined document
+func TestRosterPartsShareResources(t *testing.T) {
+	fonts := systemFonts(t)
+	dir := t.TempDir()
+	writeLogo(t, filepath.Join(dir, "logo.png"))
+	config := filepath.Join(dir, "branding.json")
+	writeBranding(t, config, `{"schoolName": "Springfield High", "logo": "logo.png"}`, 0)
+	branding, err := LoadBranding(config)
+	if err != nil {
+		t.Fatalf("Failed to load branding: %v", err)
+	}
+
+	students := make([]models.Student, 1000)
+	for i := range students {
+		students[i] = models.Student{ID: i + 1, Name: fmt.Sprintf("Student %04d", i+1), Roll: i + 1}
+	}
+	students[500].Name = "فاطمة الزهراء"
+
+	// A roster of one part is written as the PDF library writes it, with
+	// every resource once
+	var single bytes.Buffer
+	if err := NewGenerator(WithFonts(fonts), WithBranding(branding)).GenerateClassRoster(&single, "Grade 10", "A", students[:1]); err != nil {
+		t.Fatalf("Expected roster generation to succeed, got error: %v", err)
+	}
+	var joined bytes.Buffer
+	if err := NewGenerator(WithFonts(fonts), WithBranding(branding)).GenerateClassRoster(&joined, "Grade 10", "A", students); err != nil {
+		t.Fatalf("Expected roster generation to succeed, got error: %v", err)
+	}
+
+	if count := bytes.Count(joined.Bytes(), []byte("/Type /Page\n")); count <= rosterPartPages {
+		t.Fatalf("Expected the roster to span more than one part, got %d pages", count)
+	}
+	for _, resource := range []string{"/Subtype /Image", "/Subtype /Type0", "/FontFile2"} {
+		want := bytes.Count(single.Bytes(), []byte(resource))
+		if want == 0 {
+			t.Fatalf("Expected %s in a roster", resource)
+		}
+		if got := bytes.Count(joined.Bytes(), []byte(resource)); got != want {
+			t.Errorf("Expected %s %d times, got %d", resource, want, got)
+		}
+	}
+}
+
+// TestRosterPartsMemory tests that the heap stays flat while a long roster
+// is drawn and spooled: it is measured as each part is written out, when the
+// part's pages are all held, and is the same for the last part as for the
+// second, the first being measured before anything is spooled
+func TestRosterPartsMemory(t *testing.T) {
+	students := make([]models.Student, 16000)
+	for i := range students {
+		students[i] = models.Student{ID: i + 1, Name: fmt.Sprintf("Student %05d", i+1), Roll: i + 1}
+	}
+
+	generator := NewGenerator()
+	generator.addPageNumbers()
+	parts := newDocumentWriter(io.Discard, pageCountAlias, nil)
+	defer parts.discard()
+
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	var heaps []int64
+	flush := func() error {
+		runtime.GC()
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		heaps = append(heaps, int64(stats.HeapAlloc)-int64(before.HeapAlloc))
+
+		var buf bytes.Buffer
+		if err := generator.pdf.Output(&buf); err != nil {
+			return err
+		}
+		return parts.add(buf.Bytes())
+	}
+
+	generator.startPart()
+	err := generator.drawRoster("Grade 10", "A", students, func() error {
+		if err := flush(); err != nil {
+			return err
+		}
+		generator.newDocument()
+		generator.startPart()
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	runtime.KeepAlive(students)
+	if err != nil {
+		t.Fatalf("Expected the roster to be drawn, got error: %v", err)
+	}
+
+	if len(heaps) < 10 {
+		t.Fatalf("Expected the roster to span at least 10 parts, got %d", len(heaps))
+	}
+	if first, last := heaps[1], heaps[len(heaps)-1]; last > first+first/4 {
+		t.Errorf("Expected the heap to stay flat, got %d KB for the last part against %d KB for the second", last/1024, first/1024)
+	}
+}
+
+// TestRenumberUnknownReference tests that a reference to an object a part
+// does not have is an error rather than a reference to object 0
+func TestRenumberUnknownReference(t *testing.T) {
+	dict, err := renumber([]byte("<</Type /Page /Parent 1 0 R /Contents 4 0 R>>"), map[int]int{1: 1, 4: 9})
+	if err != nil {
+		t.Fatalf("Expected known references to be renumbered, got error: %v", err)
+	}
+	if string(dict) != "<</Type /Page /Parent 1 0 R /Contents 9 0 R>>" {
+		t.Errorf("Unexpected renumbered dictionary %s", dict)
+	}
+
+	if _, err := renumber([]byte("<</Type /Page /Parent 1 0 R /Contents 5 0 R>>"), map[int]int{1: 1}); err == nil {
+		t.Error("Expected a reference to an unknown object to fail")
+	}
+}
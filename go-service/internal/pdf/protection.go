@@ -0,0 +1,145 @@
+package pdf
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-service/internal/encryption"
+	"go-service/internal/export"
+	"go-service/pkg/models"
+)
+
+// protectionPermissions maps the permissions of a protected document to the
+// operations they allow readers
+var protectionPermissions = map[string]encryption.Permissions{
+	export.PermissionPrint:    encryption.AllowPrint,
+	export.PermissionCopy:     encryption.AllowCopy,
+	export.PermissionModify:   encryption.AllowModify,
+	export.PermissionAnnotate: encryption.AllowAnnotate,
+}
+
+// WithProtection encrypts the document with the user password of protection
+// and grants readers the permissions it does not deny. The owner password,
+// which lifts the restrictions, is random, so nobody can lift them.
+func WithProtection(protection export.Protection) Option {
+	return func(g *Generator) {
+		g.protection = &protection
+	}
+}
+
+// protect encrypts a finished document with AES-256, which the PDF library
+// cannot do itself
+func (g *Generator) protect(document []byte) ([]byte, error) {
+	var allow encryption.Permissions
+	for permission, operations := range protectionPermissions {
+		allow |= operations
+		for _, denied := range g.protection.Deny {
+			if denied == permission {
+				allow &^= operations
+			}
+		}
+	}
+
+	owner := make([]byte, 16)
+	if _, err := rand.Read(owner); err != nil {
+		return nil, fmt.Errorf("failed to generate owner password: %w", err)
+	}
+	encrypted, err := encryption.Encrypt(document, g.protection.Password, hex.EncodeToString(owner), allow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt PDF: %w", err)
+	}
+	return encrypted, nil
+}
+
+// passwordPart is literal text or a placeholder of a password rule
+type passwordPart struct {
+	text string
+	// path is the JSON path of the field a placeholder is replaced with
+	path string
+	// layout formats a date field with a Go time layout
+	layout string
+}
+
+// PasswordRule derives the password of a protected student report from the
+// student's record, so parents can open it with a value they already know
+type PasswordRule struct {
+	parts []passwordPart
+}
+
+// ParsePasswordRule parses a password rule: text with {path} placeholders
+// replaced by the student field at that JSON path, as in templates. Dates
+// are formatted with a Go time layout given after a colon, so {dob:02012006}
+// is the date of birth as DDMMYYYY.
+func ParsePasswordRule(rule string) (*PasswordRule, error) {
+	sample, err := toFields(templateKinds[TemplateKindStudent].sample)
+	if err != nil {
+		return nil, err
+	}
+
+	var parts []passwordPart
+	hasField := false
+	for rest := rule; rest != ""; {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			parts = append(parts, passwordPart{text: rest})
+			break
+		}
+		if start > 0 {
+			parts = append(parts, passwordPart{text: rest[:start]})
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, errors.New("unterminated placeholder")
+		}
+
+		path, layout, _ := strings.Cut(rest[start+1:start+end], ":")
+		if _, ok := lookupPath(sample, path); !ok {
+			return nil, fmt.Errorf("unknown field path %q", path)
+		}
+		parts = append(parts, passwordPart{path: path, layout: layout})
+		hasField = true
+		rest = rest[start+end+1:]
+	}
+
+	if !hasField {
+		return nil, errors.New("rule has no field placeholder")
+	}
+	return &PasswordRule{parts: parts}, nil
+}
+
+// Password derives the password of a student's report. It fails when a
+// field of the rule is empty, rather than protecting the report with a
+// password anyone could guess.
+func (r *PasswordRule) Password(student *models.Student) (string, error) {
+	fields, err := toFields(student)
+	if err != nil {
+		return "", err
+	}
+
+	var password strings.Builder
+	for _, part := range r.parts {
+		if part.path == "" {
+			password.WriteString(part.text)
+			continue
+		}
+
+		value, _ := lookupPath(fields, part.path)
+		text := formatValue(value, "")
+		if part.layout != "" {
+			if date, err := time.Parse(time.RFC3339, text); err == nil && !date.IsZero() {
+				text = date.Format(part.layout)
+			} else {
+				text = ""
+			}
+		}
+		if text == "" {
+			return "", fmt.Errorf("student has no %s to derive the report password from", part.path)
+		}
+		password.WriteString(text)
+	}
+	return password.String(), nil
+}
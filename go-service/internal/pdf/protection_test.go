@@ -0,0 +1,82 @@
+package pdf
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"go-service/internal/encryption"
+	"go-service/internal/export"
+	"go-service/pkg/models"
+)
+
+// TestProtectedDocument tests that a protected document is encrypted and
+// withholds the permissions its protection denies
+func TestProtectedDocument(t *testing.T) {
+	generator := NewGenerator(WithProtection(export.Protection{
+		Password: "01022010",
+		Deny:     []string{export.PermissionCopy, export.PermissionModify},
+	}))
+	generator.pdf.SetCompression(false)
+
+	var buf bytes.Buffer
+	if err := generator.GenerateStudentReport(&buf, &models.Student{ID: 2, Name: "Lisa Simpson", FatherPhone: "555-0113"}); err != nil {
+		t.Fatalf("Expected report generation to succeed, got error: %v", err)
+	}
+	content := buf.Bytes()
+
+	if !bytes.Contains(content, []byte("/Filter /Standard")) || !bytes.Contains(content, []byte("/CFM /AESV3")) {
+		t.Fatal("Expected the report to be encrypted with AES-256")
+	}
+	match := regexp.MustCompile(`/P (-?\d+)`).FindSubmatch(content)
+	if match == nil {
+		t.Fatal("Expected the report to list its permissions")
+	}
+	value, _ := strconv.ParseInt(string(match[1]), 10, 32)
+	p := encryption.Permissions(value)
+	if p&encryption.AllowPrint != encryption.AllowPrint || p&encryption.AllowAnnotate != encryption.AllowAnnotate {
+		t.Errorf("Expected printing and annotations to be allowed, got permissions %d", p)
+	}
+	if p&encryption.AllowCopy != 0 || p&encryption.AllowModify != 0 {
+		t.Errorf("Expected copying and modifying to be denied, got permissions %d", p)
+	}
+
+	// The content streams are encrypted, so the text they draw is unreadable
+	// even uncompressed
+	if bytes.Contains(content, []byte("555-0113")) {
+		t.Error("Expected the student's details to be encrypted")
+	}
+}
+
+func TestParsePasswordRule(t *testing.T) {
+	for _, rule := range []string{"{dob:02012006}", "{roll}-{dob:2006}", "school-{id}"} {
+		if _, err := ParsePasswordRule(rule); err != nil {
+			t.Errorf("Expected rule %q to be valid, got error: %v", rule, err)
+		}
+	}
+
+	for _, rule := range []string{"", "letmein", "{birthday}", "{dob:0201", "{}"} {
+		if _, err := ParsePasswordRule(rule); err == nil {
+			t.Errorf("Expected rule %q to be rejected", rule)
+		}
+	}
+}
+
+func TestPasswordRuleDerivesFromStudent(t *testing.T) {
+	rule, err := ParsePasswordRule("{roll}-{dob:02012006}")
+	if err != nil {
+		t.Fatalf("Failed to parse rule: %v", err)
+	}
+
+	student := &models.Student{Roll: 7, DOB: time.Date(2010, 2, 1, 0, 0, 0, 0, time.UTC)}
+	if password, err := rule.Password(student); err != nil || password != "7-01022010" {
+		t.Errorf("Expected password 7-01022010, got %q (%v)", password, err)
+	}
+
+	// A student without a date of birth cannot be given a password
+	if _, err := rule.Password(&models.Student{Roll: 7}); err == nil {
+		t.Error("Expected a student without a date of birth to have no password")
+	}
+}
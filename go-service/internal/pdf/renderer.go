@@ -0,0 +1,262 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go-service/internal/export"
+	"go-service/internal/signing"
+	"go-service/internal/verify"
+	"go-service/pkg/models"
+)
+
+// ContentType is the MIME type of PDF reports
+const ContentType = "application/pdf"
+
+// ErrOfficialUnavailable is returned for official documents when the renderer
+// has no verifier to hand out their serial numbers
+var ErrOfficialUnavailable = errors.New("official documents need a verifier")
+
+// Profile is the data of a student or staff report: a record laid out by a
+// report template
+type Profile struct {
+	Template *Template
+	// Record is a *models.Student or a *models.Staff
+	Record interface{}
+}
+
+// MarshalJSON writes the record alone, so a profile is exported as its record
+func (p Profile) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.Record)
+}
+
+// Renderer renders reports as PDF documents, laid out by the type of the
+// report's data. A Generator draws a single document, so every report gets
+// a new one and a Renderer can be shared by concurrent requests.
+type Renderer struct {
+	options func() []Option
+	// verifier records the documents issued when reports are verifiable
+	verifier *verify.Store
+	// verifyURL returns the URL a verification ID is checked at
+	verifyURL func(id string) string
+	// signer signs every document when it is set
+	signer *signing.Signer
+}
+
+// NewRenderer creates a PDF renderer. options is called for every report and
+// returns the options of its generator, such as the current branding; it may
+// be nil.
+func NewRenderer(options func() []Option) *Renderer {
+	return &Renderer{options: options}
+}
+
+// SetVerifier makes every report verifiable: it is printed with a new
+// verification ID and a QR code of url(id), and recorded in store with the
+// hash of the document once it has been written out in full. It must be
+// called before the renderer is used.
+func (r *Renderer) SetVerifier(store *verify.Store, url func(id string) string) {
+	r.verifier = store
+	r.verifyURL = url
+}
+
+// SetSigner signs every report with a PKCS#7 detached signature. A signature
+// covers the whole document, so signed reports are generated in full before
+// they are written out. It must be called before the renderer is used.
+func (r *Renderer) SetSigner(signer *signing.Signer) {
+	r.signer = signer
+}
+
+// document is a report laid out for a generator
+type document struct {
+	// report, subjectID and subject describe the document to its verifier;
+	// a new document for the same report and subject supersedes the last
+	report, subjectID, subject string
+	// options are the generator options the layout needs
+	options  []Option
+	generate func(g *Generator, w io.Writer) error
+}
+
+// Render implements export.Renderer. The document is drawn in the background
+// and streamed through the returned reader, which is an io.ReadCloser: a
+// generation error is returned by Read, and closing the reader before the
+// end stops the generator writing.
+//
+// A draft is drawn with a watermark and is neither signed nor issued, so it
+// never supersedes an issued document. An official document is issued with
+// a serial number, which needs a verifier to hand them out. A protected
+// document is encrypted with the password of its protection.
+func (r *Renderer) Render(ctx context.Context, report *export.Report) (io.Reader, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	doc, err := layout(report)
+	if err != nil {
+		return nil, "", err
+	}
+	draft := report.Mode == export.ModeDraft
+	if report.Mode == export.ModeOfficial && r.verifier == nil {
+		return nil, "", ErrOfficialUnavailable
+	}
+
+	var opts []Option
+	if r.options != nil {
+		opts = r.options()
+	}
+	opts = append(opts, doc.options...)
+	if draft {
+		opts = append(opts, WithDraftWatermark())
+	}
+	var password string
+	if report.Protection != nil {
+		password = report.Protection.Password
+		opts = append(opts, WithProtection(*report.Protection))
+	}
+
+	var issued *verify.Document
+	if r.verifier != nil && !draft {
+		id, err := verify.NewID()
+		if err != nil {
+			return nil, "", err
+		}
+		opts = append(opts, WithVerification(Verification{ID: id, URL: r.verifyURL(id)}))
+		issued = &verify.Document{ID: id, Report: doc.report, SubjectID: doc.subjectID, Subject: doc.subject, IssuedAt: time.Now().UTC()}
+		if report.Mode == export.ModeOfficial {
+			issued.Serial = r.verifier.ReserveSerial(issued.IssuedAt)
+			opts = append(opts, WithSerialNumber(issued.Serial))
+		}
+	}
+	g := NewGenerator(opts...)
+
+	signer := r.signer
+	if draft {
+		signer = nil
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		hash := sha256.New()
+		err := generate(g, doc, signer, password, io.MultiWriter(writer, hash))
+		// Only documents the reader received in full are issued, and the
+		// serial number of any other is handed out again
+		if err == nil && issued != nil {
+			issued.SHA256 = hex.EncodeToString(hash.Sum(nil))
+			if err := r.verifier.Issue(*issued); err != nil {
+				fmt.Printf("Error recording issued document %s: %v\n", issued.ID, err)
+			}
+		} else if issued != nil && issued.Serial != "" {
+			r.verifier.ReleaseSerial(issued.Serial)
+		}
+		writer.CloseWithError(err)
+	}()
+	return reader, ContentType, nil
+}
+
+// generate writes a document to w, signed with signer unless it is nil.
+// password is the user password of a protected document.
+func generate(g *Generator, doc document, signer *signing.Signer, password string, w io.Writer) error {
+	if signer == nil {
+		return doc.generate(g, w)
+	}
+
+	var buf bytes.Buffer
+	if err := doc.generate(g, &buf); err != nil {
+		return err
+	}
+	return signer.Sign(w, buf.Bytes(), time.Now(), password)
+}
+
+// layout returns the document a report is drawn as, chosen by the type of its
+// data
+func layout(report *export.Report) (document, error) {
+	switch data := report.Data.(type) {
+	case Profile:
+		options := []Option{WithTemplate(data.Template)}
+		switch record := data.Record.(type) {
+		case *models.Student:
+			return document{
+				report: "student_report", subjectID: strconv.Itoa(record.ID), subject: record.Name, options: options,
+				generate: func(g *Generator, w io.Writer) error { return g.GenerateStudentReport(w, record) },
+			}, nil
+		case *models.Staff:
+			return document{
+				report: "staff_report", subjectID: strconv.Itoa(record.ID), subject: record.Name, options: options,
+				generate: func(g *Generator, w io.Writer) error { return g.GenerateStaffReport(w, record) },
+			}, nil
+		}
+	case models.ClassRoster:
+		return document{
+			report: "class_roster", subjectID: rosterSubjectID(data), subject: fmt.Sprintf("%s, section %s", data.Class, data.Section),
+			generate: func(g *Generator, w io.Writer) error {
+				return g.GenerateClassRoster(w, data.Class, data.Section, data.Students)
+			},
+		}, nil
+	case models.LeaveReport:
+		var subjectID string
+		if data.UserID != 0 {
+			subjectID = strconv.Itoa(data.UserID) + "/" + data.AcademicYear
+		}
+		return document{
+			report: "leave_report", subjectID: subjectID, subject: fmt.Sprintf("%s, %s", data.User, data.AcademicYear),
+			generate: func(g *Generator, w io.Writer) error {
+				return g.GenerateLeaveReport(w, data.User, data.Period, data.Requests, data.Policies)
+			},
+		}, nil
+	case models.LeaveDigest:
+		return document{
+			report: "leave_digest", subjectID: strconv.Itoa(data.ApproverID), subject: "Pending leave requests",
+			generate: func(g *Generator, w io.Writer) error { return g.GenerateLeaveDigest(w, data) },
+		}, nil
+	case models.NoticeBulletin:
+		// What a bulletin lists depends on its filters and on the notices
+		// the caller may see, so bulletins have no stable subject and are
+		// never superseded
+		period := data.From.Format("2006-01-02") + "/" + data.To.Format("2006-01-02")
+		return document{
+			report: "notice_bulletin", subject: "Notices " + period,
+			generate: func(g *Generator, w io.Writer) error { return g.GenerateNoticeBulletin(w, data) },
+		}, nil
+	case *models.DashboardSummary:
+		return document{
+			report: "dashboard_summary", subjectID: strconv.Itoa(data.UserID), subject: "Dashboard summary",
+			generate: func(g *Generator, w io.Writer) error {
+				return g.GenerateDashboardSummary(w, data, report.GeneratedAt)
+			},
+		}, nil
+	case models.ClassTeacherMatrix:
+		// Neither is the class teacher matrix, which is a snapshot of the
+		// assignments the caller may see
+		return document{
+			report: "class_teachers", subject: "Class teacher assignments",
+			generate: func(g *Generator, w io.Writer) error { return g.GenerateClassTeacherMatrix(w, data) },
+		}, nil
+	}
+	return document{}, fmt.Errorf("%w: no PDF layout for %T", export.ErrUnsupportedReport, report.Data)
+}
+
+// rosterSubjectID identifies the roster of a class section, and the filters
+// its students were listed with, so a filtered roster does not supersede the
+// roster of the whole section
+func rosterSubjectID(roster models.ClassRoster) string {
+	subjectID := roster.Class + "/" + roster.Section
+	filters := url.Values{}
+	if roster.NameFilter != "" {
+		filters.Set("name", roster.NameFilter)
+	}
+	if roster.RollFilter != "" {
+		filters.Set("roll", roster.RollFilter)
+	}
+	if len(filters) > 0 {
+		subjectID += "?" + filters.Encode()
+	}
+	return subjectID
+}
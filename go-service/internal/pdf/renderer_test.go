@@ -0,0 +1,359 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"go-service/internal/export"
+	"go-service/internal/signing"
+	"go-service/internal/verify"
+	"go-service/pkg/models"
+)
+
+// TestRendererConcurrentReports tests that a shared renderer draws every
+// report type with a generator of its own while other reports are rendered
+func TestRendererConcurrentReports(t *testing.T) {
+	calls := 0
+	var mu sync.Mutex
+	renderer := NewRenderer(func() []Option {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		return []Option{WithBranding(&Branding{SchoolName: "Springfield Elementary"})}
+	})
+
+	student := &models.Student{ID: 1, Name: "Bart Simpson", Class: "Grade 4", Section: "B"}
+	day := time.Date(2024, time.May, 6, 0, 0, 0, 0, time.UTC)
+	reports := []*export.Report{
+		{Data: Profile{Template: defaultTemplate, Record: student}},
+		{Data: Profile{Template: defaultStaffTemplate, Record: &models.Staff{ID: 1, Name: "Edna Krabappel"}}},
+		{Data: models.ClassRoster{Class: "Grade 4", Section: "B", Students: []models.Student{*student}}},
+		{Data: models.LeaveReport{User: "Edna Krabappel", Period: models.NewAcademicYear(2024, time.April)}},
+		{Data: models.BuildLeaveDigest(nil, 1, day)},
+		{Data: models.NoticeBulletin{From: day, To: day}},
+		{Data: &models.DashboardSummary{}, GeneratedAt: day},
+		{Data: models.ClassTeacherMatrix{}},
+	}
+
+	const rounds = 4
+	var wg sync.WaitGroup
+	errs := make(chan error, rounds*len(reports))
+	for i := 0; i < rounds; i++ {
+		for _, report := range reports {
+			wg.Add(1)
+			go func(report *export.Report) {
+				defer wg.Done()
+				reader, contentType, err := renderer.Render(context.Background(), report)
+				if err != nil {
+					errs <- err
+					return
+				}
+				defer export.Close(reader)
+
+				content, _ := io.ReadAll(reader)
+				if contentType != ContentType || !bytes.HasPrefix(content, []byte("%PDF-")) {
+					errs <- fmt.Errorf("expected a PDF document for %T, got %s", report.Data, contentType)
+				}
+			}(report)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	if calls != rounds*len(reports) {
+		t.Errorf("Expected a generator per report, got options for %d of %d", calls, rounds*len(reports))
+	}
+}
+
+// TestRendererUnsupportedReport tests that data without a PDF layout is
+// reported as unsupported
+func TestRendererUnsupportedReport(t *testing.T) {
+	_, _, err := NewRenderer(nil).Render(context.Background(), &export.Report{Data: map[string]int{"students": 2}})
+	if !errors.Is(err, export.ErrUnsupportedReport) {
+		t.Errorf("Expected ErrUnsupportedReport, got %v", err)
+	}
+}
+
+// TestRendererIssuesDocuments tests that a verifiable renderer records every
+// document with the hash of what the reader received, and that a new report
+// for the same student supersedes the last
+func TestRendererIssuesDocuments(t *testing.T) {
+	store, err := verify.NewStore(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	var ids []string
+	renderer := NewRenderer(nil)
+	renderer.SetVerifier(store, func(id string) string {
+		ids = append(ids, id)
+		return "https://reports.example.com/api/v1/verify/" + id
+	})
+
+	report := &export.Report{Data: Profile{Template: defaultTemplate, Record: &models.Student{ID: 2, Name: "Lisa Simpson"}}}
+	var hashes []string
+	for i := 0; i < 2; i++ {
+		reader, _, err := renderer.Render(context.Background(), report)
+		if err != nil {
+			t.Fatalf("Failed to render report: %v", err)
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("Failed to read report: %v", err)
+		}
+		sum := sha256.Sum256(content)
+		hashes = append(hashes, hex.EncodeToString(sum[:]))
+	}
+
+	if len(ids) != 2 {
+		t.Fatalf("Expected a verification ID per report, got %v", ids)
+	}
+	for i, id := range ids {
+		doc, err := store.Get(id)
+		if err != nil {
+			t.Fatalf("Expected document %s to be recorded, got error: %v", id, err)
+		}
+		if doc.Report != "student_report" || doc.SubjectID != "2" || doc.Subject != "Lisa Simpson" || doc.IssuedAt.IsZero() {
+			t.Errorf("Unexpected document record %+v", doc)
+		}
+		if doc.SHA256 != hashes[i] {
+			t.Errorf("Expected the hash of the rendered PDF %s, got %s", hashes[i], doc.SHA256)
+		}
+		if superseded := i == 0; doc.Superseded() != superseded {
+			t.Errorf("Expected document %d to be superseded: %v, got %+v", i, superseded, doc)
+		}
+	}
+}
+
+// TestRendererIssuesDocumentsPerSubject tests that the dashboard, leave
+// digest and leave report of one user do not supersede those of another, even
+// of the same name, that a filtered roster does not supersede the whole
+// section's and that bulletins are never superseded
+func TestRendererIssuesDocumentsPerSubject(t *testing.T) {
+	store, err := verify.NewStore(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	var ids []string
+	renderer := NewRenderer(nil)
+	renderer.SetVerifier(store, func(id string) string {
+		ids = append(ids, id)
+		return "https://reports.example.com/api/v1/verify/" + id
+	})
+
+	day := time.Date(2024, time.May, 6, 0, 0, 0, 0, time.UTC)
+	year := models.NewAcademicYear(2024, time.April)
+	data := []interface{}{
+		&models.DashboardSummary{UserID: 1},
+		&models.DashboardSummary{UserID: 2},
+		models.BuildLeaveDigest(nil, 1, day),
+		models.BuildLeaveDigest(nil, 2, day),
+		models.LeaveReport{UserID: 1, User: "Edna Krabappel", AcademicYear: year.String(), Period: year},
+		models.LeaveReport{UserID: 2, User: "Edna Krabappel", AcademicYear: year.String(), Period: year},
+		models.ClassRoster{Class: "Grade 4", Section: "B"},
+		models.ClassRoster{Class: "Grade 4", Section: "B", NameFilter: "Bart"},
+		models.NoticeBulletin{From: day, To: day},
+		models.NoticeBulletin{From: day, To: day},
+		&models.DashboardSummary{UserID: 1},
+	}
+	for _, data := range data {
+		reader, _, err := renderer.Render(context.Background(), &export.Report{Data: data, GeneratedAt: day})
+		if err != nil {
+			t.Fatalf("Failed to render report: %v", err)
+		}
+		if _, err := io.ReadAll(reader); err != nil {
+			t.Fatalf("Failed to read report: %v", err)
+		}
+	}
+
+	if len(ids) != len(data) {
+		t.Fatalf("Expected a verification ID per report, got %v", ids)
+	}
+	for i, id := range ids {
+		doc, err := store.Get(id)
+		if err != nil {
+			t.Fatalf("Expected document %s to be recorded, got error: %v", id, err)
+		}
+		// Only the first dashboard of user 1 was issued again
+		if superseded := i == 0; doc.Superseded() != superseded {
+			t.Errorf("Expected document %d (%s for %q) to be superseded: %v", i, doc.Report, doc.SubjectID, superseded)
+		}
+	}
+}
+
+// TestRendererSignsDocuments tests that a signing renderer writes reports
+// whose signature verifies, that the verification record holds the hash of
+// the signed document, that protected documents are signed too and that
+// drafts are left unsigned
+func TestRendererSignsDocuments(t *testing.T) {
+	// A self-signed certificate is its own CA
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Springfield Elementary"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+
+	signer, err := signing.LoadSigner(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("Failed to load signer: %v", err)
+	}
+	store, err := verify.NewStore(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	var id string
+	renderer := NewRenderer(nil)
+	renderer.SetSigner(signer)
+	renderer.SetVerifier(store, func(issued string) string {
+		id = issued
+		return "https://reports.example.com/api/v1/verify/" + issued
+	})
+
+	reader, _, err := renderer.Render(context.Background(), &export.Report{Data: Profile{Template: defaultTemplate, Record: &models.Student{ID: 2, Name: "Lisa Simpson"}}})
+	if err != nil {
+		t.Fatalf("Failed to render report: %v", err)
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to read report: %v", err)
+	}
+
+	cert, _ := x509.ParseCertificate(der)
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	if signature, err := signing.Verify(content, roots); err != nil || signature.Signer.Subject.CommonName != "Springfield Elementary" {
+		t.Errorf("Expected the report to be signed by Springfield Elementary, got %+v (%v)", signature, err)
+	}
+
+	sum := sha256.Sum256(content)
+	if doc, err := store.Get(id); err != nil || doc.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected the hash of the signed report to be recorded, got %+v (%v)", doc, err)
+	}
+
+	// Protected reports are signed within their encryption
+	protection := &export.Protection{Password: "01022010", Deny: []string{export.PermissionCopy}}
+	reader, _, err = renderer.Render(context.Background(), &export.Report{Data: Profile{Template: defaultTemplate, Record: &models.Student{ID: 2, Name: "Lisa Simpson"}}, Protection: protection})
+	if err != nil {
+		t.Fatalf("Failed to render protected report: %v", err)
+	}
+	protected, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to read protected report: %v", err)
+	}
+	if !bytes.Contains(protected, []byte("/Filter /Standard")) {
+		t.Error("Expected the protected report to be encrypted")
+	}
+	if _, err := signing.Verify(protected, roots); err != nil {
+		t.Errorf("Expected the protected report to be signed, got %v", err)
+	}
+
+	reader, _, err = renderer.Render(context.Background(), &export.Report{Data: models.ClassTeacherMatrix{}, Mode: export.ModeDraft})
+	if err != nil {
+		t.Fatalf("Failed to render draft: %v", err)
+	}
+	draft, _ := io.ReadAll(reader)
+	if _, err := signing.Verify(draft, roots); !errors.Is(err, signing.ErrNotSigned) {
+		t.Errorf("Expected the draft to be unsigned, got %v", err)
+	}
+}
+
+// TestRendererModes tests that a draft is neither issued nor signed and that
+// an official document is issued with a serial number
+func TestRendererModes(t *testing.T) {
+	store, err := verify.NewStore(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	var ids []string
+	renderer := NewRenderer(nil)
+	renderer.SetVerifier(store, func(id string) string {
+		ids = append(ids, id)
+		return "https://reports.example.com/api/v1/verify/" + id
+	})
+
+	render := func(mode string) []byte {
+		t.Helper()
+		report := &export.Report{Data: Profile{Template: defaultTemplate, Record: &models.Student{ID: 2, Name: "Lisa Simpson"}}, Mode: mode}
+		reader, _, err := renderer.Render(context.Background(), report)
+		if err != nil {
+			t.Fatalf("Failed to render %s report: %v", mode, err)
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("Failed to read %s report: %v", mode, err)
+		}
+		return content
+	}
+
+	render(export.ModeDraft)
+	if len(ids) != 0 {
+		t.Errorf("Expected a draft not to be issued, got verification IDs %v", ids)
+	}
+
+	render(export.ModeOfficial)
+	if len(ids) != 1 {
+		t.Fatalf("Expected an official report to be issued, got verification IDs %v", ids)
+	}
+	if doc, err := store.Get(ids[0]); err != nil || doc.Serial == "" {
+		t.Errorf("Expected the official report to be recorded with a serial number, got %+v (%v)", doc, err)
+	}
+
+	if _, _, err := NewRenderer(nil).Render(context.Background(), &export.Report{Data: models.ClassTeacherMatrix{}, Mode: export.ModeOfficial}); !errors.Is(err, ErrOfficialUnavailable) {
+		t.Errorf("Expected ErrOfficialUnavailable without a verifier, got %v", err)
+	}
+}
+
+// TestProfileJSON tests that a profile is exported as its record alone
+func TestProfileJSON(t *testing.T) {
+	data, err := json.Marshal(Profile{Template: defaultTemplate, Record: &models.Student{ID: 2, Name: "Lisa Simpson"}})
+	if err != nil {
+		t.Fatalf("Expected profile to be encoded, got error: %v", err)
+	}
+
+	var student models.Student
+	if err := json.Unmarshal(data, &student); err != nil || student.Name != "Lisa Simpson" {
+		t.Errorf("Expected the student record, got %s", data)
+	}
+}
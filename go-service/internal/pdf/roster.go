@@ -0,0 +1,225 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"go-service/pkg/models"
+)
+
+// rosterColumn describes a single column of the class roster table
+type rosterColumn struct {
+	tableColumn
+	value func(student *models.Student) string
+}
+
+// rosterColumns defines the roster table layout; widths add up to the
+// printable width of an A4 page with the default 10mm margins
+var rosterColumns = []rosterColumn{
+	{tableColumn{"Roll", 16}, func(s *models.Student) string {
+		if s.Roll == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%d", s.Roll)
+	}},
+	{tableColumn{"Name", 54}, func(s *models.Student) string { return s.Name }},
+	{tableColumn{"Gender", 22}, func(s *models.Student) string { return s.Gender }},
+	{tableColumn{"Phone", 32}, func(s *models.Student) string { return s.Phone }},
+	{tableColumn{"Guardian Contact", 66}, guardianContact},
+}
+
+// rosterPartPages is the number of pages of a roster drawn before they are
+// written out. The PDF library holds every page of a document until it is
+// output, so long rosters are drawn as documents of this many pages that are
+// joined as they are written, which keeps the memory a roster takes flat
+// however many students it lists.
+const rosterPartPages = 20
+
+// partPageCountAlias is the page count alias of the documents of a roster
+// drawn in parts. The PDF library replaces its alias with the page count of
+// each part, so they are given one that is never drawn, and the page count
+// alias the footer draws is left for the documentWriter to replace with the
+// count of the whole roster.
+const partPageCountAlias = "{part-nb}"
+
+// GenerateClassRoster writes a tabular PDF roster for a class section.
+// Rows flow onto as many pages as needed and the table header is repeated
+// at the top of every page. A roster longer than a part is drawn in parts
+// that are spooled to a temporary file and written out once the last is
+// drawn.
+func (g *Generator) GenerateClassRoster(w io.Writer, className, section string, students []models.Student) error {
+	g.addPageNumbers()
+
+	err := g.protected(w, func(w io.Writer) error {
+		var fonts func(runes []rune) ([]byte, error)
+		if g.fonts != nil {
+			fonts = g.fontSubsets
+		}
+		parts := newDocumentWriter(w, pageCountAlias, fonts)
+		defer parts.discard()
+		flush := func() error {
+			var buf bytes.Buffer
+			if err := g.pdf.Output(&buf); err != nil {
+				return err
+			}
+			return parts.add(buf.Bytes())
+		}
+
+		g.startPart()
+		if err := g.drawRoster(className, section, students, func() error {
+			if err := flush(); err != nil {
+				return err
+			}
+			g.newDocument()
+			g.startPart()
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		// A roster that fits in one part is written as any other document
+		if parts.empty() {
+			g.pdf.AliasNbPages(pageCountAlias)
+			return g.pdf.Output(w)
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		return parts.close()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate PDF: %w", err)
+	}
+
+	return nil
+}
+
+// startPart sets the current document up as a part of a roster for a
+// documentWriter, which replaces the page count alias left in its pages
+func (g *Generator) startPart() {
+	g.pdf.AliasNbPages(partPageCountAlias)
+}
+
+// fontSubsets returns a document embedding the fonts of the generator's font
+// set subset to runes, which replace the subsets of the parts of a roster
+func (g *Generator) fontSubsets(runes []rune) ([]byte, error) {
+	g.newDocument()
+	g.pdf.AddPage()
+
+	var printable []rune
+	for _, r := range runes {
+		if r >= ' ' {
+			printable = append(printable, r)
+		}
+	}
+	for _, family := range g.fonts.families {
+		for _, style := range fontStyles {
+			g.pdf.SetFont(family.name, style, 10)
+			g.pdf.Text(0, 10, string(printable))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := g.pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawRoster draws a roster in parts of rosterPartPages pages. next is called
+// when a part is full and must write it out and start the document of the
+// next part.
+func (g *Generator) drawRoster(className, section string, students []models.Student, next func() error) error {
+	g.firstPage = 0
+
+	// Title
+	g.pdf.AddPage()
+	g.setFont(FontSpec{Family: "Arial", Style: "B", Size: 16})
+	g.cell(0, 10, "CLASS ROSTER")
+	g.pdf.Ln(12)
+
+	// School Header
+	if heading := g.schoolHeading(); heading != "" {
+		g.setFont(FontSpec{Family: "Arial", Style: "B", Size: 14})
+		g.cell(0, 8, heading)
+		g.pdf.Ln(10)
+	}
+
+	// Class summary
+	g.addField("Class", className)
+	g.addField("Section", section)
+	g.addField("Total Students", fmt.Sprintf("%d", len(students)))
+	g.pdf.Ln(4)
+
+	columns := make([]tableColumn, len(rosterColumns))
+	for i, column := range rosterColumns {
+		columns[i] = column.tableColumn
+	}
+	if len(students) == 0 {
+		g.addTable(columns, nil, "No students found for this class and section")
+	} else {
+		g.addTableHeader(columns)
+	}
+
+	// Rows are built as they are drawn rather than up front, so they take
+	// no more memory than the pages they are drawn on
+	for i := range students {
+		if !g.rowFits() {
+			if pages := g.pdf.PageNo(); pages >= rosterPartPages {
+				if err := next(); err != nil {
+					return err
+				}
+				g.firstPage += pages
+			}
+			g.pdf.AddPage()
+			g.addTableHeader(columns)
+		}
+		g.addTableRow(columns, rosterRow(&students[i]), i%2 == 1)
+	}
+
+	// Footer
+	g.addFooter()
+	return nil
+}
+
+// RosterTable returns the column titles and rows of the roster table, one
+// row per student
+func RosterTable(students []models.Student) ([]string, [][]string) {
+	titles := make([]string, len(rosterColumns))
+	for i, column := range rosterColumns {
+		titles[i] = column.title
+	}
+	rows := make([][]string, len(students))
+	for i := range students {
+		rows[i] = rosterRow(&students[i])
+	}
+	return titles, rows
+}
+
+// rosterRow returns the row of the roster table for a student
+func rosterRow(student *models.Student) []string {
+	row := make([]string, len(rosterColumns))
+	for i, column := range rosterColumns {
+		row[i] = column.value(student)
+	}
+	return row
+}
+
+// guardianContact combines the guardian's name, relation and phone number
+func guardianContact(student *models.Student) string {
+	name := student.GuardianName
+	if name != "" && student.RelationOfGuardian != "" {
+		name = fmt.Sprintf("%s (%s)", name, student.RelationOfGuardian)
+	}
+
+	parts := []string{}
+	if name != "" {
+		parts = append(parts, name)
+	}
+	if student.GuardianPhone != "" {
+		parts = append(parts, student.GuardianPhone)
+	}
+	return strings.Join(parts, " - ")
+}
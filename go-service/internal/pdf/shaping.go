@@ -0,0 +1,370 @@
+package pdf
+
+import "unicode"
+
+// The PDF library draws one glyph per character, left to right, without the
+// substitution and positioning tables of the font. shapeText does the part of
+// shaping that Unicode can express with characters of its own: Arabic letters
+// are replaced by their contextual presentation forms, the Devanagari vowel
+// sign I is moved before its consonant cluster, and right-to-left text is
+// put in display order.
+
+// arabicJoining is how an Arabic letter joins its neighbours
+type arabicJoining int
+
+const (
+	// joinsRight letters join the letter before them only
+	joinsRight arabicJoining = iota + 1
+	// joinsDual letters join the letters on both sides
+	joinsDual
+	// joinsCausing characters (tatweel) join both sides without changing form
+	joinsCausing
+)
+
+// arabicForm holds the presentation forms of an Arabic letter, in the order
+// Unicode lists them: isolated, final, initial, medial. Right-joining letters
+// have no initial or medial form.
+type arabicForm struct {
+	joining arabicJoining
+	forms   [4]rune
+}
+
+// arabicForms maps the letters of the Arabic block, and the extra letters used
+// in Persian and Urdu, to their presentation forms
+var arabicForms = map[rune]arabicForm{
+	0x0622: {joinsRight, [4]rune{0xFE81, 0xFE82}},
+	0x0623: {joinsRight, [4]rune{0xFE83, 0xFE84}},
+	0x0624: {joinsRight, [4]rune{0xFE85, 0xFE86}},
+	0x0625: {joinsRight, [4]rune{0xFE87, 0xFE88}},
+	0x0626: {joinsDual, [4]rune{0xFE89, 0xFE8A, 0xFE8B, 0xFE8C}},
+	0x0627: {joinsRight, [4]rune{0xFE8D, 0xFE8E}},
+	0x0628: {joinsDual, [4]rune{0xFE8F, 0xFE90, 0xFE91, 0xFE92}},
+	0x0629: {joinsRight, [4]rune{0xFE93, 0xFE94}},
+	0x062A: {joinsDual, [4]rune{0xFE95, 0xFE96, 0xFE97, 0xFE98}},
+	0x062B: {joinsDual, [4]rune{0xFE99, 0xFE9A, 0xFE9B, 0xFE9C}},
+	0x062C: {joinsDual, [4]rune{0xFE9D, 0xFE9E, 0xFE9F, 0xFEA0}},
+	0x062D: {joinsDual, [4]rune{0xFEA1, 0xFEA2, 0xFEA3, 0xFEA4}},
+	0x062E: {joinsDual, [4]rune{0xFEA5, 0xFEA6, 0xFEA7, 0xFEA8}},
+	0x062F: {joinsRight, [4]rune{0xFEA9, 0xFEAA}},
+	0x0630: {joinsRight, [4]rune{0xFEAB, 0xFEAC}},
+	0x0631: {joinsRight, [4]rune{0xFEAD, 0xFEAE}},
+	0x0632: {joinsRight, [4]rune{0xFEAF, 0xFEB0}},
+	0x0633: {joinsDual, [4]rune{0xFEB1, 0xFEB2, 0xFEB3, 0xFEB4}},
+	0x0634: {joinsDual, [4]rune{0xFEB5, 0xFEB6, 0xFEB7, 0xFEB8}},
+	0x0635: {joinsDual, [4]rune{0xFEB9, 0xFEBA, 0xFEBB, 0xFEBC}},
+	0x0636: {joinsDual, [4]rune{0xFEBD, 0xFEBE, 0xFEBF, 0xFEC0}},
+	0x0637: {joinsDual, [4]rune{0xFEC1, 0xFEC2, 0xFEC3, 0xFEC4}},
+	0x0638: {joinsDual, [4]rune{0xFEC5, 0xFEC6, 0xFEC7, 0xFEC8}},
+	0x0639: {joinsDual, [4]rune{0xFEC9, 0xFECA, 0xFECB, 0xFECC}},
+	0x063A: {joinsDual, [4]rune{0xFECD, 0xFECE, 0xFECF, 0xFED0}},
+	0x0640: {joining: joinsCausing},
+	0x0641: {joinsDual, [4]rune{0xFED1, 0xFED2, 0xFED3, 0xFED4}},
+	0x0642: {joinsDual, [4]rune{0xFED5, 0xFED6, 0xFED7, 0xFED8}},
+	0x0643: {joinsDual, [4]rune{0xFED9, 0xFEDA, 0xFEDB, 0xFEDC}},
+	0x0644: {joinsDual, [4]rune{0xFEDD, 0xFEDE, 0xFEDF, 0xFEE0}},
+	0x0645: {joinsDual, [4]rune{0xFEE1, 0xFEE2, 0xFEE3, 0xFEE4}},
+	0x0646: {joinsDual, [4]rune{0xFEE5, 0xFEE6, 0xFEE7, 0xFEE8}},
+	0x0647: {joinsDual, [4]rune{0xFEE9, 0xFEEA, 0xFEEB, 0xFEEC}},
+	0x0648: {joinsRight, [4]rune{0xFEED, 0xFEEE}},
+	0x0649: {joinsRight, [4]rune{0xFEEF, 0xFEF0}},
+	0x064A: {joinsDual, [4]rune{0xFEF1, 0xFEF2, 0xFEF3, 0xFEF4}},
+	0x067E: {joinsDual, [4]rune{0xFB56, 0xFB57, 0xFB58, 0xFB59}},
+	0x0686: {joinsDual, [4]rune{0xFB7A, 0xFB7B, 0xFB7C, 0xFB7D}},
+	0x0698: {joinsRight, [4]rune{0xFB8A, 0xFB8B}},
+	0x06A9: {joinsDual, [4]rune{0xFB8E, 0xFB8F, 0xFB90, 0xFB91}},
+	0x06AF: {joinsDual, [4]rune{0xFB92, 0xFB93, 0xFB94, 0xFB95}},
+	0x06CC: {joinsDual, [4]rune{0xFBFC, 0xFBFD, 0xFBFE, 0xFBFF}},
+}
+
+// lamAlefLigatures maps the alefs that lam forms a ligature with to the
+// isolated form of the ligature; the final form follows it
+var lamAlefLigatures = map[rune]rune{
+	0x0622: 0xFEF5,
+	0x0623: 0xFEF7,
+	0x0625: 0xFEF9,
+	0x0627: 0xFEFB,
+}
+
+// mirroredBrackets maps brackets to the ones drawn in right-to-left text
+var mirroredBrackets = map[rune]rune{
+	'(': ')', ')': '(',
+	'[': ']', ']': '[',
+	'{': '}', '}': '{',
+	'<': '>', '>': '<',
+	'«': '»', '»': '«',
+}
+
+// Bidirectional classes, reduced to those needed to order a line of plain
+// text without explicit direction controls
+const (
+	bidiNeutral = iota
+	bidiLeft
+	bidiRight
+	bidiNumber
+	bidiSeparator
+)
+
+// shapeText returns text as it must be drawn one character at a time from
+// left to right. Text without Arabic, Hebrew or Devanagari is returned as is.
+func shapeText(text string) string {
+	needed := false
+	for _, r := range text {
+		if unicode.In(r, unicode.Arabic, unicode.Hebrew, unicode.Devanagari) {
+			needed = true
+			break
+		}
+	}
+	if !needed {
+		return text
+	}
+
+	runes := reorderVowelSignI([]rune(text))
+	runes = shapeArabic(runes)
+	return string(reorderBidi(runes))
+}
+
+// reorderVowelSignI moves the Devanagari vowel sign I, which is written after
+// the consonant cluster it follows in speech, before that cluster
+func reorderVowelSignI(runes []rune) []rune {
+	clusterStart := -1
+	for i, r := range runes {
+		switch {
+		case r == 0x093F && clusterStart >= 0:
+			copy(runes[clusterStart+1:i+1], runes[clusterStart:i])
+			runes[clusterStart] = r
+			clusterStart = -1
+		case isDevanagariConsonant(r):
+			// A consonant after a virama belongs to the cluster before it
+			if i == 0 || runes[i-1] != 0x094D {
+				clusterStart = i
+			}
+		case r == 0x093C || r == 0x094D:
+			// Nukta and virama stay within the cluster
+		default:
+			clusterStart = -1
+		}
+	}
+	return runes
+}
+
+// isDevanagariConsonant reports whether r is a Devanagari consonant
+func isDevanagariConsonant(r rune) bool {
+	return (r >= 0x0915 && r <= 0x0939) || (r >= 0x0958 && r <= 0x095F) || r == 0x0978 || (r >= 0x0979 && r <= 0x097F)
+}
+
+// shapeArabic replaces Arabic letters by the presentation form for their
+// position in the word, and lam followed by alef by their ligature. Marks
+// such as harakat are skipped when looking for the neighbouring letters.
+func shapeArabic(runes []rune) []rune {
+	neighbour := func(i, step int) (arabicForm, bool) {
+		for i += step; i >= 0 && i < len(runes); i += step {
+			if unicode.Is(unicode.Mn, runes[i]) {
+				continue
+			}
+			form, ok := arabicForms[runes[i]]
+			return form, ok
+		}
+		return arabicForm{}, false
+	}
+	joinsNext := func(form arabicForm) bool { return form.joining == joinsDual || form.joining == joinsCausing }
+
+	shaped := make([]rune, 0, len(runes))
+	for i := 0; i < len(runes); i++ {
+		form, ok := arabicForms[runes[i]]
+		if !ok || form.joining == joinsCausing {
+			shaped = append(shaped, runes[i])
+			continue
+		}
+
+		previous, ok := neighbour(i, -1)
+		joinsPrevious := ok && joinsNext(previous)
+
+		// Lam and the alef after it are drawn as one ligature, which only
+		// joins the letter before it
+		if runes[i] == 0x0644 && i+1 < len(runes) {
+			if ligature, ok := lamAlefLigatures[runes[i+1]]; ok {
+				if joinsPrevious {
+					ligature++
+				}
+				shaped = append(shaped, ligature)
+				i++
+				continue
+			}
+		}
+
+		next, ok := neighbour(i, 1)
+		joinsFollowing := form.joining == joinsDual && ok && next.joining != 0
+
+		position := 0
+		switch {
+		case joinsPrevious && joinsFollowing:
+			position = 3
+		case joinsFollowing:
+			position = 2
+		case joinsPrevious:
+			position = 1
+		}
+		shaped = append(shaped, form.forms[position])
+	}
+	return shaped
+}
+
+// bidiClass returns the bidirectional class of r
+func bidiClass(r rune) int {
+	switch {
+	case unicode.IsDigit(r):
+		return bidiNumber
+	case r == '+' || r == '-' || r == ',' || r == '.' || r == '/' || r == ':':
+		return bidiSeparator
+	case unicode.In(r, unicode.Arabic, unicode.Hebrew, unicode.Syriac, unicode.Thaana) && !unicode.Is(unicode.Mn, r):
+		return bidiRight
+	case unicode.IsLetter(r):
+		return bidiLeft
+	}
+	return bidiNeutral
+}
+
+// reorderBidi puts a line of text in display order. It follows the Unicode
+// bidirectional algorithm for text without explicit direction controls: the
+// first strong character sets the direction of the line, numbers keep their
+// digits left to right, neutrals between characters of the same direction
+// take that direction, and brackets in right-to-left text are mirrored.
+func reorderBidi(runes []rune) []rune {
+	classes := make([]int, len(runes))
+	paragraph := bidiLeft
+	for i, r := range runes {
+		classes[i] = bidiClass(r)
+		if unicode.Is(unicode.Mn, r) && i > 0 {
+			// Marks take the class of the character they are on
+			classes[i] = classes[i-1]
+		}
+	}
+	for _, class := range classes {
+		if class == bidiLeft || class == bidiRight {
+			paragraph = class
+			break
+		}
+	}
+
+	// A separator between two digits is part of the number
+	for i := 1; i+1 < len(classes); i++ {
+		if classes[i] == bidiSeparator && classes[i-1] == bidiNumber && classes[i+1] == bidiNumber {
+			classes[i] = bidiNumber
+		}
+	}
+
+	// Numbers after left-to-right text are left-to-right text
+	strong := paragraph
+	for i, class := range classes {
+		switch class {
+		case bidiLeft, bidiRight:
+			strong = class
+		case bidiNumber:
+			if strong == bidiLeft {
+				classes[i] = bidiLeft
+			}
+		}
+	}
+
+	// Neutrals take the direction of the text around them when both sides
+	// agree, numbers counting as right-to-left, or the line's otherwise
+	direction := func(class int) int {
+		if class == bidiNumber {
+			return bidiRight
+		}
+		return class
+	}
+	for i := 0; i < len(classes); {
+		if classes[i] != bidiNeutral && classes[i] != bidiSeparator {
+			i++
+			continue
+		}
+		end := i
+		for end < len(classes) && (classes[end] == bidiNeutral || classes[end] == bidiSeparator) {
+			end++
+		}
+		before, after := paragraph, paragraph
+		if i > 0 {
+			before = direction(classes[i-1])
+		}
+		if end < len(classes) {
+			after = direction(classes[end])
+		}
+		resolved := paragraph
+		if before == after {
+			resolved = before
+		}
+		for ; i < end; i++ {
+			classes[i] = resolved
+		}
+	}
+
+	// Embedding levels: right-to-left text is odd, and numbers sit a level
+	// above the text around them so their digits stay in order
+	levels := make([]int, len(classes))
+	base := 0
+	if paragraph == bidiRight {
+		base = 1
+	}
+	highest := base
+	for i, class := range classes {
+		switch {
+		case base == 0 && class == bidiRight:
+			levels[i] = 1
+		case base == 0 && class == bidiNumber:
+			levels[i] = 2
+		case base == 1 && class != bidiRight:
+			levels[i] = 2
+		default:
+			levels[i] = base
+		}
+		if levels[i] > highest {
+			highest = levels[i]
+		}
+	}
+
+	// Reverse every run at or above each level, from the highest down to the
+	// lowest odd level
+	for level := highest; level >= 1; level-- {
+		for i := 0; i < len(runes); {
+			if levels[i] < level {
+				i++
+				continue
+			}
+			end := i
+			for end < len(runes) && levels[end] >= level {
+				end++
+			}
+			for a, b := i, end-1; a < b; a, b = a+1, b-1 {
+				runes[a], runes[b] = runes[b], runes[a]
+				levels[a], levels[b] = levels[b], levels[a]
+			}
+			i = end
+		}
+	}
+
+	for i := 0; i < len(runes); i++ {
+		if levels[i]%2 == 0 {
+			continue
+		}
+		if mirrored, ok := mirroredBrackets[runes[i]]; ok {
+			runes[i] = mirrored
+		}
+
+		// Marks are drawn over the character before them, so those reversed
+		// ahead of their letter go back after it
+		if unicode.Is(unicode.Mn, runes[i]) {
+			end := i
+			for end < len(runes) && levels[end]%2 == 1 && unicode.Is(unicode.Mn, runes[end]) {
+				end++
+			}
+			if end < len(runes) && levels[end]%2 == 1 {
+				letter := runes[end]
+				copy(runes[i+1:end+1], runes[i:end])
+				runes[i] = letter
+			}
+			i = end
+		}
+	}
+	return runes
+}
@@ -0,0 +1,46 @@
+package pdf
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestShapeText tests contextual forms, display order and the Devanagari
+// vowel sign I
+func TestShapeText(t *testing.T) {
+	testCases := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"latin", "José Müller-Øberg", "José Müller-Øberg"},
+		{"arabic_forms", "فاطمة الزهراء", "\u0621\uFE8D\uFEAE\uFEEB\uFEB0\uFEDF\uFE8D \uFE94\uFEE4\uFEC3\uFE8E\uFED3"},
+		{"lam_alef", "سلام", "\uFEE1\uFEFC\uFEB3"},
+		{"number_in_arabic", "شارع 12, دبي", "\uFEF2\uFE91\uFEA9 ,12 \uFEC9\uFEAD\uFE8E\uFEB7"},
+		{"arabic_in_latin", "Fatima (فاطمة)", "Fatima (\uFE94\uFEE4\uFEC3\uFE8E\uFED3)"},
+		{"mirrored_brackets", "(مريم)", "(\uFEE2\uFEF3\uFEAE\uFEE3)"},
+		{"mark_after_letter", "بُ", "\uFE8F\u064F"},
+		{"hebrew", "שלום", "םולש"},
+		{"vowel_sign_i", "किताब", "\u093F\u0915\u0924\u093E\u092C"},
+		{"vowel_sign_i_after_conjunct", "स्थिति", "\u093F\u0938\u094D\u0925\u093F\u0924"},
+		{"devanagari_without_i", "12, गांधी मार्ग", "12, गांधी मार्ग"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := shapeText(tc.text); got != tc.want {
+				t.Errorf("Expected %s, got %s", codePoints(tc.want), codePoints(got))
+			}
+		})
+	}
+}
+
+// codePoints formats text as its code points, which shows presentation forms
+// and order unambiguously
+func codePoints(text string) string {
+	var out string
+	for _, r := range text {
+		out += fmt.Sprintf("%04X ", r)
+	}
+	return out
+}
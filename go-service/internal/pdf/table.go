@@ -0,0 +1,122 @@
+package pdf
+
+import (
+	"fmt"
+)
+
+// tableColumn describes a single column of a report table
+type tableColumn struct {
+	title string
+	width float64
+}
+
+const (
+	tableRowHeight    = 7
+	tableHeaderHeight = 8
+)
+
+// pageCountAlias is drawn in place of the page count, which the PDF library
+// replaces once the document is drawn
+const pageCountAlias = "{nb}"
+
+// addPageNumbers prints "Page n of m" at the bottom of every page
+func (g *Generator) addPageNumbers() {
+	g.pdf.AliasNbPages(pageCountAlias)
+	g.pageNumbers = true
+}
+
+// addPageFooter draws the bottom of a page: the page number if the report
+// numbers its pages, the verification code if it is verifiable and the serial
+// number if it is official. The draft watermark is drawn here too, so it
+// lies over the content of the page.
+func (g *Generator) addPageFooter() {
+	if !g.pageNumbers && g.verification == nil && !g.draft && g.serial == "" {
+		return
+	}
+
+	// The footer can be drawn in the middle of a row, so keep its font
+	// from leaking into the row
+	current := g.font
+	defer g.setFont(current)
+
+	if g.draft {
+		g.addWatermark()
+	}
+	if g.verification != nil {
+		g.addVerification()
+	}
+	if g.serial != "" {
+		g.addSerialNumber()
+	}
+	if g.pageNumbers {
+		g.pdf.SetY(-15)
+		g.setFont(FontSpec{Family: "Arial", Style: "I", Size: 8})
+		g.cellFormat(0, 10, fmt.Sprintf("Page %d of %s", g.firstPage+g.pdf.PageNo(), pageCountAlias), "", 0, "C", false)
+	}
+}
+
+// addTable draws a table of rows, shading alternate rows. Rows flow onto as
+// many pages as needed and the header is repeated at the top of every page.
+// emptyMessage is shown in place of the rows when there are none.
+func (g *Generator) addTable(columns []tableColumn, rows [][]string, emptyMessage string) {
+	g.addTableHeader(columns)
+
+	if len(rows) == 0 {
+		g.setFont(FontSpec{Family: "Arial", Style: "I", Size: 10})
+		g.cellFormat(0, tableRowHeight, emptyMessage, "1", 1, "C", false)
+		return
+	}
+
+	for i, row := range rows {
+		// Break the page manually so the header can be repeated
+		if !g.rowFits() {
+			g.pdf.AddPage()
+			g.addTableHeader(columns)
+		}
+		g.addTableRow(columns, row, i%2 == 1)
+	}
+}
+
+// rowFits reports whether another table row fits on the current page
+func (g *Generator) rowFits() bool {
+	_, pageHeight := g.pdf.GetPageSize()
+	_, _, _, bottomMargin := g.pdf.GetMargins()
+	return g.pdf.GetY()+tableRowHeight <= pageHeight-bottomMargin
+}
+
+// addTableHeader draws a table header row in the accent color
+func (g *Generator) addTableHeader(columns []tableColumn) {
+	g.setFont(FontSpec{Family: "Arial", Style: "B", Size: 10})
+	g.setAccentFill()
+	for _, column := range columns {
+		g.cellFormat(column.width, tableHeaderHeight, column.title, "1", 0, "L", true)
+	}
+	g.resetColors()
+	g.pdf.Ln(-1)
+}
+
+// addTableRow draws a single table row, truncating values that do not fit
+func (g *Generator) addTableRow(columns []tableColumn, values []string, shaded bool) {
+	g.setFont(FontSpec{Family: "Arial", Style: "", Size: 9})
+	g.pdf.SetFillColor(245, 245, 245)
+	for i, column := range columns {
+		text := ""
+		if i < len(values) {
+			text = g.fitText(values[i], column.width-2)
+		}
+		g.cellFormat(column.width, tableRowHeight, text, "1", 0, "L", shaded)
+	}
+	g.pdf.Ln(-1)
+}
+
+// fitText truncates text with an ellipsis so it fits within width using the current font
+func (g *Generator) fitText(text string, width float64) string {
+	if g.textWidth(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && g.textWidth(string(runes)+"...") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
@@ -0,0 +1,397 @@
+package pdf
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-service/pkg/models"
+)
+
+// Names of the built-in report layouts
+const (
+	// DefaultTemplateName is the name of the built-in student report layout
+	DefaultTemplateName = "default"
+	// DefaultStaffTemplateName is the name of the built-in staff profile layout
+	DefaultStaffTemplateName = "staff"
+)
+
+// Kinds of report data a template can lay out
+const (
+	TemplateKindStudent = "student"
+	TemplateKindStaff   = "staff"
+)
+
+// templateKinds maps each template kind to its default layout name and an
+// empty value of the report data, used to validate field paths
+var templateKinds = map[string]struct {
+	defaultName string
+	sample      interface{}
+}{
+	TemplateKindStudent: {DefaultTemplateName, &models.Student{}},
+	TemplateKindStaff:   {DefaultStaffTemplateName, &models.Staff{}},
+}
+
+// builtinTemplates holds the report templates shipped with the service
+//
+//go:embed templates/*.json
+var builtinTemplates embed.FS
+
+// defaultTemplate is the built-in layout used when no template is selected
+var defaultTemplate = mustLoadBuiltinTemplate(DefaultTemplateName)
+
+// defaultStaffTemplate is the built-in layout for staff profiles
+var defaultStaffTemplate = mustLoadBuiltinTemplate(DefaultStaffTemplateName)
+
+// FontSpec selects a font for a part of the report
+type FontSpec struct {
+	Family string  `json:"family"`
+	Style  string  `json:"style"`
+	Size   float64 `json:"size"`
+}
+
+// TemplateFonts holds the fonts used for each part of the report
+type TemplateFonts struct {
+	Title         FontSpec `json:"title"`
+	Subtitle      FontSpec `json:"subtitle"`
+	SectionHeader FontSpec `json:"sectionHeader"`
+	Label         FontSpec `json:"label"`
+	Value         FontSpec `json:"value"`
+}
+
+// TemplateSpacing holds the vertical spacing and label column width, in mm
+type TemplateSpacing struct {
+	AfterTitle          float64 `json:"afterTitle"`
+	AfterSubtitle       float64 `json:"afterSubtitle"`
+	SectionHeaderHeight float64 `json:"sectionHeaderHeight"`
+	AfterSectionHeader  float64 `json:"afterSectionHeader"`
+	LabelWidth          float64 `json:"labelWidth"`
+	LineHeight          float64 `json:"lineHeight"`
+	FieldAdvance        float64 `json:"fieldAdvance"`
+	BetweenSections     float64 `json:"betweenSections"`
+}
+
+// TemplateField binds a label to a value at a JSON path of the report data
+type TemplateField struct {
+	Label string `json:"label"`
+	// Path is the dot-separated JSON path of the value, e.g. "fatherName"
+	Path string `json:"path"`
+	// Format is empty for plain values or "date" for timestamps
+	Format string `json:"format,omitempty"`
+}
+
+// TemplateSection is a titled group of fields
+type TemplateSection struct {
+	Title  string          `json:"title"`
+	Fields []TemplateField `json:"fields"`
+}
+
+// Template is a declarative report layout
+type Template struct {
+	Name string `json:"name"`
+	// Kind is the report data the template lays out, "student" (the default) or "staff"
+	Kind     string            `json:"kind"`
+	Title    string            `json:"title"`
+	Subtitle string            `json:"subtitle"`
+	Fonts    TemplateFonts     `json:"fonts"`
+	Spacing  TemplateSpacing   `json:"spacing"`
+	Sections []TemplateSection `json:"sections"`
+}
+
+// ParseTemplate decodes and validates a JSON report template. Fonts and
+// spacing left out of the template fall back to the default layout.
+func ParseTemplate(data []byte) (*Template, error) {
+	return parseTemplate(data, defaultTemplate)
+}
+
+// parseTemplate decodes and validates a template, filling unset fonts and
+// spacing from base if it is not nil
+func parseTemplate(data []byte, base *Template) (*Template, error) {
+	var tmpl Template
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&tmpl); err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+
+	if tmpl.Kind == "" {
+		tmpl.Kind = TemplateKindStudent
+	}
+	if base != nil {
+		tmpl.applyDefaults(base)
+	}
+
+	if err := tmpl.validate(); err != nil {
+		return nil, fmt.Errorf("invalid template %q: %w", tmpl.Name, err)
+	}
+
+	return &tmpl, nil
+}
+
+// applyDefaults fills unset fonts and spacing from base
+func (t *Template) applyDefaults(base *Template) {
+	fonts := []struct{ font, fallback *FontSpec }{
+		{&t.Fonts.Title, &base.Fonts.Title},
+		{&t.Fonts.Subtitle, &base.Fonts.Subtitle},
+		{&t.Fonts.SectionHeader, &base.Fonts.SectionHeader},
+		{&t.Fonts.Label, &base.Fonts.Label},
+		{&t.Fonts.Value, &base.Fonts.Value},
+	}
+	for _, f := range fonts {
+		// An omitted font inherits the style too; a partial one keeps its own style
+		if *f.font == (FontSpec{}) {
+			*f.font = *f.fallback
+			continue
+		}
+		if f.font.Family == "" {
+			f.font.Family = f.fallback.Family
+		}
+		if f.font.Size == 0 {
+			f.font.Size = f.fallback.Size
+		}
+	}
+
+	spacing := []struct{ value, fallback *float64 }{
+		{&t.Spacing.AfterTitle, &base.Spacing.AfterTitle},
+		{&t.Spacing.AfterSubtitle, &base.Spacing.AfterSubtitle},
+		{&t.Spacing.SectionHeaderHeight, &base.Spacing.SectionHeaderHeight},
+		{&t.Spacing.AfterSectionHeader, &base.Spacing.AfterSectionHeader},
+		{&t.Spacing.LabelWidth, &base.Spacing.LabelWidth},
+		{&t.Spacing.LineHeight, &base.Spacing.LineHeight},
+		{&t.Spacing.FieldAdvance, &base.Spacing.FieldAdvance},
+		{&t.Spacing.BetweenSections, &base.Spacing.BetweenSections},
+	}
+	for _, s := range spacing {
+		if *s.value == 0 {
+			*s.value = *s.fallback
+		}
+	}
+}
+
+// validate checks that the template is complete and that every field is
+// bound to a path that exists on the report data of its kind
+func (t *Template) validate() error {
+	if t.Name == "" {
+		return errors.New("name is required")
+	}
+	kind, ok := templateKinds[t.Kind]
+	if !ok {
+		return fmt.Errorf("unknown kind %q", t.Kind)
+	}
+	if len(t.Sections) == 0 {
+		return errors.New("at least one section is required")
+	}
+
+	sample, err := toFields(kind.sample)
+	if err != nil {
+		return err
+	}
+
+	for _, section := range t.Sections {
+		for _, field := range section.Fields {
+			if field.Label == "" || field.Path == "" {
+				return fmt.Errorf("section %q: fields need a label and a path", section.Title)
+			}
+			if _, ok := lookupPath(sample, field.Path); !ok {
+				return fmt.Errorf("section %q: unknown field path %q", section.Title, field.Path)
+			}
+			if field.Format != "" && field.Format != "date" {
+				return fmt.Errorf("section %q: unknown format %q for %q", section.Title, field.Format, field.Path)
+			}
+		}
+	}
+
+	return nil
+}
+
+// TemplateSet holds the report templates available for selection by name
+type TemplateSet struct {
+	templates map[string]*Template
+}
+
+// BuiltinTemplates returns a set containing only the templates shipped with the service
+func BuiltinTemplates() *TemplateSet {
+	return &TemplateSet{templates: map[string]*Template{
+		DefaultTemplateName:      defaultTemplate,
+		DefaultStaffTemplateName: defaultStaffTemplate,
+	}}
+}
+
+// LoadTemplates returns the built-in templates plus every *.json template in
+// dir. A template in dir with the same name as a built-in one of the same kind
+// replaces it. A missing dir is not an error.
+func LoadTemplates(dir string) (*TemplateSet, error) {
+	set := BuiltinTemplates()
+	if dir == "" {
+		return set, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", path, err)
+		}
+
+		tmpl, err := ParseTemplate(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		if builtin, ok := set.templates[tmpl.Name]; ok && builtin.Kind != tmpl.Kind {
+			return nil, fmt.Errorf("%s: template %q cannot replace the built-in %s template", filepath.Base(path), tmpl.Name, builtin.Kind)
+		}
+		set.templates[tmpl.Name] = tmpl
+	}
+
+	return set, nil
+}
+
+// Get returns the template of the given kind with the given name, or the
+// default template of that kind if name is empty
+func (s *TemplateSet) Get(kind, name string) (*Template, bool) {
+	if name == "" {
+		name = templateKinds[kind].defaultName
+	}
+	tmpl, ok := s.templates[name]
+	if !ok || tmpl.Kind != kind {
+		return nil, false
+	}
+	return tmpl, true
+}
+
+// FieldValue is a template field resolved against report data
+type FieldValue struct {
+	Section string
+	Label   string
+	Value   string
+}
+
+// Values resolves every field of the template against record, formatted as
+// the PDF shows them, in layout order
+func (t *Template) Values(record interface{}) ([]FieldValue, error) {
+	// Template fields are bound to the record's JSON paths
+	data, err := toFields(record)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []FieldValue
+	for _, section := range t.Sections {
+		for _, field := range section.Fields {
+			value, _ := lookupPath(data, field.Path)
+			values = append(values, FieldValue{Section: section.Title, Label: field.Label, Value: formatValue(value, field.Format)})
+		}
+	}
+	return values, nil
+}
+
+// renderTemplate draws the title and every section of the template with the
+// field values resolved by Values
+func (g *Generator) renderTemplate(values []FieldValue) {
+	t := g.template
+
+	// Title
+	g.setFont(t.Fonts.Title)
+	g.cell(0, 10, t.Title)
+	g.pdf.Ln(t.Spacing.AfterTitle)
+
+	// School Header, naming the school unless the template overrides it
+	subtitle := t.Subtitle
+	if subtitle == "" {
+		subtitle = g.schoolHeading()
+	}
+	if subtitle != "" {
+		g.setFont(t.Fonts.Subtitle)
+		g.cell(0, 8, subtitle)
+		g.pdf.Ln(t.Spacing.AfterSubtitle)
+	}
+
+	for i, section := range t.Sections {
+		if i > 0 {
+			g.pdf.Ln(t.Spacing.BetweenSections)
+		}
+
+		g.addSectionHeader(section.Title)
+		for _, field := range section.Fields {
+			g.addField(field.Label, values[0].Value)
+			values = values[1:]
+		}
+	}
+}
+
+// formatValue renders a JSON value for display
+func formatValue(value interface{}, format string) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case bool:
+		return formatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		if format == "date" {
+			if t, err := time.Parse(time.RFC3339, v); err == nil {
+				return formatDate(t)
+			}
+		}
+		return v
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// toFields converts report data to a generic JSON map so fields can be
+// resolved by their JSON path
+func toFields(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode report data: %w", err)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode report data: %w", err)
+	}
+	return fields, nil
+}
+
+// lookupPath resolves a dot-separated JSON path within fields
+func lookupPath(fields map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = fields
+	for _, key := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = object[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// mustLoadBuiltinTemplate parses a template embedded in the binary
+func mustLoadBuiltinTemplate(name string) *Template {
+	data, err := builtinTemplates.ReadFile("templates/" + name + ".json")
+	if err != nil {
+		panic(fmt.Sprintf("missing built-in template %s: %v", name, err))
+	}
+
+	tmpl, err := parseTemplate(data, nil)
+	if err != nil {
+		panic(fmt.Sprintf("invalid built-in template %s: %v", name, err))
+	}
+	return tmpl
+}
@@ -0,0 +1,265 @@
+package pdf
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-service/pkg/models"
+)
+
+// customTemplate is a minimal school-specific layout used by the tests
+const customTemplate = `{
+  "name": "compact",
+  "title": "PUPIL SUMMARY",
+  "fonts": { "label": { "family": "Times", "style": "I" } },
+  "spacing": { "labelWidth": 40 },
+  "sections": [
+    {
+      "title": "PUPIL",
+      "fields": [
+        { "label": "Pupil", "path": "name" },
+        { "label": "Born", "path": "dob", "format": "date" }
+      ]
+    }
+  ]
+}`
+
+// TestDefaultTemplateLayout tests that the built-in template renders the
+// original report sections in order
+func TestDefaultTemplateLayout(t *testing.T) {
+	generator := NewGenerator()
+	generator.pdf.SetCompression(false)
+
+	student := &models.Student{
+		ID:           7,
+		Name:         "Layout Student",
+		DOB:          time.Date(2005, 1, 15, 0, 0, 0, 0, time.UTC),
+		SystemAccess: true,
+	}
+
+	var buf bytes.Buffer
+	if err := generator.GenerateStudentReport(&buf, student); err != nil {
+		t.Fatalf("Expected PDF generation to succeed, got error: %v", err)
+	}
+	pdfBytes := buf.Bytes()
+
+	// Text is written uncompressed, so it can be found in the content stream
+	expected := []string{
+		"STUDENT REPORT",
+		"School Management System",
+		"STUDENT INFORMATION",
+		"Student ID:", "(7)",
+		"Full Name:", "Layout Student",
+		"Date of Birth:", "January 15, 2005",
+		"ACADEMIC INFORMATION",
+		"Admission Date:", "Not specified",
+		"System Access:", "Yes",
+		"FAMILY INFORMATION",
+		"ADDRESS INFORMATION",
+		"ADDITIONAL INFORMATION",
+		"Class Teacher/Reporter:",
+	}
+	offset := 0
+	for _, text := range expected {
+		index := bytes.Index(pdfBytes[offset:], []byte(text))
+		if index < 0 {
+			t.Fatalf("Expected %q to appear after offset %d in the report", text, offset)
+		}
+		offset += index + len(text)
+	}
+}
+
+// TestTemplateValues tests that template fields resolve to the values the
+// PDF shows, in layout order
+func TestTemplateValues(t *testing.T) {
+	tmpl, err := ParseTemplate([]byte(customTemplate))
+	if err != nil {
+		t.Fatalf("Expected template to parse, got error: %v", err)
+	}
+
+	values, err := tmpl.Values(&models.Student{Name: "Lisa Simpson", DOB: time.Date(2005, 1, 15, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("Expected values to resolve, got error: %v", err)
+	}
+
+	want := []FieldValue{
+		{Section: "PUPIL", Label: "Pupil", Value: "Lisa Simpson"},
+		{Section: "PUPIL", Label: "Born", Value: "January 15, 2005"},
+	}
+	if len(values) != len(want) {
+		t.Fatalf("Expected %d values, got %+v", len(want), values)
+	}
+	for i := range want {
+		if values[i] != want[i] {
+			t.Errorf("Expected %+v, got %+v", want[i], values[i])
+		}
+	}
+}
+
+// TestLoadTemplates tests loading templates from a directory
+func TestLoadTemplates(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "compact.json"), []byte(customTemplate), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	set, err := LoadTemplates(dir)
+	if err != nil {
+		t.Fatalf("Failed to load templates: %v", err)
+	}
+
+	if tmpl, ok := set.Get(TemplateKindStudent, ""); !ok || tmpl.Name != DefaultTemplateName {
+		t.Error("Expected the default template to be selected when no name is given")
+	}
+	if _, ok := set.Get(TemplateKindStudent, "missing"); ok {
+		t.Error("Expected unknown template names to be rejected")
+	}
+	if tmpl, ok := set.Get(TemplateKindStaff, ""); !ok || tmpl.Name != DefaultStaffTemplateName {
+		t.Error("Expected the staff template to be the default for staff reports")
+	}
+	if _, ok := set.Get(TemplateKindStaff, "compact"); ok {
+		t.Error("Expected student templates to be rejected for staff reports")
+	}
+
+	tmpl, ok := set.Get(TemplateKindStudent, "compact")
+	if !ok {
+		t.Fatal("Expected the compact template to be loaded")
+	}
+
+	// Unset fonts and spacing fall back to the default layout
+	if tmpl.Fonts.Label != (FontSpec{Family: "Times", Style: "I", Size: 10}) {
+		t.Errorf("Unexpected label font: %+v", tmpl.Fonts.Label)
+	}
+	if tmpl.Fonts.Value != defaultTemplate.Fonts.Value {
+		t.Errorf("Expected value font to default, got %+v", tmpl.Fonts.Value)
+	}
+	if tmpl.Spacing.LabelWidth != 40 || tmpl.Spacing.FieldAdvance != defaultTemplate.Spacing.FieldAdvance {
+		t.Errorf("Unexpected spacing: %+v", tmpl.Spacing)
+	}
+
+	generator := NewGenerator(WithTemplate(tmpl))
+	generator.pdf.SetCompression(false)
+	var buf bytes.Buffer
+	if err := generator.GenerateStudentReport(&buf, &models.Student{Name: "Custom Student"}); err != nil {
+		t.Fatalf("Expected PDF generation to succeed, got error: %v", err)
+	}
+	pdfBytes := buf.Bytes()
+	for _, text := range []string{"PUPIL SUMMARY", "Pupil:", "Custom Student"} {
+		if !bytes.Contains(pdfBytes, []byte(text)) {
+			t.Errorf("Expected %q in the custom report", text)
+		}
+	}
+	if bytes.Contains(pdfBytes, []byte("FAMILY INFORMATION")) {
+		t.Error("Expected sections of the default template to be left out")
+	}
+
+	// A built-in template cannot be replaced by one of another kind
+	if err := os.WriteFile(filepath.Join(dir, "staff.json"), []byte(`{"name":"staff","sections":[{"title":"A","fields":[{"label":"L","path":"name"}]}]}`), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	if _, err := LoadTemplates(dir); err == nil || !strings.Contains(err.Error(), "cannot replace") {
+		t.Errorf("Expected the staff template override to be rejected, got %v", err)
+	}
+
+	// A missing directory only provides the built-in templates
+	if _, err := LoadTemplates(filepath.Join(dir, "missing")); err != nil {
+		t.Errorf("Expected a missing directory to be ignored, got %v", err)
+	}
+}
+
+// TestParseTemplateValidation tests that invalid templates are rejected
+func TestParseTemplateValidation(t *testing.T) {
+	testCases := []struct {
+		name     string
+		template string
+		errorMsg string
+	}{
+		{"unknown_path", `{"name":"x","sections":[{"title":"A","fields":[{"label":"L","path":"shoeSize"}]}]}`, "unknown field path"},
+		{"unknown_format", `{"name":"x","sections":[{"title":"A","fields":[{"label":"L","path":"dob","format":"ago"}]}]}`, "unknown format"},
+		{"missing_name", `{"sections":[{"title":"A","fields":[{"label":"L","path":"name"}]}]}`, "name is required"},
+		{"no_sections", `{"name":"x"}`, "at least one section"},
+		{"unknown_key", `{"name":"x","colour":"red","sections":[]}`, "unknown field"},
+		{"unknown_kind", `{"name":"x","kind":"parent","sections":[{"title":"A","fields":[{"label":"L","path":"name"}]}]}`, "unknown kind"},
+		{"staff_path_on_student", `{"name":"x","sections":[{"title":"A","fields":[{"label":"L","path":"qualification"}]}]}`, "unknown field path"},
+		{"student_path_on_staff", `{"name":"x","kind":"staff","sections":[{"title":"A","fields":[{"label":"L","path":"roll"}]}]}`, "unknown field path"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseTemplate([]byte(tc.template))
+			if err == nil || !strings.Contains(err.Error(), tc.errorMsg) {
+				t.Errorf("Expected error containing %q, got %v", tc.errorMsg, err)
+			}
+		})
+	}
+}
+
+// TestStaffReport tests the built-in staff profile layout
+func TestStaffReport(t *testing.T) {
+	staff := &models.Staff{
+		ID:            7,
+		Name:          "Edna Krabappel",
+		RoleName:      "Teacher",
+		Department:    "Primary",
+		ReporterName:  "Seymour Skinner",
+		Qualification: "M.Ed.",
+		Experience:    "12 years",
+		JoinDate:      time.Date(2012, time.August, 20, 0, 0, 0, 0, time.UTC),
+	}
+
+	// A student template falls back to the staff layout
+	generator := NewGenerator(WithTemplate(defaultTemplate))
+	generator.pdf.SetCompression(false)
+	var buf bytes.Buffer
+	if err := generator.GenerateStaffReport(&buf, staff); err != nil {
+		t.Fatalf("Expected PDF generation to succeed, got error: %v", err)
+	}
+	pdfBytes := buf.Bytes()
+
+	expected := []string{
+		"STAFF PROFILE",
+		"PERSONAL INFORMATION",
+		"Edna Krabappel",
+		"EMPLOYMENT",
+		"Teacher",
+		"Primary",
+		"Seymour Skinner",
+		"August 20, 2012",
+		"QUALIFICATIONS",
+		"M.Ed.",
+		"12 years",
+	}
+	offset := 0
+	for _, text := range expected {
+		index := bytes.Index(pdfBytes[offset:], []byte(text))
+		if index < 0 {
+			t.Fatalf("Expected %q to appear after offset %d in the report", text, offset)
+		}
+		offset += index + len(text)
+	}
+	if bytes.Contains(pdfBytes, []byte("STUDENT")) {
+		t.Error("Expected no student sections in a staff report")
+	}
+}
+
+// TestLookupPath tests resolving dot-separated JSON paths
+func TestLookupPath(t *testing.T) {
+	fields := map[string]interface{}{
+		"name":    "Alice",
+		"address": map[string]interface{}{"city": "Springfield"},
+	}
+
+	if value, ok := lookupPath(fields, "address.city"); !ok || value != "Springfield" {
+		t.Errorf("Expected nested value, got %v", value)
+	}
+	if _, ok := lookupPath(fields, "name.first"); ok {
+		t.Error("Expected paths through non-objects to fail")
+	}
+	if _, ok := lookupPath(fields, "missing"); ok {
+		t.Error("Expected missing keys to fail")
+	}
+}
@@ -0,0 +1,149 @@
+package pdf
+
+import "strings"
+
+// registerFonts embeds the UTF-8 font families of the generator's font set
+func (g *Generator) registerFonts() {
+	// The page count alias must be known before fonts are registered so its
+	// digits are kept in the font subsets
+	g.pdf.AliasNbPages(pageCountAlias)
+
+	for _, family := range g.fonts.families {
+		for _, style := range fontStyles {
+			g.pdf.AddUTF8FontFromBytes(family.name, style, family.faces[style])
+		}
+	}
+}
+
+// setFont selects a font for the text drawn next. With a UTF-8 font set the
+// family is replaced by the default UTF-8 family, keeping style and size,
+// since core fonts can only encode Latin-1 text.
+func (g *Generator) setFont(font FontSpec) {
+	g.font = font
+	g.pdf.SetFont(g.resolveFamily(font.Family), font.Style, font.Size)
+}
+
+// resolveFamily returns the family actually used to draw text set in family
+func (g *Generator) resolveFamily(family string) string {
+	if g.fonts == nil {
+		return family
+	}
+	for _, f := range g.fonts.families {
+		if strings.EqualFold(f.name, family) {
+			return f.name
+		}
+	}
+	return defaultFontFamily
+}
+
+// useFamily switches the font family while keeping the current style and size
+func (g *Generator) useFamily(family string) {
+	g.pdf.SetFont(family, g.font.Style, g.font.Size)
+}
+
+// cell draws text in a cell of the given width without border or fill
+func (g *Generator) cell(w, h float64, text string) {
+	g.cellFormat(w, h, text, "", 0, "L", false)
+}
+
+// cellFormat draws text like gofpdf's CellFormat. Text is shaped and put in
+// display order (see shapeText), then split into runs drawn with the fallback
+// font for each script.
+func (g *Generator) cellFormat(w, h float64, text, border string, ln int, align string, fill bool) {
+	if g.fonts == nil {
+		g.pdf.CellFormat(w, h, g.translate(text), border, ln, align, fill, 0, "")
+		return
+	}
+
+	text = shapeText(text)
+	runs := g.fonts.splitRuns(text)
+	if len(runs) <= 1 {
+		if len(runs) == 1 {
+			g.useFamily(runs[0].Family)
+		}
+		g.pdf.CellFormat(w, h, text, border, ln, align, fill, 0, "")
+		g.setFont(g.font)
+		return
+	}
+
+	x := g.pdf.GetX()
+	if w == 0 {
+		pageWidth, _ := g.pdf.GetPageSize()
+		_, _, rightMargin, _ := g.pdf.GetMargins()
+		w = pageWidth - rightMargin - x
+	}
+
+	// Draw the border and background first, then the runs on top of it
+	g.pdf.CellFormat(w, h, "", border, 0, "", fill, 0, "")
+	y := g.pdf.GetY()
+
+	margin := g.pdf.GetCellMargin()
+	start := x + margin
+	switch {
+	case strings.Contains(align, "C"):
+		start = x + (w-g.runsWidth(runs))/2
+	case strings.Contains(align, "R"):
+		start = x + w - margin - g.runsWidth(runs)
+	}
+
+	g.pdf.SetCellMargin(0)
+	g.pdf.SetXY(start, y)
+	for _, run := range runs {
+		g.useFamily(run.Family)
+		g.pdf.CellFormat(g.pdf.GetStringWidth(run.Text), h, run.Text, "", 0, "L", false, 0, "")
+	}
+	g.pdf.SetCellMargin(margin)
+	g.setFont(g.font)
+
+	// Leave the position where CellFormat would have
+	switch ln {
+	case 0:
+		g.pdf.SetXY(x+w, y)
+	case 1:
+		leftMargin, _, _, _ := g.pdf.GetMargins()
+		g.pdf.SetXY(leftMargin, y+h)
+	default:
+		g.pdf.SetXY(x, y+h)
+	}
+}
+
+// textWidth returns the width of text drawn with the current font
+func (g *Generator) textWidth(text string) float64 {
+	if g.fonts == nil {
+		return g.pdf.GetStringWidth(g.translate(text))
+	}
+
+	return g.runsWidth(g.fonts.splitRuns(shapeText(text)))
+}
+
+// runsWidth returns the width of runs drawn with the current font style and size
+func (g *Generator) runsWidth(runs []textRun) float64 {
+	width := 0.0
+	for _, run := range runs {
+		g.useFamily(run.Family)
+		width += g.pdf.GetStringWidth(run.Text)
+	}
+	g.setFont(g.font)
+	return width
+}
+
+// wrapText breaks text into lines no wider than width at word boundaries
+func (g *Generator) wrapText(text string, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if line != "" && g.textWidth(candidate) > width {
+				lines = append(lines, line)
+				candidate = word
+			}
+			line = candidate
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,95 @@
+package pdf
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/boombuler/barcode/qr"
+)
+
+const (
+	// verificationQRSize is the width and height of the verification QR code
+	// in mm
+	verificationQRSize = 18
+	// verificationMargin is the bottom margin of a verifiable report, which
+	// leaves room for the QR code below the content
+	verificationMargin = 32
+)
+
+// Verification identifies an issued report so a printed copy can be checked:
+// the ID is printed on every page next to a QR code of the URL it is
+// verified at
+type Verification struct {
+	ID  string
+	URL string
+}
+
+// WithVerification prints a verification ID and QR code on every page
+func WithVerification(verification Verification) Option {
+	return func(g *Generator) {
+		if verification.ID != "" {
+			g.verification = &verification
+		}
+	}
+}
+
+// applyVerification makes room for the verification code at the bottom of
+// every page of a verifiable report
+func (g *Generator) applyVerification() {
+	if g.verification != nil {
+		g.pdf.SetAutoPageBreak(true, verificationMargin)
+	}
+}
+
+// addVerification draws the QR code in the bottom right corner of the page
+// with the verification ID and URL to its left
+func (g *Generator) addVerification() {
+	pageWidth, pageHeight := g.pdf.GetPageSize()
+	leftMargin, _, rightMargin, _ := g.pdf.GetMargins()
+	qrX := pageWidth - rightMargin - verificationQRSize
+	qrY := pageHeight - verificationMargin + 4
+
+	if err := g.drawQRCode(g.verification.URL, qrX, qrY, verificationQRSize); err != nil {
+		fmt.Printf("Error drawing verification code %s: %v\n", g.verification.ID, err)
+	}
+
+	width := qrX - leftMargin - 2
+	g.pdf.SetXY(leftMargin, qrY+verificationQRSize-13)
+	g.setFont(FontSpec{Family: "Arial", Style: "B", Size: 8})
+	g.cellFormat(width, 4, "Verification ID: "+g.verification.ID, "", 2, "R", false)
+	g.setFont(FontSpec{Family: "Arial", Style: "", Size: 7})
+	g.cellFormat(width, 3.5, "Scan the code or visit", "", 2, "R", false)
+	g.cellFormat(width, 3.5, g.verification.URL, "", 2, "R", false)
+}
+
+// drawQRCode draws content as a QR code of the given size, one filled
+// rectangle per run of dark modules in a row
+func (g *Generator) drawQRCode(content string, x, y, size float64) error {
+	code, err := qr.Encode(content, qr.M, qr.Auto)
+	if err != nil {
+		return err
+	}
+
+	modules := code.Bounds().Dx()
+	module := size / float64(modules)
+	g.pdf.SetFillColor(0, 0, 0)
+	for row := 0; row < modules; row++ {
+		for col := 0; col < modules; {
+			if !isDark(code.At(col, row)) {
+				col++
+				continue
+			}
+			start := col
+			for col < modules && isDark(code.At(col, row)) {
+				col++
+			}
+			g.pdf.Rect(x+float64(start)*module, y+float64(row)*module, float64(col-start)*module, module, "F")
+		}
+	}
+	return nil
+}
+
+// isDark reports whether a QR code module is dark
+func isDark(c color.Color) bool {
+	return color.GrayModel.Convert(c).(color.Gray).Y < 128
+}
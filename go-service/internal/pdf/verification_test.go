@@ -0,0 +1,63 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"go-service/pkg/models"
+)
+
+// TestVerificationOnEveryPage tests that a verifiable report prints its
+// verification ID and QR code on every page
+func TestVerificationOnEveryPage(t *testing.T) {
+	generator := NewGenerator(WithVerification(Verification{ID: "8KQ4M-ZT2XH", URL: "https://reports.example.com/api/v1/verify/8KQ4M-ZT2XH"}))
+	generator.pdf.SetCompression(false)
+
+	students := make([]models.Student, 120)
+	for i := range students {
+		students[i] = models.Student{ID: i + 1, Name: fmt.Sprintf("Student %03d", i+1), Roll: i + 1}
+	}
+
+	var buf bytes.Buffer
+	if err := generator.GenerateClassRoster(&buf, "Grade 10", "A", students); err != nil {
+		t.Fatalf("Expected roster generation to succeed, got error: %v", err)
+	}
+
+	pages := generator.pdf.PageNo()
+	if count := bytes.Count(buf.Bytes(), []byte("Verification ID: 8KQ4M-ZT2XH")); count != pages {
+		t.Errorf("Expected the verification ID on all %d pages, got %d", pages, count)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("https://reports.example.com/api/v1/verify/8KQ4M-ZT2XH")) {
+		t.Error("Expected the verification URL to be printed")
+	}
+	// The QR code is drawn as filled rectangles (re and f operators)
+	if !bytes.Contains(buf.Bytes(), []byte(" re f")) {
+		t.Error("Expected the QR code to be drawn")
+	}
+	if _, _, _, bottom := generator.pdf.GetMargins(); bottom != verificationMargin {
+		t.Errorf("Expected the bottom margin to leave room for the QR code, got %.0fmm", bottom)
+	}
+}
+
+// TestDrawQRCode tests that the QR code has the finder pattern in its top
+// left corner: a ring of seven dark modules around a light one
+func TestDrawQRCode(t *testing.T) {
+	generator := NewGenerator()
+	generator.pdf.SetCompression(false)
+	generator.pdf.AddPage()
+
+	if err := generator.drawQRCode("https://reports.example.com/api/v1/verify/8KQ4M-ZT2XH", 10, 10, 33); err != nil {
+		t.Fatalf("Expected the QR code to be drawn, got error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := generator.pdf.Output(&buf); err != nil {
+		t.Fatalf("Failed to write PDF: %v", err)
+	}
+	// A version 4 code has 33 modules, so at 33mm the first row starts with
+	// a 7mm run of the finder pattern
+	if !bytes.Contains(buf.Bytes(), []byte("28.35 813.54 19.84 -2.83 re f")) {
+		t.Error("Expected the top edge of the finder pattern as one 7mm run")
+	}
+}
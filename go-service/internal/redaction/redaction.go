@@ -0,0 +1,190 @@
+// Package redaction hides the student fields a caller's role may not see,
+// such as guardian phone numbers and home addresses, before a report is
+// rendered. A policy holds a rule per role, which masks or removes fields.
+package redaction
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"go-service/pkg/models"
+)
+
+// maskVisible is the number of trailing letters and digits a masked value
+// keeps, so a masked phone number can still be told apart from others
+const maskVisible = 4
+
+// ContactFields are the student fields holding the phone numbers and
+// addresses of a student and their family
+var ContactFields = []string{
+	"phone", "fatherPhone", "motherPhone", "guardianPhone", "currentAddress", "permanentAddress",
+}
+
+// studentFields maps the JSON name of each student field to its index
+var studentFields = func() map[string]int {
+	fields := make(map[string]int)
+	studentType := reflect.TypeOf(models.Student{})
+	for i := 0; i < studentType.NumField(); i++ {
+		name, _, _ := strings.Cut(studentType.Field(i).Tag.Get("json"), ",")
+		fields[name] = i
+	}
+	return fields
+}()
+
+// Rule masks and removes fields of the students a role sees
+type Rule struct {
+	// Role names the role the rule applies to
+	Role string `json:"role"`
+	// Permission identifies the role by a Node.js API permission it holds,
+	// such as GET /api/v1/students/:id
+	Permission string `json:"permission"`
+	// Mask lists the JSON names of text fields shown with all but their last
+	// few letters and digits hidden
+	Mask []string `json:"mask"`
+	// Remove lists the JSON names of fields left empty
+	Remove []string `json:"remove"`
+}
+
+// Policy selects the redaction rule of a caller by the permissions of their
+// role
+type Policy struct {
+	// Roles are matched in order, so roles that see more come first
+	Roles []Rule `json:"roles"`
+	// Default applies to callers whose role matches none of Roles, including
+	// roles without any permissions
+	Default Rule `json:"default"`
+}
+
+// LoadPolicy reads and validates a JSON redaction policy
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redaction policy: %w", err)
+	}
+
+	policy, err := ParsePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redaction policy %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+// ParsePolicy decodes and validates a JSON redaction policy
+func ParsePolicy(data []byte) (*Policy, error) {
+	var policy Policy
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&policy); err != nil {
+		return nil, err
+	}
+
+	for _, rule := range policy.Roles {
+		if rule.Role == "" {
+			return nil, errors.New("roles need a name")
+		}
+		if method, path, ok := strings.Cut(rule.Permission, " "); !ok || method == "" || !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("role %q: permission must be a method and path, such as GET /api/v1/students/:id", rule.Role)
+		}
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("role %q: %w", rule.Role, err)
+		}
+	}
+	if err := policy.Default.validate(); err != nil {
+		return nil, fmt.Errorf("default: %w", err)
+	}
+
+	if policy.Default.Role == "" {
+		policy.Default.Role = "default"
+	}
+	return &policy, nil
+}
+
+// Restrictive returns the policy that removes the ContactFields of every
+// student for every caller
+func Restrictive() *Policy {
+	return &Policy{Default: Rule{Role: "default", Remove: ContactFields}}
+}
+
+// validate checks that the fields of a rule exist and that masked fields
+// hold text
+func (r *Rule) validate() error {
+	for _, name := range r.Mask {
+		index, ok := studentFields[name]
+		if !ok {
+			return fmt.Errorf("unknown student field %q", name)
+		}
+		if reflect.TypeOf(models.Student{}).Field(index).Type.Kind() != reflect.String {
+			return fmt.Errorf("student field %q is not text and cannot be masked", name)
+		}
+	}
+	for _, name := range r.Remove {
+		if _, ok := studentFields[name]; !ok {
+			return fmt.Errorf("unknown student field %q", name)
+		}
+	}
+	return nil
+}
+
+// Select returns the rule of the first role whose permission is among
+// permissions, or the default rule. permissions is nil for a role without
+// any.
+func (p *Policy) Select(permissions *models.Permissions) *Rule {
+	if permissions != nil {
+		for i, rule := range p.Roles {
+			method, path, _ := strings.Cut(rule.Permission, " ")
+			if permissions.AllowsAPI(method, path) {
+				return &p.Roles[i]
+			}
+		}
+	}
+	return &p.Default
+}
+
+// Apply masks and removes the fields of student the rule covers. A nil rule
+// leaves the student as it is.
+func (r *Rule) Apply(student *models.Student) {
+	if r == nil {
+		return
+	}
+
+	value := reflect.ValueOf(student).Elem()
+	for _, name := range r.Mask {
+		field := value.Field(studentFields[name])
+		field.SetString(mask(field.String()))
+	}
+	for _, name := range r.Remove {
+		value.Field(studentFields[name]).SetZero()
+	}
+}
+
+// mask hides the letters and digits of a value but for the last few, at most
+// half of them, keeping its punctuation and spacing so a masked phone number
+// still reads as one
+func mask(text string) string {
+	runes := []rune(text)
+	count := 0
+	for _, r := range runes {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			count++
+		}
+	}
+	visible := min(maskVisible, count/2)
+
+	for i := len(runes) - 1; i >= 0; i-- {
+		if !unicode.IsLetter(runes[i]) && !unicode.IsDigit(runes[i]) {
+			continue
+		}
+		if visible > 0 {
+			visible--
+			continue
+		}
+		runes[i] = '*'
+	}
+	return string(runes)
+}
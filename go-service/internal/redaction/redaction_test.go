@@ -0,0 +1,159 @@
+package redaction
+
+import (
+	"testing"
+	"time"
+
+	"go-service/pkg/models"
+)
+
+// testPolicy lets admins see everything, masks phone numbers and removes
+// addresses for teachers, and removes every contact field for anyone else
+const testPolicy = `{
+	"roles": [
+		{"role": "admin", "permission": "PUT /api/v1/students/:id"},
+		{
+			"role": "teacher",
+			"permission": "GET /api/v1/students/:id",
+			"mask": ["fatherPhone", "motherPhone", "guardianPhone"],
+			"remove": ["currentAddress", "permanentAddress"]
+		}
+	],
+	"default": {"remove": ["phone", "fatherPhone", "motherPhone", "guardianPhone", "currentAddress", "permanentAddress", "dob"]}
+}`
+
+// permissions returns the API permissions of the mock roles
+func permissions(role string) *models.Permissions {
+	apis := map[string][]models.AccessControl{
+		"admin": {
+			{Path: "/api/v1/students/:id", Method: "GET"},
+			{Path: "/api/v1/students/:id", Method: "PUT"},
+		},
+		"teacher": {
+			{Path: "/api/v1/students", Method: "GET"},
+			{Path: "/api/v1/students/:id", Method: "GET"},
+		},
+	}
+	if _, ok := apis[role]; !ok {
+		// The Node.js API has no permissions for the role
+		return nil
+	}
+	return &models.Permissions{APIs: apis[role]}
+}
+
+// sampleStudent returns a student with every contact field set
+func sampleStudent() *models.Student {
+	return &models.Student{
+		ID:               2,
+		Name:             "Alice Johnson",
+		Phone:            "555-0102",
+		DOB:              time.Date(2005, 8, 15, 0, 0, 0, 0, time.UTC),
+		FatherPhone:      "555-0103",
+		MotherPhone:      "555-0104",
+		GuardianPhone:    "555-0103",
+		CurrentAddress:   "456 Oak Ave, Springfield, IL 62701",
+		PermanentAddress: "456 Oak Ave, Springfield, IL 62701",
+	}
+}
+
+func TestRedactionPerRole(t *testing.T) {
+	policy, err := ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+
+	t.Run("admin", func(t *testing.T) {
+		rule := policy.Select(permissions("admin"))
+		if rule.Role != "admin" {
+			t.Fatalf("Expected the admin rule, got %q", rule.Role)
+		}
+		student := sampleStudent()
+		rule.Apply(student)
+		if *student != *sampleStudent() {
+			t.Errorf("Expected admins to see every field, got %+v", student)
+		}
+	})
+
+	t.Run("teacher", func(t *testing.T) {
+		rule := policy.Select(permissions("teacher"))
+		if rule.Role != "teacher" {
+			t.Fatalf("Expected the teacher rule, got %q", rule.Role)
+		}
+		student := sampleStudent()
+		rule.Apply(student)
+		if student.FatherPhone != "***-*103" || student.MotherPhone != "***-*104" || student.GuardianPhone != "***-*103" {
+			t.Errorf("Expected guardian phones to be masked, got %q, %q, %q", student.FatherPhone, student.MotherPhone, student.GuardianPhone)
+		}
+		if student.CurrentAddress != "" || student.PermanentAddress != "" {
+			t.Errorf("Expected addresses to be removed, got %q, %q", student.CurrentAddress, student.PermanentAddress)
+		}
+		if student.Phone != "555-0102" || student.Name != "Alice Johnson" {
+			t.Errorf("Expected other fields to be left as they are, got %+v", student)
+		}
+	})
+
+	t.Run("student", func(t *testing.T) {
+		rule := policy.Select(permissions("student"))
+		if rule.Role != "default" {
+			t.Fatalf("Expected the default rule, got %q", rule.Role)
+		}
+		student := sampleStudent()
+		rule.Apply(student)
+		if student.Phone != "" || student.FatherPhone != "" || student.CurrentAddress != "" || !student.DOB.IsZero() {
+			t.Errorf("Expected every contact field to be removed, got %+v", student)
+		}
+		if student.Name != "Alice Johnson" {
+			t.Errorf("Expected the name to be kept, got %q", student.Name)
+		}
+	})
+}
+
+func TestRestrictivePolicy(t *testing.T) {
+	student := sampleStudent()
+	Restrictive().Select(permissions("admin")).Apply(student)
+	for _, value := range []string{student.Phone, student.FatherPhone, student.MotherPhone, student.GuardianPhone, student.CurrentAddress, student.PermanentAddress} {
+		if value != "" {
+			t.Errorf("Expected every contact field to be removed, got %+v", student)
+			break
+		}
+	}
+}
+
+func TestNilRuleKeepsStudent(t *testing.T) {
+	var rule *Rule
+	student := sampleStudent()
+	rule.Apply(student)
+	if *student != *sampleStudent() {
+		t.Errorf("Expected a nil rule to keep the student, got %+v", student)
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := map[string]string{
+		"555-0103":         "***-*103",
+		"+1 (555) 010-3":   "+* (***) 010-3",
+		"42":               "*2",
+		"":                 "",
+		"Flat 3, Oak Lane": "**** *, *** Lane",
+	}
+	for value, want := range tests {
+		if got := mask(value); got != want {
+			t.Errorf("mask(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestParsePolicyErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":      `{"default": {"remove": ["fatherEmail"]}}`,
+		"masked date":        `{"default": {"mask": ["dob"]}}`,
+		"missing permission": `{"roles": [{"role": "teacher"}]}`,
+		"unnamed role":       `{"roles": [{"permission": "GET /api/v1/students/:id"}]}`,
+		"unknown key":        `{"default": {"hide": ["phone"]}}`,
+	}
+	for name, policy := range tests {
+		if _, err := ParsePolicy([]byte(policy)); err == nil {
+			t.Errorf("%s: expected policy %s to be rejected", name, policy)
+		}
+	}
+}
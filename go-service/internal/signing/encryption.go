@@ -0,0 +1,47 @@
+package signing
+
+import (
+	"regexp"
+	"strconv"
+
+	"go-service/internal/encryption"
+)
+
+var (
+	// encryptPattern matches the reference to the encryption dictionary in a
+	// trailer
+	encryptPattern = regexp.MustCompile(`/Encrypt (\d+) 0 R`)
+	// idPattern matches the file identifier in a trailer
+	idPattern = regexp.MustCompile(`/ID\s*\[[^\]]*\]`)
+)
+
+// encryptedDocument encrypts the strings of objects added to a document
+// encrypted with AES-256, as protected reports are
+type encryptedDocument struct {
+	// number is the object number of the encryption dictionary
+	number int
+	// id is the file identifier, which is repeated in every trailer
+	id     string
+	cipher *encryption.Cipher
+}
+
+// newEncryptedDocument returns the encryption of a document whose trailer
+// refers to the encryption dictionary, unlocked with the user password. It
+// returns nil for a document that is not encrypted.
+func newEncryptedDocument(document []byte, offsets map[int]int, trailer []byte, password string) (*encryptedDocument, error) {
+	match := encryptPattern.FindSubmatch(trailer)
+	if match == nil {
+		return nil, nil
+	}
+	number, _ := strconv.Atoi(string(match[1]))
+	dict, err := object(document, offsets, number)
+	if err != nil {
+		return nil, err
+	}
+
+	cipher, err := encryption.Open(dict, password)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedDocument{number: number, id: string(idPattern.Find(trailer)), cipher: cipher}, nil
+}
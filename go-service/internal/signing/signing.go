@@ -0,0 +1,356 @@
+// Package signing applies and checks PKCS#7 detached signatures embedded in
+// PDF documents (the adbe.pkcs7.detached sub-filter), which make a report
+// tamper-evident: any change to the signed bytes invalidates the signature.
+package signing
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/digitorus/pkcs7"
+)
+
+// signatureSize is the space reserved in a PDF for the DER encoded
+// signature, which holds the signer's certificate chain
+const signatureSize = 16384
+
+// byteRangeWidth is the space reserved for the byte range array, which is
+// filled in once the offsets of the signature are known
+const byteRangeWidth = 48
+
+var (
+	// startXrefPattern matches the offset of the last cross-reference table
+	startXrefPattern = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+	// trailerPattern matches an entry of the trailer dictionary
+	trailerPattern = regexp.MustCompile(`/(Size|Root|Info) (\d+)`)
+	// kidsPattern matches the first page of the page tree
+	kidsPattern = regexp.MustCompile(`/Kids \[(\d+) 0 R`)
+)
+
+// Signer signs PDFs with a certificate and its private key
+type Signer struct {
+	cert *x509.Certificate
+	// chain holds the certificates issuing cert, which are embedded in
+	// signatures so they can be verified with only the root
+	chain []*x509.Certificate
+	key   crypto.Signer
+}
+
+// LoadSigner reads a PEM certificate file, which may be followed by the
+// certificates of the chain issuing it, and the PEM private key of the
+// certificate
+func LoadSigner(certFile, keyFile string) (*Signer, error) {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	certs, err := parseCertificates(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing certificate: %w", err)
+	}
+	key, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key: %w", err)
+	}
+
+	type publicKey interface{ Equal(crypto.PublicKey) bool }
+	if public, ok := key.Public().(publicKey); !ok || !public.Equal(certs[0].PublicKey) {
+		return nil, errors.New("signing key does not match the certificate")
+	}
+
+	return &Signer{cert: certs[0], chain: certs[1:], key: key}, nil
+}
+
+// Name returns the common name of the signing certificate
+func (s *Signer) Name() string {
+	return s.cert.Subject.CommonName
+}
+
+// Sign writes document to w followed by an incremental update adding an
+// invisible signature field that signs the whole file. document must be a
+// complete PDF with a classic cross-reference table, as the generator
+// writes them. password is the user password of an encrypted document, whose
+// key the signature field is encrypted with; it is ignored for documents
+// that are not encrypted.
+func (s *Signer) Sign(w io.Writer, document []byte, signedAt time.Time, password string) error {
+	update, err := signatureUpdate(document, s.Name(), signedAt, password)
+	if err != nil {
+		return err
+	}
+
+	// The signature covers everything but the hex string it is written into
+	file := append(append(make([]byte, 0, len(document)+len(update)), document...), update...)
+	contents := bytes.LastIndex(file, []byte("/Contents <")) + len("/Contents ")
+	contentsEnd := contents + 2*signatureSize + 2
+	byteRange := fmt.Sprintf("[0 %d %d %d]", contents, contentsEnd, len(file)-contentsEnd)
+	placeholder := bytes.LastIndex(file, []byte("/ByteRange ")) + len("/ByteRange ")
+	copy(file[placeholder:placeholder+byteRangeWidth], byteRange+strings.Repeat(" ", byteRangeWidth-len(byteRange)))
+
+	signed, err := pkcs7.NewSignedData(append(file[:contents:contents], file[contentsEnd:]...))
+	if err != nil {
+		return fmt.Errorf("failed to sign PDF: %w", err)
+	}
+	signed.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
+	if err := signed.AddSignerChain(s.cert, s.key, s.chain, pkcs7.SignerInfoConfig{}); err != nil {
+		return fmt.Errorf("failed to sign PDF: %w", err)
+	}
+	signed.Detach()
+	signature, err := signed.Finish()
+	if err != nil {
+		return fmt.Errorf("failed to sign PDF: %w", err)
+	}
+	if len(signature) > signatureSize {
+		return fmt.Errorf("signature of %d bytes does not fit the %d reserved", len(signature), signatureSize)
+	}
+	hex.Encode(file[contents+1:], signature)
+
+	_, err = w.Write(file)
+	return err
+}
+
+// signatureUpdate returns the incremental update that adds a signature field
+// to document: the signature dictionary with placeholders for the byte range
+// and signature, the field's widget on the first page, and the page and
+// catalog updated to reference it
+func signatureUpdate(document []byte, name string, signedAt time.Time, password string) ([]byte, error) {
+	match := startXrefPattern.FindSubmatch(document)
+	if match == nil {
+		return nil, errors.New("PDF has no cross-reference table")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if xref >= len(document) {
+		return nil, errors.New("PDF cross-reference table is out of range")
+	}
+	offsets, err := objectOffsets(document, xref)
+	if err != nil {
+		return nil, err
+	}
+
+	trailer := make(map[string]int)
+	for _, entry := range trailerPattern.FindAllSubmatch(document[xref:], -1) {
+		trailer[string(entry[1])], _ = strconv.Atoi(string(entry[2]))
+	}
+	size, root, info := trailer["Size"], trailer["Root"], trailer["Info"]
+	if size == 0 || root == 0 {
+		return nil, errors.New("PDF trailer has no catalog")
+	}
+	encrypted, err := newEncryptedDocument(document, offsets, document[xref:], password)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := object(document, offsets, root)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := object(document, offsets, referencedObject(catalog, "/Pages"))
+	if err != nil {
+		return nil, err
+	}
+	kids := kidsPattern.FindSubmatch(pages)
+	if kids == nil {
+		return nil, errors.New("PDF has no pages")
+	}
+	pageNumber, _ := strconv.Atoi(string(kids[1]))
+	page, err := object(document, offsets, pageNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	sigNumber, fieldNumber := size, size+1
+	// The strings of an encrypted document are encrypted, except for the
+	// signature itself
+	texts := []string{pdfDate(signedAt), name, "Signature1"}
+	for i, value := range texts {
+		texts[i] = pdfString(value)
+		if encrypted != nil {
+			if texts[i], err = encrypted.cipher.EncryptString([]byte(value)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	field := fmt.Sprintf("%d 0 R", fieldNumber)
+	objects := map[int]string{
+		sigNumber: fmt.Sprintf("<</Type /Sig /Filter /Adobe.PPKLite /SubFilter /adbe.pkcs7.detached /ByteRange %s /Contents <%s> /M %s /Name %s>>",
+			strings.Repeat(" ", byteRangeWidth), strings.Repeat("0", 2*signatureSize), texts[0], texts[1]),
+		fieldNumber: fmt.Sprintf("<</Type /Annot /Subtype /Widget /FT /Sig /T %s /V %d 0 R /F 132 /Rect [0 0 0 0] /P %d 0 R>>",
+			texts[2], sigNumber, pageNumber),
+		pageNumber: string(appendArrayEntry(page, "/Annots", field)),
+		root:       string(appendEntry(catalog, "/AcroForm", fmt.Sprintf("<</Fields [%s] /SigFlags 3>>", field))),
+	}
+
+	var update bytes.Buffer
+	update.WriteString("\n")
+	written := make(map[int]int)
+	for _, number := range []int{pageNumber, root, sigNumber, fieldNumber} {
+		written[number] = len(document) + update.Len()
+		fmt.Fprintf(&update, "%d 0 obj\n%s\nendobj\n", number, objects[number])
+	}
+
+	xrefOffset := len(document) + update.Len()
+	update.WriteString("xref\n0 1\n0000000000 65535 f \n")
+	for _, number := range []int{pageNumber, root} {
+		fmt.Fprintf(&update, "%d 1\n%010d 00000 n \n", number, written[number])
+	}
+	fmt.Fprintf(&update, "%d 2\n%010d 00000 n \n%010d 00000 n \n", sigNumber, written[sigNumber], written[fieldNumber])
+	fmt.Fprintf(&update, "trailer\n<<\n/Size %d\n/Root %d 0 R\n", size+2, root)
+	if info != 0 {
+		fmt.Fprintf(&update, "/Info %d 0 R\n", info)
+	}
+	if encrypted != nil {
+		fmt.Fprintf(&update, "/Encrypt %d 0 R\n%s\n", encrypted.number, encrypted.id)
+	}
+	fmt.Fprintf(&update, "/Prev %d\n>>\nstartxref\n%d\n%%%%EOF\n", xref, xrefOffset)
+	return update.Bytes(), nil
+}
+
+// objectOffsets reads the offsets of the objects in the cross-reference
+// table at xref
+func objectOffsets(document []byte, xref int) (map[int]int, error) {
+	lines := strings.Split(string(document[xref:]), "\n")
+	if len(lines) < 2 || strings.TrimSpace(lines[0]) != "xref" {
+		return nil, errors.New("PDF cross-reference table is not a classic table")
+	}
+
+	offsets := make(map[int]int)
+	for i := 1; i < len(lines); {
+		var first, count int
+		if _, err := fmt.Sscanf(lines[i], "%d %d", &first, &count); err != nil {
+			break
+		}
+		for j := 0; j < count && i+1+j < len(lines); j++ {
+			var offset, generation int
+			var kind string
+			if _, err := fmt.Sscanf(lines[i+1+j], "%d %d %s", &offset, &generation, &kind); err == nil && kind == "n" {
+				offsets[first+j] = offset
+			}
+		}
+		i += count + 1
+	}
+	return offsets, nil
+}
+
+// object returns the body of an indirect object, without the obj and endobj
+// keywords
+func object(document []byte, offsets map[int]int, number int) ([]byte, error) {
+	offset, ok := offsets[number]
+	if !ok || offset >= len(document) {
+		return nil, fmt.Errorf("PDF object %d not found", number)
+	}
+	body := document[offset:]
+	start := bytes.Index(body, []byte("obj"))
+	end := bytes.Index(body, []byte("endobj"))
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("PDF object %d is malformed", number)
+	}
+	return bytes.TrimSpace(body[start+len("obj") : end]), nil
+}
+
+// referencedObject returns the number of the object a dictionary entry
+// refers to, or 0
+func referencedObject(dict []byte, key string) int {
+	match := regexp.MustCompile(regexp.QuoteMeta(key) + `\s+(\d+) 0 R`).FindSubmatch(dict)
+	if match == nil {
+		return 0
+	}
+	number, _ := strconv.Atoi(string(match[1]))
+	return number
+}
+
+// appendEntry adds an entry at the end of a dictionary
+func appendEntry(dict []byte, key, value string) []byte {
+	end := bytes.LastIndex(dict, []byte(">>"))
+	return []byte(string(dict[:end]) + key + " " + value + "\n>>")
+}
+
+// appendArrayEntry adds value to the array held by key in a dictionary,
+// adding the array if the dictionary has none
+func appendArrayEntry(dict []byte, key, value string) []byte {
+	if start := bytes.Index(dict, []byte(key+" [")); start >= 0 {
+		at := start + len(key) + 2
+		return []byte(string(dict[:at]) + value + " " + string(dict[at:]))
+	}
+	return appendEntry(dict, key, "["+value+"]")
+}
+
+// pdfDate formats a time as a PDF date string
+func pdfDate(t time.Time) string {
+	return "D:" + t.UTC().Format("20060102150405") + "Z"
+}
+
+// pdfString encodes text as a PDF literal string
+func pdfString(text string) string {
+	return "(" + strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(text) + ")"
+}
+
+// parseCertificates decodes the certificates of a PEM file
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate found")
+	}
+	return certs, nil
+}
+
+// parsePrivateKey decodes a PKCS#8, PKCS#1 or SEC 1 PEM private key
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("no private key found")
+		}
+
+		var key interface{}
+		var err error
+		switch block.Type {
+		case "PRIVATE KEY":
+			key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		case "RSA PRIVATE KEY":
+			key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			key, err = x509.ParseECPrivateKey(block.Bytes)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+}
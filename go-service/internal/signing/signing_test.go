@@ -0,0 +1,270 @@
+package signing
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go-service/internal/encryption"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// testCA is a self-signed CA with a signing certificate it issued, written to
+// PEM files
+type testCA struct {
+	caFile   string
+	certFile string
+	keyFile  string
+}
+
+// newTestCA creates a self-signed test CA and a signing certificate issued by
+// it in a temporary directory
+func newTestCA(t *testing.T, name string) testCA {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate CA key: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name + " Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Failed to create CA certificate: %v", err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate signing key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: name + " (Registrar)"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Failed to create signing certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to encode signing key: %v", err)
+	}
+
+	ca := testCA{
+		caFile:   filepath.Join(dir, "ca.pem"),
+		certFile: filepath.Join(dir, "cert.pem"),
+		keyFile:  filepath.Join(dir, "key.pem"),
+	}
+	writePEM(t, ca.caFile, "CERTIFICATE", caDER)
+	writePEM(t, ca.certFile, "CERTIFICATE", der)
+	writePEM(t, ca.keyFile, "PRIVATE KEY", keyDER)
+	return ca
+}
+
+// writePEM writes a single PEM block to path
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+// samplePDF returns a two page PDF written by gofpdf
+func samplePDF(t *testing.T) []byte {
+	t.Helper()
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Arial", "", 12)
+	for _, text := range []string{"Bonafide Certificate", "Transcript"} {
+		pdf.AddPage()
+		pdf.Cell(40, 10, text)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatalf("Failed to generate PDF: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// protectedPDF returns the sample PDF encrypted with a user password
+func protectedPDF(t *testing.T, password string) []byte {
+	t.Helper()
+	document, err := encryption.Encrypt(samplePDF(t), password, "owner", encryption.AllowPrint)
+	if err != nil {
+		t.Fatalf("Failed to encrypt PDF: %v", err)
+	}
+	return document
+}
+
+// signedPDF signs the sample PDF with the signing certificate of ca
+func signedPDF(t *testing.T, ca testCA) []byte {
+	t.Helper()
+	signer, err := LoadSigner(ca.certFile, ca.keyFile)
+	if err != nil {
+		t.Fatalf("Failed to load signer: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := signer.Sign(&buf, samplePDF(t), time.Now(), ""); err != nil {
+		t.Fatalf("Failed to sign PDF: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// loadRoots loads the CA certificate of ca
+func loadRoots(t *testing.T, ca testCA) *x509.CertPool {
+	t.Helper()
+	roots, err := LoadRoots(ca.caFile)
+	if err != nil {
+		t.Fatalf("Failed to load CA: %v", err)
+	}
+	return roots
+}
+
+func TestSignAndVerify(t *testing.T) {
+	ca := newTestCA(t, "Springfield Elementary")
+	document := samplePDF(t)
+	signed := signedPDF(t, ca)
+
+	if !bytes.HasPrefix(signed, document) {
+		t.Error("Expected the signature to be appended as an incremental update")
+	}
+	for _, entry := range []string{"/SubFilter /adbe.pkcs7.detached", "/AcroForm <</Fields [", "/Annots [", "/Prev "} {
+		if !bytes.Contains(signed, []byte(entry)) {
+			t.Errorf("Expected signed PDF to contain %q", entry)
+		}
+	}
+
+	signature, err := Verify(signed, loadRoots(t, ca))
+	if err != nil {
+		t.Fatalf("Expected signature to verify, got error: %v", err)
+	}
+	if signature.Signer.Subject.CommonName != "Springfield Elementary (Registrar)" {
+		t.Errorf("Unexpected signer %s", signature.Signer.Subject.CommonName)
+	}
+	if time.Since(signature.SignedAt) > time.Minute {
+		t.Errorf("Expected the signing time to be recorded, got %v", signature.SignedAt)
+	}
+}
+
+func TestVerifyTamperedPDF(t *testing.T) {
+	ca := newTestCA(t, "Springfield Elementary")
+	signed := signedPDF(t, ca)
+
+	tampered := append([]byte(nil), signed...)
+	tampered[len(samplePDF(t))/2] ^= 1
+	if _, err := Verify(tampered, loadRoots(t, ca)); err == nil {
+		t.Error("Expected a changed document to fail verification")
+	}
+}
+
+func TestVerifyAppendedData(t *testing.T) {
+	ca := newTestCA(t, "Springfield Elementary")
+	signed := signedPDF(t, ca)
+
+	appended := append(signed, []byte("1 0 obj\n<</Type /Pages>>\nendobj\n")...)
+	_, err := Verify(appended, loadRoots(t, ca))
+	if err == nil || !strings.Contains(err.Error(), "changed after it was signed") {
+		t.Errorf("Expected data appended after signing to be reported, got %v", err)
+	}
+}
+
+func TestVerifyUntrustedSigner(t *testing.T) {
+	signed := signedPDF(t, newTestCA(t, "Springfield Elementary"))
+	other := newTestCA(t, "Shelbyville Elementary")
+
+	if _, err := Verify(signed, loadRoots(t, other)); err == nil {
+		t.Error("Expected a signer from another CA to fail verification")
+	}
+}
+
+func TestVerifyUnsignedPDF(t *testing.T) {
+	ca := newTestCA(t, "Springfield Elementary")
+	if _, err := Verify(samplePDF(t), loadRoots(t, ca)); !errors.Is(err, ErrNotSigned) {
+		t.Errorf("Expected ErrNotSigned, got %v", err)
+	}
+}
+
+func TestLoadSignerMismatchedKey(t *testing.T) {
+	ca := newTestCA(t, "Springfield Elementary")
+	other := newTestCA(t, "Shelbyville Elementary")
+
+	if _, err := LoadSigner(ca.certFile, other.keyFile); err == nil {
+		t.Error("Expected a key that does not match the certificate to be rejected")
+	}
+}
+
+func TestSignEncryptedPDF(t *testing.T) {
+	ca := newTestCA(t, "Springfield Elementary")
+	signer, err := LoadSigner(ca.certFile, ca.keyFile)
+	if err != nil {
+		t.Fatalf("Failed to load signer: %v", err)
+	}
+	document := protectedPDF(t, "01022010")
+
+	var buf bytes.Buffer
+	if err := signer.Sign(&buf, document, time.Now(), "01022010"); err != nil {
+		t.Fatalf("Failed to sign encrypted PDF: %v", err)
+	}
+	update := buf.Bytes()[len(document):]
+	for _, entry := range []string{"/Encrypt ", "/ID [<"} {
+		if !bytes.Contains(update, []byte(entry)) {
+			t.Errorf("Expected the update trailer to repeat %q", entry)
+		}
+	}
+
+	// The name of the signature field is encrypted with the document's key
+	xref, _ := strconv.Atoi(string(startXrefPattern.FindSubmatch(document)[1]))
+	offsets, err := objectOffsets(document, xref)
+	if err != nil {
+		t.Fatalf("Failed to read cross-reference table: %v", err)
+	}
+	encrypted, err := newEncryptedDocument(document, offsets, document[xref:], "01022010")
+	if err != nil || encrypted == nil {
+		t.Fatalf("Expected the encryption of the document, got %v", err)
+	}
+	match := regexp.MustCompile(`/T <([0-9a-f]+)>`).FindSubmatch(update)
+	if match == nil {
+		t.Fatal("Expected the name of the signature field to be encrypted")
+	}
+	name, _ := hex.DecodeString(string(match[1]))
+	if decrypted, err := encrypted.cipher.Decrypt(name); err != nil || string(decrypted) != "Signature1" {
+		t.Errorf("Expected the field name to decrypt to Signature1, got %q (%v)", decrypted, err)
+	}
+
+	if _, err := Verify(buf.Bytes(), loadRoots(t, ca)); err != nil {
+		t.Errorf("Expected signature of encrypted PDF to verify, got error: %v", err)
+	}
+
+	if err := signer.Sign(&bytes.Buffer{}, document, time.Now(), "wrong"); err == nil {
+		t.Error("Expected signing with the wrong password to fail")
+	}
+}
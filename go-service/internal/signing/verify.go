@@ -0,0 +1,105 @@
+package signing
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/digitorus/pkcs7"
+)
+
+// ErrNotSigned is returned when verifying a PDF without a signature
+var ErrNotSigned = errors.New("PDF is not signed")
+
+// signaturePattern matches the byte range and contents of a signature
+// dictionary
+var signaturePattern = regexp.MustCompile(`/ByteRange\s*\[\s*(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s*\]\s*/Contents\s*<([0-9A-Fa-f]*)>`)
+
+// Signature describes a verified PDF signature
+type Signature struct {
+	// Signer is the certificate the PDF was signed with
+	Signer *x509.Certificate
+	// SignedAt is the signing time recorded in the signature, if any
+	SignedAt time.Time
+}
+
+// LoadRoots reads the PEM certificates of the CAs trusted to issue signing
+// certificates
+func LoadRoots(caFile string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificates: %w", err)
+	}
+	certs, err := parseCertificates(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA certificates: %w", err)
+	}
+
+	roots := x509.NewCertPool()
+	for _, cert := range certs {
+		roots.AddCert(cert)
+	}
+	return roots, nil
+}
+
+// Verify checks the last signature of a PDF: the signed bytes must be
+// unchanged, the signature must cover the whole file so nothing was appended
+// after signing, and the signer's certificate must chain to one of roots
+func Verify(document []byte, roots *x509.CertPool) (*Signature, error) {
+	matches := signaturePattern.FindAllSubmatchIndex(document, -1)
+	if len(matches) == 0 {
+		return nil, ErrNotSigned
+	}
+	match := matches[len(matches)-1]
+
+	var byteRange [4]int
+	for i := range byteRange {
+		byteRange[i], _ = strconv.Atoi(string(document[match[2*i+2]:match[2*i+3]]))
+	}
+	// Only the hex string holding the signature may be left out
+	contents := document[match[10]:match[11]]
+	if byteRange[0] != 0 || byteRange[1] != match[10]-1 || byteRange[2] != match[11]+1 || byteRange[2]+byteRange[3] > len(document) {
+		return nil, errors.New("signature byte range does not match the signature")
+	}
+	if byteRange[2]+byteRange[3] != len(document) {
+		return nil, errors.New("document was changed after it was signed")
+	}
+
+	der, err := hex.DecodeString(string(contents))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	// The reserved space is padded with zeros after the DER encoding
+	var signature asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &signature); err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+
+	p7, err := pkcs7.Parse(signature.FullBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	signed := append(document[:byteRange[1]:byteRange[1]], document[byteRange[2]:byteRange[2]+byteRange[3]]...)
+	p7.Content = signed
+
+	signer := p7.GetOnlySigner()
+	if signer == nil {
+		return nil, errors.New("signature must have exactly one signer")
+	}
+	if err := p7.VerifyWithChain(roots); err != nil {
+		return nil, fmt.Errorf("signature is invalid: %w", err)
+	}
+
+	result := &Signature{Signer: signer}
+	var signingTime time.Time
+	if err := p7.UnmarshalSignedAttribute(pkcs7.OIDAttributeSigningTime, &signingTime); err == nil {
+		result.SignedAt = signingTime
+	}
+	return result, nil
+}
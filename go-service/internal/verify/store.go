@@ -0,0 +1,279 @@
+package verify
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// idAlphabet is Crockford's base32 alphabet, which leaves out the letters
+// that are easily mistaken for digits when an ID is typed in
+const idAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// expiryInterval is how often Issue looks for documents past the retention
+// period
+const expiryInterval = time.Hour
+
+// ErrNotFound is returned for unknown verification IDs
+var ErrNotFound = errors.New("document not found")
+
+// idEncoding encodes verification IDs
+var idEncoding = base32.NewEncoding(idAlphabet).WithPadding(base32.NoPadding)
+
+// Document is the record of an issued report, kept so a printed copy can be
+// checked against it
+type Document struct {
+	ID string `json:"id"`
+	// Report is the kind of report, such as student_report
+	Report string `json:"report"`
+	// SubjectID identifies whom or what the report is about within its kind,
+	// such as the student ID; a new document for the same report and subject
+	// supersedes the previous one. Documents without a stable subject leave
+	// it empty and are never superseded.
+	SubjectID string `json:"subjectId,omitempty"`
+	// Subject names whom or what the report is about, such as the student
+	Subject string `json:"subject"`
+	// SHA256 is the hex encoded hash of the issued PDF
+	SHA256 string `json:"sha256"`
+	// Serial is the serial number of an official document
+	Serial       string     `json:"serial,omitempty"`
+	IssuedAt     time.Time  `json:"issuedAt"`
+	SupersededBy string     `json:"supersededBy,omitempty"`
+	SupersededAt *time.Time `json:"supersededAt,omitempty"`
+}
+
+// Superseded reports whether a newer document was issued for the same
+// report and subject
+func (d Document) Superseded() bool {
+	return d.SupersededBy != ""
+}
+
+// key identifies the report and subject a document is issued for
+func (d Document) key() string {
+	return d.Report + "/" + d.SubjectID
+}
+
+// Store records issued documents in a local directory, one file per
+// document, so they can still be verified after a process restart. Documents
+// without a serial number are deleted once they are older than the retention
+// period; official documents are kept, and so are their serial numbers.
+type Store struct {
+	dir       string
+	retention time.Duration
+	mu        sync.Mutex
+	documents map[string]*Document
+	// current holds the ID of the latest document of each report and subject
+	current map[string]string
+	// serials holds the last serial number handed out in each year
+	serials map[int]int
+	// reserved holds the serial numbers handed out for documents not yet
+	// issued, and released those given back, by year, to be handed out again
+	reserved map[string]bool
+	released map[int][]int
+	// expiredAt is when the store last looked for expired documents
+	expiredAt time.Time
+}
+
+// NewStore creates a document store in dir that keeps documents without a
+// serial number for retention, loading the documents recorded there before
+func NewStore(dir string, retention time.Duration) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create verification directory: %w", err)
+	}
+
+	s := &Store{
+		dir:       dir,
+		retention: retention,
+		documents: make(map[string]*Document),
+		current:   make(map[string]string),
+		serials:   make(map[int]int),
+		reserved:  make(map[string]bool),
+		released:  make(map[int][]int),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	s.expire(time.Now())
+	return s, nil
+}
+
+// NewID returns a random verification ID short enough to be typed in, in two
+// groups of five characters such as 8KQ4M-ZT2XH
+func NewID() (string, error) {
+	buf := make([]byte, 7)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate verification ID: %w", err)
+	}
+	id := idEncoding.EncodeToString(buf)[:10]
+	return id[:5] + "-" + id[5:], nil
+}
+
+// ReserveSerial reserves the serial number of an official document issued
+// at issuedAt, which is printed on it. Serial numbers count up within each
+// calendar year, such as 2024-000042, following the last one of the
+// documents recorded in the store. A reserved serial number is taken by
+// Issue, or given back with ReleaseSerial if the document is not issued and
+// handed out again, so numbers are only used up by issued documents.
+func (s *Store) ReserveSerial(issuedAt time.Time) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	year := issuedAt.Year()
+	var serial string
+	if released := s.released[year]; len(released) > 0 {
+		lowest := 0
+		for i := range released {
+			if released[i] < released[lowest] {
+				lowest = i
+			}
+		}
+		serial = fmt.Sprintf("%d-%06d", year, released[lowest])
+		s.released[year] = append(released[:lowest], released[lowest+1:]...)
+	} else {
+		s.serials[year]++
+		serial = fmt.Sprintf("%d-%06d", year, s.serials[year])
+	}
+	s.reserved[serial] = true
+	return serial
+}
+
+// ReleaseSerial gives back a serial number reserved for a document that was
+// not issued
+func (s *Store) ReleaseSerial(serial string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.reserved[serial] {
+		return
+	}
+	delete(s.reserved, serial)
+
+	var year, number int
+	if _, err := fmt.Sscanf(serial, "%d-%d", &year, &number); err == nil {
+		s.released[year] = append(s.released[year], number)
+	}
+}
+
+// Issue records an issued document, taking its reserved serial number, and
+// marks the document previously issued for the same report and subject as
+// superseded by it, unless it has no subject ID. The document is recorded
+// even if it cannot be persisted, in which case the error is returned.
+func (s *Store) Issue(doc Document) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.reserved, doc.Serial)
+
+	if now := time.Now(); now.Sub(s.expiredAt) >= expiryInterval {
+		s.expire(now)
+	}
+
+	record := doc
+	s.documents[doc.ID] = &record
+
+	if doc.SubjectID == "" {
+		return s.save(&record)
+	}
+
+	var errs []error
+	if previous, ok := s.documents[s.current[doc.key()]]; ok && previous.ID != doc.ID {
+		supersededAt := doc.IssuedAt
+		previous.SupersededBy = doc.ID
+		previous.SupersededAt = &supersededAt
+		errs = append(errs, s.save(previous))
+	}
+	s.current[doc.key()] = doc.ID
+
+	errs = append(errs, s.save(&record))
+	return errors.Join(errs...)
+}
+
+// Get returns the document with the given verification ID, which is matched
+// regardless of case
+func (s *Store) Get(id string) (Document, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, ok := s.documents[strings.ToUpper(strings.TrimSpace(id))]
+	if !ok {
+		return Document{}, ErrNotFound
+	}
+	return *doc, nil
+}
+
+// load reads the recorded documents from the store's directory
+func (s *Store) load() error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("failed to list recorded documents: %w", err)
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read recorded document: %w", err)
+		}
+
+		var doc Document
+		if err := json.Unmarshal(data, &doc); err != nil {
+			fmt.Printf("Skipping unreadable document record %s: %v\n", path, err)
+			continue
+		}
+		s.documents[doc.ID] = &doc
+
+		var year, serial int
+		if _, err := fmt.Sscanf(doc.Serial, "%d-%d", &year, &serial); err == nil && serial > s.serials[year] {
+			s.serials[year] = serial
+		}
+
+		if doc.SubjectID == "" {
+			continue
+		}
+		if current, ok := s.documents[s.current[doc.key()]]; !doc.Superseded() && (!ok || doc.IssuedAt.After(current.IssuedAt)) {
+			s.current[doc.key()] = doc.ID
+		}
+	}
+
+	return nil
+}
+
+// expire deletes the documents without a serial number issued more than the
+// retention period before now. The caller must hold s.mu.
+func (s *Store) expire(now time.Time) {
+	s.expiredAt = now
+	for id, doc := range s.documents {
+		if doc.Serial != "" || now.Sub(doc.IssuedAt) < s.retention {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(s.dir, id+".json")); err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("Error deleting expired document record %s: %v\n", id, err)
+			continue
+		}
+		delete(s.documents, id)
+		if s.current[doc.key()] == id {
+			delete(s.current, doc.key())
+		}
+	}
+}
+
+// save atomically writes the record of a document to disk
+func (s *Store) save(doc *Document) error {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(s.dir, doc.ID+".json")
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
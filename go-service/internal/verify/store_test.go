@@ -0,0 +1,217 @@
+package verify
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// retention keeps the documents of the tests, which are issued on fixed days
+const retention = 100 * 365 * 24 * time.Hour
+
+// issue records a new document for a report and subject
+func issue(t *testing.T, s *Store, report, subjectID string, issuedAt time.Time) Document {
+	t.Helper()
+
+	id, err := NewID()
+	if err != nil {
+		t.Fatalf("Failed to generate ID: %v", err)
+	}
+	doc := Document{ID: id, Report: report, SubjectID: subjectID, Subject: "Bart Simpson", SHA256: "ab12", IssuedAt: issuedAt}
+	if err := s.Issue(doc); err != nil {
+		t.Fatalf("Failed to issue document: %v", err)
+	}
+	return doc
+}
+
+func TestNewID(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id, err := NewID()
+		if err != nil {
+			t.Fatalf("Failed to generate ID: %v", err)
+		}
+		if !regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{5}-[0-9A-HJKMNP-TV-Z]{5}$`).MatchString(id) {
+			t.Errorf("Unexpected ID format %q", id)
+		}
+		if seen[id] {
+			t.Errorf("Expected unique IDs, got %q twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestIssueSupersedesPreviousDocument(t *testing.T) {
+	s, err := NewStore(t.TempDir(), retention)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	day := time.Date(2024, time.May, 6, 9, 0, 0, 0, time.UTC)
+	first := issue(t, s, "student_report", "2", day)
+	other := issue(t, s, "student_report", "3", day.Add(time.Hour))
+	second := issue(t, s, "student_report", "2", day.AddDate(0, 0, 1))
+
+	doc, err := s.Get(first.ID)
+	if err != nil {
+		t.Fatalf("Failed to get document: %v", err)
+	}
+	if !doc.Superseded() || doc.SupersededBy != second.ID || !doc.SupersededAt.Equal(second.IssuedAt) {
+		t.Errorf("Expected the first report to be superseded by %s, got %+v", second.ID, doc)
+	}
+
+	for _, id := range []string{other.ID, strings.ToLower(second.ID)} {
+		doc, err := s.Get(id)
+		if err != nil {
+			t.Fatalf("Failed to get document %s: %v", id, err)
+		}
+		if doc.Superseded() {
+			t.Errorf("Expected %s to be current, got %+v", id, doc)
+		}
+	}
+}
+
+func TestIssueWithoutSubjectID(t *testing.T) {
+	s, err := NewStore(t.TempDir(), retention)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	day := time.Date(2024, time.May, 6, 9, 0, 0, 0, time.UTC)
+	first := issue(t, s, "notice_bulletin", "", day)
+	second := issue(t, s, "notice_bulletin", "", day.Add(time.Hour))
+
+	for _, id := range []string{first.ID, second.ID} {
+		if doc, err := s.Get(id); err != nil || doc.Superseded() {
+			t.Errorf("Expected %s without a subject to stay current, got %+v (%v)", id, doc, err)
+		}
+	}
+}
+
+func TestExpiredDocuments(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewStore(dir, retention)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	old := time.Now().AddDate(0, 0, -2)
+	expired := issue(t, s, "student_report", "2", old)
+	official := Document{ID: "8KQ4M-ZT2XH", Report: "student_report", SubjectID: "3", Serial: "2024-000002", IssuedAt: old}
+	if err := s.Issue(official); err != nil {
+		t.Fatalf("Failed to issue document: %v", err)
+	}
+	recent := issue(t, s, "student_report", "4", time.Now())
+
+	reopened, err := NewStore(dir, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	if _, err := reopened.Get(expired.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the document past the retention period to be deleted, got %v", err)
+	}
+	for _, id := range []string{official.ID, recent.ID} {
+		if _, err := reopened.Get(id); err != nil {
+			t.Errorf("Expected document %s to be kept, got %v", id, err)
+		}
+	}
+	if paths, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(paths) != 2 {
+		t.Errorf("Expected the record of the expired document to be deleted, got %v", paths)
+	}
+}
+
+func TestUnknownDocument(t *testing.T) {
+	s, err := NewStore(t.TempDir(), retention)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	if _, err := s.Get("00000-00000"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestDocumentsSurviveRestart(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewStore(dir, retention)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	day := time.Date(2024, time.May, 6, 9, 0, 0, 0, time.UTC)
+	first := issue(t, s, "class_roster", "Grade 4/B", day)
+	second := issue(t, s, "class_roster", "Grade 4/B", day.Add(time.Hour))
+
+	reopened, err := NewStore(dir, retention)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	if doc, err := reopened.Get(first.ID); err != nil || doc.SupersededBy != second.ID {
+		t.Errorf("Expected the superseded roster to be reloaded, got %+v (%v)", doc, err)
+	}
+
+	// The reloaded store keeps track of the current document
+	third := issue(t, reopened, "class_roster", "Grade 4/B", day.Add(2*time.Hour))
+	if doc, _ := reopened.Get(second.ID); doc.SupersededBy != third.ID {
+		t.Errorf("Expected the reloaded current roster to be superseded by %s, got %+v", third.ID, doc)
+	}
+}
+
+func TestSerialNumbers(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewStore(dir, retention)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	day := time.Date(2024, time.December, 31, 9, 0, 0, 0, time.UTC)
+	for i, expected := range []string{"2024-000001", "2024-000002"} {
+		if serial := s.ReserveSerial(day); serial != expected {
+			t.Errorf("Expected serial %d to be %s, got %s", i+1, expected, serial)
+		}
+	}
+	if serial := s.ReserveSerial(day.AddDate(0, 0, 1)); serial != "2025-000001" {
+		t.Errorf("Expected serials to start over in a new year, got %s", serial)
+	}
+
+	// Serial numbers continue from the documents recorded before a restart
+	doc := Document{ID: "8KQ4M-ZT2XH", Report: "student_report", SubjectID: "2", Serial: "2024-000002", IssuedAt: day}
+	if err := s.Issue(doc); err != nil {
+		t.Fatalf("Failed to issue document: %v", err)
+	}
+	reopened, err := NewStore(dir, retention)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	if serial := reopened.ReserveSerial(day); serial != "2024-000003" {
+		t.Errorf("Expected the serial after the recorded document, got %s", serial)
+	}
+}
+
+// TestReleasedSerialNumbers tests that the serial number of a document that
+// is not issued is handed out again, so failures leave no gaps
+func TestReleasedSerialNumbers(t *testing.T) {
+	s, err := NewStore(t.TempDir(), retention)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	day := time.Date(2024, time.March, 4, 9, 0, 0, 0, time.UTC)
+	first, second, third := s.ReserveSerial(day), s.ReserveSerial(day), s.ReserveSerial(day)
+	if err := s.Issue(Document{ID: "8KQ4M-ZT2XH", Report: "student_report", SubjectID: "2", Serial: first, IssuedAt: day}); err != nil {
+		t.Fatalf("Failed to issue document: %v", err)
+	}
+	s.ReleaseSerial(third)
+	s.ReleaseSerial(second)
+	// An issued serial number cannot be given back
+	s.ReleaseSerial(first)
+
+	for _, expected := range []string{second, third, "2024-000004"} {
+		if serial := s.ReserveSerial(day); serial != expected {
+			t.Errorf("Expected serial %s, got %s", expected, serial)
+		}
+	}
+}
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"net/http"
+	"runtime"
 	"sync"
 	"testing"
 	"time"
+
+	"go-service/internal/pdf"
+	"go-service/pkg/models"
 )
 
 // BenchmarkPDFGeneration benchmarks PDF generation performance
@@ -522,4 +527,108 @@ func TestResponseTimeConsistency(t *testing.T) {
 		
 		t.Log("✓ Response times are consistent")
 	})
-} 
\ No newline at end of file
+} 
+
+// TestReportMemoryProfile tests that the memory a streamed roster takes stays
+// flat as the roster grows: long rosters are drawn a part at a time and
+// spooled, so the peak heap is the same for fifteen times as many pages. The
+// generator writes to the test directly rather than through the renderer's
+// pipe, so the heap is measured while the generator waits for a write instead
+// of while it runs on. It is measured every heapSampleBytes of the document,
+// while the spooled parts are written out; the parts are measured as they are
+// drawn by the pdf package's tests.
+func TestReportMemoryProfile(t *testing.T) {
+	// Draw a report first so the fonts and tables the library sets up once
+	// are not counted against the first roster
+	if err := pdf.NewGenerator().GenerateClassRoster(io.Discard, "Grade 10", "A", nil); err != nil {
+		t.Fatalf("Failed to render an empty roster: %v", err)
+	}
+
+	var pages []int
+	var peaks []float64
+	for _, size := range []int{1000, 4000, 16000} {
+		students := make([]models.Student, size)
+		for i := range students {
+			students[i] = models.Student{
+				ID:                 i + 1,
+				Name:               fmt.Sprintf("Student %05d", i+1),
+				Gender:             "Female",
+				Roll:               i + 1,
+				GuardianName:       fmt.Sprintf("Guardian %05d", i+1),
+				RelationOfGuardian: "Mother",
+				GuardianPhone:      fmt.Sprintf("555-%04d", i%10000),
+			}
+		}
+
+		// Pooled buffers survive one collection
+		runtime.GC()
+		runtime.GC()
+		var before runtime.MemStats
+		runtime.ReadMemStats(&before)
+
+		sink := &pageCounter{}
+		err := pdf.NewGenerator().GenerateClassRoster(sink, "Grade 10", "A", students)
+		// The students are part of the baseline, so they must outlive the
+		// measurement
+		runtime.KeepAlive(students)
+		if err != nil {
+			t.Fatalf("Failed to stream roster of %d students: %v", size, err)
+		}
+
+		peak := float64(sink.peak) - float64(before.HeapAlloc)
+		pages = append(pages, sink.pages)
+		peaks = append(peaks, peak)
+		t.Logf("%d students: %d pages, %d bytes written, %.0f KB peak heap", size, sink.pages, sink.written, peak/1024)
+	}
+
+	for i := 1; i < len(pages); i++ {
+		if pages[i] <= pages[i-1] {
+			t.Fatalf("Expected longer rosters to have more pages, got %v", pages)
+		}
+	}
+	if last := peaks[len(peaks)-1]; last > peaks[0]*1.25 {
+		t.Errorf("Expected the peak heap to stay flat, got %.0f KB for %d pages against %.0f KB for %d pages",
+			last/1024, pages[len(pages)-1], peaks[0]/1024, pages[0])
+	}
+}
+
+// heapSampleBytes is how much of a document pageCounter takes between
+// measurements of the heap
+const heapSampleBytes = 64 * 1024
+
+// pageCounter discards a streamed PDF, counting its pages and recording the
+// peak live heap, measured when the first bytes arrive and then every
+// heapSampleBytes
+type pageCounter struct {
+	written int
+	pages   int
+	peak    uint64
+	// sampled is the number of bytes written when the heap was last measured
+	sampled int
+	// tail keeps the end of the previous write, so a page marker split
+	// between writes is still found
+	tail []byte
+}
+
+// Write implements io.Writer
+func (c *pageCounter) Write(p []byte) (int, error) {
+	if c.peak == 0 || c.written-c.sampled >= heapSampleBytes {
+		runtime.GC()
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		if stats.HeapAlloc > c.peak {
+			c.peak = stats.HeapAlloc
+		}
+		c.sampled = c.written
+	}
+	c.written += len(p)
+
+	marker := []byte("/Type /Page\n")
+	data := append(c.tail, p...)
+	c.pages += bytes.Count(data, marker)
+	if keep := len(marker) - 1; len(data) > keep {
+		data = data[len(data)-keep:]
+	}
+	c.tail = append([]byte(nil), data...)
+	return len(p), nil
+}
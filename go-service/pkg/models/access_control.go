@@ -0,0 +1,40 @@
+package models
+
+import "strings"
+
+// AccessControl is a menu, screen or API endpoint the Node.js API grants
+// roles access to
+type AccessControl struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Path is the route of the menu or screen, or the API path with its
+	// parameters written as in Express, such as /api/v1/students/:id
+	Path string `json:"path"`
+	// Type is "menu", "menu-screen", "screen" or "api"
+	Type string `json:"type"`
+	// Method is the HTTP method of an API endpoint
+	Method string `json:"method"`
+}
+
+// Permissions are the access controls granted to the role of the user the
+// credentials belong to
+type Permissions struct {
+	APIs []AccessControl `json:"apis"`
+	UIs  []AccessControl `json:"uis"`
+}
+
+// PermissionsResponse represents the /api/v1/access-controls/me response
+type PermissionsResponse struct {
+	Permissions Permissions `json:"permissions"`
+}
+
+// AllowsAPI reports whether the permissions include the API endpoint with the
+// given method and path, such as PUT /api/v1/students/:id
+func (p Permissions) AllowsAPI(method, path string) bool {
+	for _, api := range p.APIs {
+		if strings.EqualFold(api.Method, method) && api.Path == path {
+			return true
+		}
+	}
+	return false
+}
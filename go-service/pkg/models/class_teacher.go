@@ -0,0 +1,213 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// Class represents a class returned by the Node.js API
+type Class struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Sections is the comma separated list of the sections the class runs
+	Sections string `json:"sections"`
+}
+
+// SectionNames returns the names of the sections the class runs
+func (c Class) SectionNames() []string {
+	var names []string
+	for _, name := range strings.Split(c.Sections, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ClassList represents the class list response from the API
+type ClassList struct {
+	Classes []Class `json:"classes"`
+}
+
+// Section represents a section returned by the Node.js API
+type Section struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// SectionList represents the section list response from the API
+type SectionList struct {
+	Sections []Section `json:"sections"`
+}
+
+// ClassTeacher represents a class teacher assignment returned by the Node.js
+// API. Class and Section are empty when the class or section was deleted, and
+// Teacher is empty when the teacher was.
+type ClassTeacher struct {
+	ID      int    `json:"id"`
+	Class   string `json:"class"`
+	Section string `json:"section"`
+	Teacher string `json:"teacher"`
+}
+
+// ClassTeacherList represents the class teacher list response from the API
+type ClassTeacherList struct {
+	ClassTeachers []ClassTeacher `json:"classTeachers"`
+}
+
+// ClassSection identifies a section of a class
+type ClassSection struct {
+	Class   string `json:"class"`
+	Section string `json:"section"`
+}
+
+// ClassTeacherMatrix lays the class teacher assignments out with a row per
+// class and a column per section
+type ClassTeacherMatrix struct {
+	Sections []string          `json:"sections"`
+	Rows     []ClassTeacherRow `json:"rows"`
+	// Unassigned lists the sections classes run without a class teacher
+	Unassigned []ClassSection `json:"unassigned"`
+	// MultiAssigned lists the teachers assigned to more than one section
+	MultiAssigned []TeacherAssignments `json:"multiAssigned"`
+	// Unplaced lists the assignments whose class or section no longer exists
+	Unplaced []ClassTeacher `json:"unplaced"`
+}
+
+// ClassTeacherRow holds the cells of one class, in the order of the
+// matrix's sections
+type ClassTeacherRow struct {
+	Class string             `json:"class"`
+	Cells []ClassTeacherCell `json:"cells"`
+}
+
+// ClassTeacherCell holds the teachers assigned to a section of a class
+type ClassTeacherCell struct {
+	// Runs reports whether the class runs the section
+	Runs     bool     `json:"runs"`
+	Teachers []string `json:"teachers"`
+}
+
+// Unassigned reports whether the class runs the section without a teacher
+func (c ClassTeacherCell) Unassigned() bool {
+	return c.Runs && len(c.Teachers) == 0
+}
+
+// TeacherAssignments lists the sections a teacher is assigned to
+type TeacherAssignments struct {
+	Teacher  string         `json:"teacher"`
+	Sections []ClassSection `json:"sections"`
+}
+
+// TeachesSeveral reports whether the teacher is assigned to more than one section
+func (m ClassTeacherMatrix) TeachesSeveral(teacher string) bool {
+	for _, assignments := range m.MultiAssigned {
+		if assignments.Teacher == teacher {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildClassTeacherMatrix cross-references the class teacher assignments
+// with the classes and sections, so the sections nobody teaches appear as
+// empty cells. Rows keep the order of classes and columns are sorted by name.
+// A class or section that only appears in an assignment gets its own row or
+// column so no assignment is lost.
+func BuildClassTeacherMatrix(classes []Class, sections []Section, assignments []ClassTeacher) ClassTeacherMatrix {
+	var matrix ClassTeacherMatrix
+
+	columns := make(map[string]int)
+	addSection := func(name string) {
+		if _, ok := columns[name]; !ok {
+			columns[name] = len(matrix.Sections)
+			matrix.Sections = append(matrix.Sections, name)
+		}
+	}
+	for _, section := range sections {
+		addSection(section.Name)
+	}
+	for _, class := range classes {
+		for _, name := range class.SectionNames() {
+			addSection(name)
+		}
+	}
+	for _, assignment := range assignments {
+		if assignment.Class != "" && assignment.Section != "" {
+			addSection(assignment.Section)
+		}
+	}
+	sort.Strings(matrix.Sections)
+	for i, name := range matrix.Sections {
+		columns[name] = i
+	}
+
+	rows := make(map[string]int)
+	addClass := func(name string) *ClassTeacherRow {
+		i, ok := rows[name]
+		if !ok {
+			i = len(matrix.Rows)
+			rows[name] = i
+			matrix.Rows = append(matrix.Rows, ClassTeacherRow{Class: name, Cells: make([]ClassTeacherCell, len(matrix.Sections))})
+		}
+		return &matrix.Rows[i]
+	}
+	for _, class := range classes {
+		row := addClass(class.Name)
+		for _, name := range class.SectionNames() {
+			row.Cells[columns[name]].Runs = true
+		}
+	}
+
+	// Teachers are only known by name, so teachers sharing a name are
+	// counted together
+	taught := make(map[string][]ClassSection)
+	var teachers []string
+	for _, assignment := range assignments {
+		if assignment.Class == "" || assignment.Section == "" {
+			matrix.Unplaced = append(matrix.Unplaced, assignment)
+			continue
+		}
+		if assignment.Teacher == "" {
+			continue
+		}
+
+		cell := &addClass(assignment.Class).Cells[columns[assignment.Section]]
+		cell.Teachers = appendUnique(cell.Teachers, assignment.Teacher)
+
+		if _, ok := taught[assignment.Teacher]; !ok {
+			teachers = append(teachers, assignment.Teacher)
+		}
+		section := ClassSection{Class: assignment.Class, Section: assignment.Section}
+		if !containsClassSection(taught[assignment.Teacher], section) {
+			taught[assignment.Teacher] = append(taught[assignment.Teacher], section)
+		}
+	}
+
+	for _, row := range matrix.Rows {
+		for i, cell := range row.Cells {
+			if cell.Unassigned() {
+				matrix.Unassigned = append(matrix.Unassigned, ClassSection{Class: row.Class, Section: matrix.Sections[i]})
+			}
+		}
+	}
+
+	sort.Strings(teachers)
+	for _, teacher := range teachers {
+		if len(taught[teacher]) > 1 {
+			matrix.MultiAssigned = append(matrix.MultiAssigned, TeacherAssignments{Teacher: teacher, Sections: taught[teacher]})
+		}
+	}
+
+	return matrix
+}
+
+// containsClassSection reports whether sections contains section
+func containsClassSection(sections []ClassSection, section ClassSection) bool {
+	for _, s := range sections {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestBuildClassTeacherMatrix tests the cross-referencing of assignments with
+// classes and sections, and the unassigned and multi-assigned flags
+func TestBuildClassTeacherMatrix(t *testing.T) {
+	classes := []Class{
+		{ID: 1, Name: "Grade 8", Sections: "A,B"},
+		{ID: 2, Name: "Grade 9", Sections: " A, C "},
+		{ID: 3, Name: "Grade 10", Sections: ""},
+	}
+	sections := []Section{{ID: 3, Name: "C"}, {ID: 1, Name: "A"}, {ID: 2, Name: "B"}}
+	assignments := []ClassTeacher{
+		{ID: 1, Class: "Grade 8", Section: "A", Teacher: "Edna Krabappel"},
+		{ID: 2, Class: "Grade 9", Section: "C", Teacher: "Edna Krabappel"},
+		{ID: 3, Class: "Grade 9", Section: "A", Teacher: "Elizabeth Hoover"},
+		{ID: 4, Class: "Grade 8", Section: "B", Teacher: ""},
+		{ID: 5, Class: "", Section: "B", Teacher: "Dewey Largo"},
+		{ID: 6, Class: "Grade 11", Section: "D", Teacher: "Dewey Largo"},
+	}
+
+	matrix := BuildClassTeacherMatrix(classes, sections, assignments)
+
+	if want := []string{"A", "B", "C", "D"}; !reflect.DeepEqual(matrix.Sections, want) {
+		t.Fatalf("Expected sections %v, got %v", want, matrix.Sections)
+	}
+
+	var classNames []string
+	for _, row := range matrix.Rows {
+		classNames = append(classNames, row.Class)
+	}
+	if want := []string{"Grade 8", "Grade 9", "Grade 10", "Grade 11"}; !reflect.DeepEqual(classNames, want) {
+		t.Fatalf("Expected classes %v, got %v", want, classNames)
+	}
+
+	grade9 := matrix.Rows[1].Cells
+	if !grade9[0].Runs || !reflect.DeepEqual(grade9[0].Teachers, []string{"Elizabeth Hoover"}) {
+		t.Errorf("Expected Grade 9 A to be taught by Elizabeth Hoover, got %+v", grade9[0])
+	}
+	if grade9[1].Runs || grade9[1].Unassigned() {
+		t.Errorf("Expected Grade 9 not to run section B, got %+v", grade9[1])
+	}
+	if grade11 := matrix.Rows[3].Cells[3]; grade11.Runs || len(grade11.Teachers) != 1 {
+		t.Errorf("Expected Grade 11 D to keep its assignment, got %+v", grade11)
+	}
+
+	if want := []ClassSection{{"Grade 8", "B"}}; !reflect.DeepEqual(matrix.Unassigned, want) {
+		t.Errorf("Expected unassigned %v, got %v", want, matrix.Unassigned)
+	}
+
+	if len(matrix.MultiAssigned) != 1 || matrix.MultiAssigned[0].Teacher != "Edna Krabappel" || len(matrix.MultiAssigned[0].Sections) != 2 {
+		t.Errorf("Expected Edna Krabappel to be assigned to two sections, got %+v", matrix.MultiAssigned)
+	}
+	if !matrix.TeachesSeveral("Edna Krabappel") || matrix.TeachesSeveral("Dewey Largo") {
+		t.Error("Expected only Edna Krabappel to be flagged as multi-assigned")
+	}
+
+	if len(matrix.Unplaced) != 1 || matrix.Unplaced[0].ID != 5 {
+		t.Errorf("Expected assignment 5 to be unplaced, got %+v", matrix.Unplaced)
+	}
+}
@@ -0,0 +1,90 @@
+package models
+
+import "encoding/json"
+
+// DashboardSummary represents the dashboard data returned by the Node.js API
+// for the calling user. The head counts are only filled in for admins.
+type DashboardSummary struct {
+	Students      DashboardCount         `json:"students"`
+	Teachers      DashboardCount         `json:"teachers"`
+	Parents       DashboardCount         `json:"parents"`
+	Notices       []DashboardNotice      `json:"notices"`
+	LeavePolicies []DashboardLeavePolicy `json:"leavePolicies"`
+	LeaveHistory  []DashboardLeave       `json:"leaveHistory"`
+	Celebrations  []Celebration          `json:"celebrations"`
+	OneMonthLeave []UpcomingLeave        `json:"oneMonthLeave"`
+	// UserID is the user the dashboard was fetched for, which the Node.js
+	// API does not send
+	UserID int `json:"-"`
+}
+
+// DashboardCount is the number of users of a role who joined this calendar
+// year, compared with the previous year
+type DashboardCount struct {
+	CurrentYear   int     `json:"totalNumberCurrentYear"`
+	Change        int     `json:"totalNumberValueInComparisonFromPrevYear"`
+	PercentChange float64 `json:"totalNumberPercInComparisonFromPrevYear"`
+}
+
+// PreviousYear returns the count for the previous calendar year
+func (c DashboardCount) PreviousYear() int {
+	return c.CurrentYear - c.Change
+}
+
+// DashboardNotice is one of the latest notices visible to the user
+type DashboardNotice struct {
+	ID           int    `json:"id"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	AuthorID     int    `json:"authorId"`
+	Author       string `json:"author"`
+	CreatedDate  Date   `json:"createdDate"`
+	UpdatedDate  Date   `json:"updatedDate"`
+	ReviewerName string `json:"reviewerName"`
+	ReviewedDate Date   `json:"reviewedDate"`
+	Status       string `json:"status"`
+	StatusID     int    `json:"statusId"`
+}
+
+// DashboardLeavePolicy is a leave policy assigned to the user
+type DashboardLeavePolicy struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// TotalDaysUsed counts the approved days of every user under the policy
+	TotalDaysUsed json.Number `json:"totalDaysUsed"`
+}
+
+// DashboardLeave is one of the latest leave requests: the user's own, or
+// everyone's for admins
+type DashboardLeave struct {
+	ID        int         `json:"id"`
+	Policy    string      `json:"policy"`
+	PolicyID  int         `json:"policyId"`
+	From      Date        `json:"from"`
+	To        Date        `json:"to"`
+	Note      string      `json:"note"`
+	Status    string      `json:"status"`
+	Submitted Date        `json:"submitted"`
+	Updated   Date        `json:"updated"`
+	Approved  Date        `json:"approved"`
+	Approver  string      `json:"approver"`
+	User      string      `json:"user"`
+	Days      json.Number `json:"days"`
+}
+
+// Celebration is a birthday or work anniversary in the next 90 days
+type Celebration struct {
+	UserID    int    `json:"userId"`
+	User      string `json:"user"`
+	Event     string `json:"event"`
+	EventDate Date   `json:"eventDate"`
+}
+
+// UpcomingLeave is approved leave overlapping the next 30 days
+type UpcomingLeave struct {
+	UserID    int    `json:"userId"`
+	User      string `json:"user"`
+	FromDate  Date   `json:"fromDate"`
+	ToDate    Date   `json:"toDate"`
+	LeaveType string `json:"leaveType"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestDecodeDashboardSummary tests decoding the dashboard data as serialized
+// by PostgreSQL, with zone-less timestamps, bare dates and nulls
+func TestDecodeDashboardSummary(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/dashboard.json")
+	if err != nil {
+		t.Fatalf("Failed to read dashboard fixture: %v", err)
+	}
+
+	var summary DashboardSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatalf("Expected dashboard to decode, got error: %v", err)
+	}
+
+	if summary.Students.CurrentYear != 48 || summary.Students.PreviousYear() != 40 || summary.Teachers.PercentChange != -25 {
+		t.Errorf("Unexpected head counts: %+v %+v", summary.Students, summary.Teachers)
+	}
+	if got := summary.Notices[1].CreatedDate.Time; !got.Equal(time.Date(2024, time.May, 2, 9, 0, 0, 123456000, time.UTC)) {
+		t.Errorf("Unexpected notice date %v", got)
+	}
+	if !summary.Notices[2].ReviewedDate.IsZero() {
+		t.Error("Expected a null date to decode as the zero time")
+	}
+	if got := summary.OneMonthLeave[1].FromDate.Time; !got.Equal(time.Date(2024, time.May, 27, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected leave date %v", got)
+	}
+	if days, _ := summary.LeavePolicies[1].TotalDaysUsed.Int64(); days != 31 {
+		t.Errorf("Expected 31 days used, got %d", days)
+	}
+
+	var date Date
+	if err := json.Unmarshal([]byte(`"05/27/2024"`), &date); err == nil {
+		t.Error("Expected an unknown date format to be rejected")
+	}
+}
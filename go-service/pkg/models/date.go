@@ -0,0 +1,49 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// dateLayouts are the formats dates arrive in from the Node.js API. Columns
+// serialized by PostgreSQL itself, as in the dashboard data, have no time zone
+// and DATE columns have no time.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// Date is a time decoded from any of the date formats used by the Node.js API
+type Date struct {
+	time.Time
+}
+
+// UnmarshalJSON decodes a date string; null and empty strings leave the zero time
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var value *string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if value == nil || *value == "" {
+		d.Time = time.Time{}
+		return nil
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, *value); err == nil {
+			d.Time = t
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid date %q", *value)
+}
+
+// MarshalJSON encodes the date as RFC 3339, or null for the zero time
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.Time.Format(time.RFC3339Nano))
+}
@@ -0,0 +1,214 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Leave request status IDs used by the Node.js API
+const (
+	LeaveStatusOnReview  = 1
+	LeaveStatusApproved  = 2
+	LeaveStatusCancelled = 3
+)
+
+// AdminRoleID is the ID of the admin role in the Node.js API
+const AdminRoleID = 1
+
+// LeaveRequest represents a leave request returned by the Node.js API
+type LeaveRequest struct {
+	ID        int       `json:"id"`
+	Policy    string    `json:"policy"`
+	PolicyID  int       `json:"policyId"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Note      string    `json:"note"`
+	StatusID  int       `json:"statusId"`
+	Status    string    `json:"status"`
+	Submitted time.Time `json:"submitted"`
+	Updated   time.Time `json:"updated"`
+	Approved  time.Time `json:"approved"`
+	Approver  string    `json:"approver"`
+	User      string    `json:"user"`
+	// Department of the requester, sent with pending requests; empty when
+	// their profile has none
+	Department string `json:"department,omitempty"`
+	// UserID identifies the requester, sent with pending requests
+	UserID int `json:"userId,omitempty"`
+	// ReporterID and RoleID are those of the requester, sent with pending
+	// requests to tell who may review them
+	ReporterID int `json:"reporterId,omitempty"`
+	RoleID     int `json:"roleId,omitempty"`
+	// Days is the day count computed by the Node.js API, sent as a string or
+	// a number. It wraps around for requests longer than a month; use DayCount.
+	Days json.Number `json:"days"`
+}
+
+// LeaveHistory represents the leave history response from the API
+type LeaveHistory struct {
+	LeaveHistory []LeaveRequest `json:"leaveHistory"`
+}
+
+// PendingLeaveList represents the pending leave requests response from the API
+type PendingLeaveList struct {
+	PendingLeaves []LeaveRequest `json:"pendingLeaves"`
+}
+
+// ReviewableBy reports whether the user may approve or cancel a pending
+// request. It mirrors the check of the Node.js API: a request is reviewed by
+// the requester's reporter, or by anyone when the requester is an admin.
+func (r LeaveRequest) ReviewableBy(userID int) bool {
+	return r.RoleID == AdminRoleID || r.ReporterID == userID
+}
+
+// Overlaps reports whether r and other share at least one day
+func (r LeaveRequest) Overlaps(other LeaveRequest) bool {
+	return !dateOf(r.To).Before(dateOf(other.From)) && !dateOf(other.To).Before(dateOf(r.From))
+}
+
+// DayCount returns the number of calendar days the request covers, counting
+// both the first and the last day
+func (r LeaveRequest) DayCount() int {
+	return daysBetween(r.From, r.To) + 1
+}
+
+// DaysWithin returns the number of days of the request that fall within year
+func (r LeaveRequest) DaysWithin(year AcademicYear) int {
+	from, to := dateOf(r.From), dateOf(r.To)
+	if from.Before(year.Start) {
+		from = year.Start
+	}
+	if last := year.End.AddDate(0, 0, -1); to.After(last) {
+		to = last
+	}
+	if to.Before(from) {
+		return 0
+	}
+	return daysBetween(from, to) + 1
+}
+
+// LeavePolicy represents a leave policy assigned to a user
+type LeavePolicy struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// LeavePolicyList represents the leave policy list response from the API
+type LeavePolicyList struct {
+	LeavePolicies []LeavePolicy `json:"leavePolicies"`
+}
+
+// AcademicYear is the date range [Start, End) of a school year
+type AcademicYear struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// NewAcademicYear returns the academic year beginning on the first day of
+// startMonth in year
+func NewAcademicYear(year int, startMonth time.Month) AcademicYear {
+	start := time.Date(year, startMonth, 1, 0, 0, 0, 0, time.UTC)
+	return AcademicYear{Start: start, End: start.AddDate(1, 0, 0)}
+}
+
+// AcademicYearOf returns the academic year, beginning in startMonth, that
+// contains t
+func AcademicYearOf(t time.Time, startMonth time.Month) AcademicYear {
+	year := t.Year()
+	if t.Month() < startMonth {
+		year--
+	}
+	return NewAcademicYear(year, startMonth)
+}
+
+// String formats the academic year as "2024-25", or "2024" when it starts in January
+func (y AcademicYear) String() string {
+	if y.Start.Month() == time.January {
+		return fmt.Sprintf("%d", y.Start.Year())
+	}
+	return fmt.Sprintf("%d-%02d", y.Start.Year(), y.End.Year()%100)
+}
+
+// Overlaps reports whether any day of the leave request falls within the year
+func (y AcademicYear) Overlaps(r LeaveRequest) bool {
+	return r.DaysWithin(y) > 0
+}
+
+// LeavePolicySummary totals a user's leave under one policy for an academic year
+type LeavePolicySummary struct {
+	PolicyID int    `json:"policyId"`
+	Policy   string `json:"policy"`
+	Requests int    `json:"requests"`
+	// ApprovedDays are the days taken; PendingDays are still on review
+	ApprovedDays int `json:"approvedDays"`
+	PendingDays  int `json:"pendingDays"`
+}
+
+// LeaveReport is a user's leave requests in an academic year with the days
+// taken per policy
+type LeaveReport struct {
+	User         string               `json:"user"`
+	AcademicYear string               `json:"academicYear"`
+	Period       AcademicYear         `json:"period"`
+	Requests     []LeaveRequest       `json:"requests"`
+	Policies     []LeavePolicySummary `json:"policies"`
+	// UserID is the user the report was fetched for, or 0 when the caller's
+	// token carries no user ID
+	UserID int `json:"-"`
+}
+
+// SummarizeLeave totals the days of requests within year per policy. Every
+// policy in policies is listed, even without requests, followed by policies
+// that only appear in the requests.
+func SummarizeLeave(requests []LeaveRequest, policies []LeavePolicy, year AcademicYear) []LeavePolicySummary {
+	var summaries []LeavePolicySummary
+	index := make(map[int]int)
+	add := func(id int, name string) int {
+		if i, ok := index[id]; ok {
+			return i
+		}
+		index[id] = len(summaries)
+		summaries = append(summaries, LeavePolicySummary{PolicyID: id, Policy: name})
+		return index[id]
+	}
+
+	for _, policy := range policies {
+		add(policy.ID, policy.Name)
+	}
+	assigned := len(summaries)
+
+	for _, request := range requests {
+		days := request.DaysWithin(year)
+		if days == 0 {
+			continue
+		}
+
+		summary := &summaries[add(request.PolicyID, request.Policy)]
+		summary.Requests++
+		switch request.StatusID {
+		case LeaveStatusApproved:
+			summary.ApprovedDays += days
+		case LeaveStatusOnReview:
+			summary.PendingDays += days
+		}
+	}
+
+	// Keep unassigned policies in a stable order after the assigned ones
+	extra := summaries[assigned:]
+	sort.Slice(extra, func(i, j int) bool { return extra[i].Policy < extra[j].Policy })
+
+	return summaries
+}
+
+// dateOf returns the calendar date of t as midnight UTC
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// daysBetween returns the number of calendar days from a to b
+func daysBetween(a, b time.Time) int {
+	return int(math.Round(dateOf(b).Sub(dateOf(a)).Hours() / 24))
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// LeaveDigest groups pending leave requests for an approver by requester and
+// policy, and lists the absences that overlap within a department
+type LeaveDigest struct {
+	// ApproverID is the user the digest was built for
+	ApproverID  int                    `json:"approverId"`
+	GeneratedAt time.Time              `json:"generatedAt"`
+	Requesters  []LeaveDigestRequester `json:"requesters"`
+	Overlaps    []LeaveOverlap         `json:"overlaps"`
+	// Requests is the total number of pending requests
+	Requests int `json:"requests"`
+}
+
+// LeaveDigestRequester holds the pending requests of one requester
+type LeaveDigestRequester struct {
+	UserID     int                 `json:"userId"`
+	Name       string              `json:"name"`
+	Department string              `json:"department"`
+	Policies   []LeaveDigestPolicy `json:"policies"`
+}
+
+// LeaveDigestPolicy holds a requester's pending requests under one policy
+type LeaveDigestPolicy struct {
+	Policy   string             `json:"policy"`
+	Requests []LeaveDigestEntry `json:"requests"`
+	// Days is the total day count of the requests
+	Days int `json:"days"`
+}
+
+// LeaveDigestEntry is a pending request with the names of the other
+// requesters in the same department who would be absent at the same time
+type LeaveDigestEntry struct {
+	LeaveRequest
+	OverlapsWith []string `json:"overlapsWith"`
+}
+
+// LeaveOverlap is a period in which two requesters of the same department
+// have asked to be absent
+type LeaveOverlap struct {
+	Department string       `json:"department"`
+	From       time.Time    `json:"from"`
+	To         time.Time    `json:"to"`
+	First      LeaveRequest `json:"first"`
+	Second     LeaveRequest `json:"second"`
+}
+
+// BuildLeaveDigest groups the pending requests the approver may review by
+// requester, identified by user ID, and then policy, both sorted by name,
+// with each policy's requests in date order. Requests of different
+// requesters overlap when they share a day and a department; requests
+// without a department overlap with none.
+func BuildLeaveDigest(requests []LeaveRequest, approverID int, generatedAt time.Time) LeaveDigest {
+	digest := LeaveDigest{ApproverID: approverID, GeneratedAt: generatedAt}
+
+	var sorted []LeaveRequest
+	for _, request := range requests {
+		if request.ReviewableBy(approverID) {
+			sorted = append(sorted, request)
+		}
+	}
+	digest.Requests = len(sorted)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].From.Before(sorted[j].From) })
+
+	// Find the overlapping absences, in date order
+	overlapsWith := make(map[int][]string)
+	for i, first := range sorted {
+		for _, second := range sorted[i+1:] {
+			if first.UserID == second.UserID || first.Department == "" || first.Department != second.Department || !first.Overlaps(second) {
+				continue
+			}
+
+			from, to := second.From, first.To
+			if second.To.Before(to) {
+				to = second.To
+			}
+			digest.Overlaps = append(digest.Overlaps, LeaveOverlap{
+				Department: first.Department,
+				From:       dateOf(from),
+				To:         dateOf(to),
+				First:      first,
+				Second:     second,
+			})
+			overlapsWith[first.ID] = appendUnique(overlapsWith[first.ID], second.User)
+			overlapsWith[second.ID] = appendUnique(overlapsWith[second.ID], first.User)
+		}
+	}
+
+	// Group by requester, then policy
+	requesters := make(map[int]*LeaveDigestRequester)
+	for _, request := range sorted {
+		requester, ok := requesters[request.UserID]
+		if !ok {
+			requester = &LeaveDigestRequester{UserID: request.UserID, Name: request.User, Department: request.Department}
+			requesters[request.UserID] = requester
+		}
+
+		var policy *LeaveDigestPolicy
+		for i := range requester.Policies {
+			if requester.Policies[i].Policy == request.Policy {
+				policy = &requester.Policies[i]
+			}
+		}
+		if policy == nil {
+			requester.Policies = append(requester.Policies, LeaveDigestPolicy{Policy: request.Policy})
+			policy = &requester.Policies[len(requester.Policies)-1]
+		}
+
+		policy.Requests = append(policy.Requests, LeaveDigestEntry{LeaveRequest: request, OverlapsWith: overlapsWith[request.ID]})
+		policy.Days += request.DayCount()
+	}
+
+	for _, requester := range requesters {
+		sort.Slice(requester.Policies, func(i, j int) bool { return requester.Policies[i].Policy < requester.Policies[j].Policy })
+		digest.Requesters = append(digest.Requesters, *requester)
+	}
+	sort.Slice(digest.Requesters, func(i, j int) bool {
+		first, second := digest.Requesters[i], digest.Requesters[j]
+		if first.Name != second.Name {
+			return first.Name < second.Name
+		}
+		return first.UserID < second.UserID
+	})
+
+	return digest
+}
+
+// appendUnique appends value to values unless it is already present
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
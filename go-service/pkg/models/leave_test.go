@@ -0,0 +1,154 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// date returns midnight UTC of the given day
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// TestAcademicYear tests academic year boundaries and formatting
+func TestAcademicYear(t *testing.T) {
+	year := AcademicYearOf(date(2025, time.February, 10), time.April)
+	if !year.Start.Equal(date(2024, time.April, 1)) || !year.End.Equal(date(2025, time.April, 1)) {
+		t.Errorf("Expected April 2024 to April 2025, got %v to %v", year.Start, year.End)
+	}
+	if year.String() != "2024-25" {
+		t.Errorf("Expected 2024-25, got %s", year.String())
+	}
+
+	if calendar := NewAcademicYear(2025, time.January); calendar.String() != "2025" {
+		t.Errorf("Expected 2025, got %s", calendar.String())
+	}
+}
+
+// TestLeaveDays tests day counts of requests, including requests longer
+// than a month and requests that cross the academic year boundary
+func TestLeaveDays(t *testing.T) {
+	year := NewAcademicYear(2024, time.April)
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		total    int
+		within   int
+	}{
+		{"single day", date(2024, time.May, 6), date(2024, time.May, 6), 1, 1},
+		{"longer than a month", date(2024, time.June, 1), date(2024, time.July, 15), 45, 45},
+		{"starts before the year", date(2024, time.March, 30), date(2024, time.April, 2), 4, 2},
+		{"ends after the year", date(2025, time.March, 31), date(2025, time.April, 1), 2, 1},
+		{"outside the year", date(2025, time.May, 1), date(2025, time.May, 2), 2, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := LeaveRequest{From: tt.from, To: tt.to}
+			if got := request.DayCount(); got != tt.total {
+				t.Errorf("Expected %d days, got %d", tt.total, got)
+			}
+			if got := request.DaysWithin(year); got != tt.within {
+				t.Errorf("Expected %d days within the year, got %d", tt.within, got)
+			}
+		})
+	}
+}
+
+// TestSummarizeLeave tests per-policy totals for an academic year
+func TestSummarizeLeave(t *testing.T) {
+	year := NewAcademicYear(2024, time.April)
+	policies := []LeavePolicy{{ID: 1, Name: "Sick Leave"}, {ID: 2, Name: "Annual Leave"}, {ID: 3, Name: "Study Leave"}}
+	requests := []LeaveRequest{
+		{PolicyID: 1, Policy: "Sick Leave", From: date(2024, time.May, 6), To: date(2024, time.May, 7), StatusID: LeaveStatusApproved},
+		{PolicyID: 1, Policy: "Sick Leave", From: date(2024, time.June, 3), To: date(2024, time.June, 3), StatusID: LeaveStatusOnReview},
+		{PolicyID: 2, Policy: "Annual Leave", From: date(2024, time.August, 1), To: date(2024, time.August, 5), StatusID: LeaveStatusCancelled},
+		{PolicyID: 2, Policy: "Annual Leave", From: date(2023, time.August, 1), To: date(2023, time.August, 5), StatusID: LeaveStatusApproved},
+		{PolicyID: 9, Policy: "Unpaid Leave", From: date(2024, time.December, 23), To: date(2024, time.December, 24), StatusID: LeaveStatusApproved},
+	}
+
+	summaries := SummarizeLeave(requests, policies, year)
+
+	want := []LeavePolicySummary{
+		{PolicyID: 1, Policy: "Sick Leave", Requests: 2, ApprovedDays: 2, PendingDays: 1},
+		{PolicyID: 2, Policy: "Annual Leave", Requests: 1},
+		{PolicyID: 3, Policy: "Study Leave"},
+		{PolicyID: 9, Policy: "Unpaid Leave", Requests: 1, ApprovedDays: 2},
+	}
+	if len(summaries) != len(want) {
+		t.Fatalf("Expected %d summaries, got %d: %+v", len(want), len(summaries), summaries)
+	}
+	for i := range want {
+		if summaries[i] != want[i] {
+			t.Errorf("Summary %d: expected %+v, got %+v", i, want[i], summaries[i])
+		}
+	}
+}
+
+// TestBuildLeaveDigest tests grouping the pending requests an approver may
+// review and finding overlapping absences within a department
+func TestBuildLeaveDigest(t *testing.T) {
+	requests := []LeaveRequest{
+		{ID: 1, User: "Edna Krabappel", UserID: 21, Department: "Primary", ReporterID: 7, Policy: "Sick Leave", From: date(2024, time.May, 6), To: date(2024, time.May, 8)},
+		{ID: 2, User: "Elizabeth Hoover", UserID: 22, Department: "Primary", ReporterID: 7, Policy: "Annual Leave", From: date(2024, time.May, 8), To: date(2024, time.May, 10)},
+		{ID: 3, User: "Dewey Largo", UserID: 23, Department: "Music", ReporterID: 8, RoleID: AdminRoleID, Policy: "Annual Leave", From: date(2024, time.May, 7), To: date(2024, time.May, 7)},
+		{ID: 4, User: "Edna Krabappel", UserID: 21, Department: "Primary", ReporterID: 7, Policy: "Annual Leave", From: date(2024, time.June, 3), To: date(2024, time.June, 4)},
+		{ID: 5, User: "Edna Krabappel", UserID: 21, Department: "Primary", ReporterID: 7, Policy: "Sick Leave", From: date(2024, time.April, 1), To: date(2024, time.April, 1)},
+		// Reports to someone else, so the approver may not review it
+		{ID: 6, User: "Seymour Skinner", UserID: 24, Department: "Primary", ReporterID: 8, Policy: "Sick Leave", From: date(2024, time.May, 8), To: date(2024, time.May, 8)},
+	}
+
+	digest := BuildLeaveDigest(requests, 7, date(2024, time.May, 1))
+
+	if digest.ApproverID != 7 {
+		t.Errorf("Expected the digest to be built for approver 7, got %d", digest.ApproverID)
+	}
+	if digest.Requests != 5 || len(digest.Requesters) != 3 {
+		t.Fatalf("Expected 5 requests from 3 requesters, got %d from %d", digest.Requests, len(digest.Requesters))
+	}
+
+	edna := digest.Requesters[1]
+	if edna.Name != "Edna Krabappel" || len(edna.Policies) != 2 || edna.Policies[0].Policy != "Annual Leave" {
+		t.Fatalf("Unexpected requester grouping: %+v", edna)
+	}
+	sick := edna.Policies[1]
+	if len(sick.Requests) != 2 || sick.Requests[0].ID != 5 || sick.Days != 4 {
+		t.Errorf("Expected 2 sick leave requests in date order totalling 4 days, got %+v", sick)
+	}
+	if overlaps := sick.Requests[1].OverlapsWith; len(overlaps) != 1 || overlaps[0] != "Elizabeth Hoover" {
+		t.Errorf("Expected the May sick leave to overlap with Elizabeth Hoover, got %v", overlaps)
+	}
+
+	// Dewey Largo is absent on the same day but in another department
+	if len(digest.Overlaps) != 1 {
+		t.Fatalf("Expected a single overlap, got %+v", digest.Overlaps)
+	}
+	overlap := digest.Overlaps[0]
+	if overlap.Department != "Primary" || !overlap.From.Equal(date(2024, time.May, 8)) || !overlap.To.Equal(date(2024, time.May, 8)) {
+		t.Errorf("Unexpected overlap: %+v", overlap)
+	}
+}
+
+// TestLeaveDigestRequesters tests that requesters are told apart by user ID
+// rather than name, and that requests without a department overlap with none
+func TestLeaveDigestRequesters(t *testing.T) {
+	requests := []LeaveRequest{
+		{ID: 1, User: "Jane Smith", UserID: 31, Department: "Science", ReporterID: 7, Policy: "Sick Leave", From: date(2024, time.May, 6), To: date(2024, time.May, 8)},
+		{ID: 2, User: "Jane Smith", UserID: 32, Department: "Science", ReporterID: 7, Policy: "Sick Leave", From: date(2024, time.May, 7), To: date(2024, time.May, 7)},
+		{ID: 3, User: "Otto Mann", UserID: 33, ReporterID: 7, Policy: "Annual Leave", From: date(2024, time.May, 6), To: date(2024, time.May, 8)},
+		{ID: 4, User: "Willie MacDougal", UserID: 34, ReporterID: 7, Policy: "Annual Leave", From: date(2024, time.May, 7), To: date(2024, time.May, 7)},
+	}
+
+	digest := BuildLeaveDigest(requests, 7, date(2024, time.May, 1))
+
+	if len(digest.Requesters) != 4 {
+		t.Fatalf("Expected 4 requesters, got %+v", digest.Requesters)
+	}
+	if first, second := digest.Requesters[0], digest.Requesters[1]; first.UserID != 31 || second.UserID != 32 {
+		t.Errorf("Expected both requesters named Jane Smith in user ID order, got %d and %d", first.UserID, second.UserID)
+	}
+	if len(digest.Overlaps) != 1 || digest.Overlaps[0].First.ID != 1 || digest.Overlaps[0].Second.ID != 2 {
+		t.Errorf("Expected only the requests of the two Jane Smiths to overlap, got %+v", digest.Overlaps)
+	}
+}
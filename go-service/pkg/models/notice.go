@@ -0,0 +1,102 @@
+package models
+
+import "time"
+
+// Notice status IDs used by the Node.js API
+const (
+	NoticeStatusDraft    = 1
+	NoticeStatusApproved = 5
+)
+
+// Notice recipient types used by the Node.js API
+const (
+	// NoticeRecipientEveryone addresses a notice to every user
+	NoticeRecipientEveryone = "EV"
+	// NoticeRecipientSpecific addresses a notice to one role, optionally
+	// narrowed to a department (staff) or class (students)
+	NoticeRecipientSpecific = "SP"
+)
+
+// Notice represents a notice in the Node.js API notice list. The list does not
+// include the recipients; they are filled in from the NoticeDetail.
+type Notice struct {
+	ID           int       `json:"id"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	AuthorID     int       `json:"authorId"`
+	Author       string    `json:"author"`
+	CreatedDate  time.Time `json:"createdDate"`
+	UpdatedDate  time.Time `json:"updatedDate"`
+	ReviewerName string    `json:"reviewerName"`
+	ReviewedDate time.Time `json:"reviewedDate"`
+	// Status is the status name; StatusID is one of the NoticeStatus IDs
+	Status   string `json:"status"`
+	StatusID int    `json:"statusId"`
+
+	RecipientType string `json:"recipientType"`
+	RecipientRole int    `json:"recipientRole"`
+	// FirstField is the department ID or class name narrowing the recipients
+	FirstField string `json:"firstField"`
+}
+
+// NoticeList represents the notice list response from the API
+type NoticeList struct {
+	Notices []Notice `json:"notices"`
+}
+
+// NoticeDetail represents a single notice returned by the Node.js API, which
+// adds the recipients to the list fields but omits the reviewer
+type NoticeDetail struct {
+	ID            int       `json:"id"`
+	Title         string    `json:"title"`
+	Description   string    `json:"description"`
+	Status        int       `json:"status"`
+	AuthorID      int       `json:"authorId"`
+	Author        string    `json:"author"`
+	CreatedDate   time.Time `json:"createdDate"`
+	UpdatedDate   time.Time `json:"updatedDate"`
+	RecipientType string    `json:"recipientType"`
+	RecipientRole int       `json:"recipientRole"`
+	FirstField    string    `json:"firstField"`
+}
+
+// PublishedDate returns the date the notice was approved, or the date it was
+// created if the approval date is unknown
+func (n Notice) PublishedDate() time.Time {
+	if !n.ReviewedDate.IsZero() {
+		return n.ReviewedDate
+	}
+	return n.CreatedDate
+}
+
+// AddressedTo reports whether the notice reaches users of the given role
+func (n Notice) AddressedTo(roleID int) bool {
+	return n.RecipientType == NoticeRecipientEveryone || n.RecipientRole == roleID
+}
+
+// Role represents a role returned by the Node.js API
+type Role struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// RoleList represents the role list response from the API
+type RoleList struct {
+	Roles []Role `json:"roles"`
+}
+
+// NoticeBulletin is the set of approved notices printed on a bulletin board
+type NoticeBulletin struct {
+	// From and To are the first and last day of the notices' published dates
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Audience describes whom the notices were selected for, e.g. "Teacher"
+	Audience string           `json:"audience"`
+	Notices  []BulletinNotice `json:"notices"`
+}
+
+// BulletinNotice is a notice with a description of its recipients
+type BulletinNotice struct {
+	Notice
+	Recipients string `json:"recipients"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Staff represents the staff profile returned by the Node.js API
+type Staff struct {
+	ID               int       `json:"id"`
+	Name             string    `json:"name"`
+	Email            string    `json:"email"`
+	SystemAccess     bool      `json:"systemAccess"`
+	Role             int       `json:"role"`
+	RoleName         string    `json:"roleName"`
+	Department       string    `json:"department"`
+	ReporterID       int       `json:"reporterId"`
+	ReporterName     string    `json:"reporterName"`
+	Gender           string    `json:"gender"`
+	MaritalStatus    string    `json:"maritalStatus"`
+	JoinDate         time.Time `json:"joinDate"`
+	Qualification    string    `json:"qualification"`
+	Experience       string    `json:"experience"`
+	DOB              time.Time `json:"dob"`
+	Phone            string    `json:"phone"`
+	FatherName       string    `json:"fatherName"`
+	MotherName       string    `json:"motherName"`
+	EmergencyPhone   string    `json:"emergencyPhone"`
+	CurrentAddress   string    `json:"currentAddress"`
+	PermanentAddress string    `json:"permanentAddress"`
+}
+
+// StaffSummary represents a staff member in the Node.js API staff list, which
+// only returns summary fields and names the role instead of giving its ID
+type StaffSummary struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	Email        string    `json:"email"`
+	Role         string    `json:"role"`
+	SystemAccess bool      `json:"systemAccess"`
+	LastLogin    time.Time `json:"lastLogin"`
+}
+
+// StaffList represents the staff list response from the API
+type StaffList struct {
+	Staffs []StaffSummary `json:"staffs"`
+}
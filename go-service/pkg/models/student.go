@@ -28,4 +28,15 @@ type Student struct {
 }
 
 // StudentList represents a list of students from the API
-type StudentList []Student 
\ No newline at end of file
+type StudentList []Student 
+
+// ClassRoster is the students of a class section
+type ClassRoster struct {
+	Class    string    `json:"class"`
+	Section  string    `json:"section"`
+	Students []Student `json:"students"`
+	// NameFilter and RollFilter are the name and roll number filters the
+	// students were listed with, empty for the whole section
+	NameFilter string `json:"-"`
+	RollFilter string `json:"-"`
+}